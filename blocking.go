@@ -0,0 +1,189 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BLPop pops the first element of the first non-empty list of keys, waiting for one to be pushed when they
+// are all empty, and returns the key it was popped from with the element. It waits until the deadline of
+// ctx, forever without one, and returns ErrNil when nothing was pushed in time.
+//
+// The command runs on a connection of its own, closed afterwards, so a long wait doesn't hold a pooled one.
+func (client *Client) BLPop(ctx context.Context, keys ...string) (string, string, error) {
+	return client.blockingPop(ctx, "BLPOP", keys)
+}
+
+// BRPop is like BLPop, popping the last element.
+func (client *Client) BRPop(ctx context.Context, keys ...string) (string, string, error) {
+	return client.blockingPop(ctx, "BRPOP", keys)
+}
+
+func (client *Client) blockingPop(ctx context.Context, command string, keys []string) (string, string, error) {
+	if len(keys) == 0 {
+		return "", "", fmt.Errorf("%s: no key to pop from", strings.ToLower(command))
+	}
+	reply, err := client.doBlocking(ctx, func(wait time.Duration) []interface{} {
+		return append(keyArgs(command, keys), timeoutArg(wait))
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if reply.IsNil() {
+		return "", "", ErrNil
+	}
+	parts, err := reply.StringSlice()
+	if err != nil || len(parts) != 2 {
+		return "", "", fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return parts[0], parts[1], nil
+}
+
+// BLMove is the blocking LMove: it waits for source to get an element like BLPop (Redis 6.2+).
+func (client *Client) BLMove(ctx context.Context, source string, destination string, from ListSide, to ListSide) (string, error) {
+	reply, err := client.doBlocking(ctx, func(wait time.Duration) []interface{} {
+		return []interface{}{"BLMOVE", source, destination, string(from), string(to), timeoutArg(wait)}
+	})
+	if err != nil {
+		return "", err
+	}
+	return reply.Text()
+}
+
+// BZPopMin pops the member with the lowest score from the first non-empty sorted set of keys, waiting like
+// BLPop, and returns the key it was popped from with the member.
+func (client *Client) BZPopMin(ctx context.Context, keys ...string) (string, Member, error) {
+	return client.blockingZPop(ctx, "BZPOPMIN", keys)
+}
+
+// BZPopMax is like BZPopMin, popping the highest score.
+func (client *Client) BZPopMax(ctx context.Context, keys ...string) (string, Member, error) {
+	return client.blockingZPop(ctx, "BZPOPMAX", keys)
+}
+
+func (client *Client) blockingZPop(ctx context.Context, command string, keys []string) (string, Member, error) {
+	if len(keys) == 0 {
+		return "", Member{}, fmt.Errorf("%s: no key to pop from", strings.ToLower(command))
+	}
+	reply, err := client.doBlocking(ctx, func(wait time.Duration) []interface{} {
+		return append(keyArgs(command, keys), timeoutArg(wait))
+	})
+	if err != nil {
+		return "", Member{}, err
+	}
+	if reply.IsNil() {
+		return "", Member{}, ErrNil
+	}
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 3 {
+		return "", Member{}, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	key, err := parts[0].Text()
+	if err != nil {
+		return "", Member{}, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	member, err := newMember(parts[1], parts[2])
+	if err != nil {
+		return "", Member{}, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return key, member, nil
+}
+
+// BZMPop is the blocking ZMPop, waiting like BLPop (Redis 7.0+).
+func (client *Client) BZMPop(ctx context.Context, order ZPopOrder, count int, keys ...string) (ZPopResult, error) {
+	if len(keys) == 0 {
+		return ZPopResult{}, errors.New("bzmpop: no key to pop from")
+	}
+	reply, err := client.doBlocking(ctx, func(wait time.Duration) []interface{} {
+		return append([]interface{}{"BZMPOP", timeoutArg(wait)}, mpopArgs(keys, string(order), count)...)
+	})
+	if err != nil {
+		return ZPopResult{}, err
+	}
+	return parseZPop("bzmpop", reply)
+}
+
+// blockingReadMargin is how long a blocking command waits for the reply past the server timeout, so the
+// server answers nil, rather than the read being cut short while an element may be on its way.
+const blockingReadMargin = 500 * time.Millisecond
+
+// doBlocking runs the blocking command returned by args for the time left until the deadline of ctx, 0
+// (forever) without one, on a new connection. The reply is read until a little past the deadline, see
+// blockingReadMargin, cancelling ctx still interrupts it. The deadline only means no element once the
+// command was written, failing to dial or write by then returns the error as is.
+func (client *Client) doBlocking(ctx context.Context, args func(wait time.Duration) []interface{}) (*Reply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	conn, err := client.dialBlocking(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var wait time.Duration
+	readCtx := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		if wait = time.Until(deadline); wait < time.Millisecond {
+			return nil, context.DeadlineExceeded // too late for the smallest server timeout
+		}
+		var cancelRead context.CancelFunc
+		readCtx, cancelRead = context.WithDeadline(readCtx, deadline.Add(blockingReadMargin))
+		defer cancelRead()
+	}
+	readCtx, cancel := context.WithCancel(readCtx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			cancel()
+		}
+	})
+	defer stop()
+
+	if err := conn.SendCommand(readCtx, args(wait)...); err != nil {
+		return nil, cancelErr(ctx, err)
+	}
+	value, err := conn.ReceiveValue(readCtx)
+	if err != nil {
+		return nil, blockingErr(ctx, cancelErr(ctx, err))
+	}
+	return NewReply(value), nil
+}
+
+// dialBlocking opens a connection for blocking commands and subscriptions, without a read timeout: the reply
+// can take as long as the server waits, ctx still applies.
+func (client *Client) dialBlocking(ctx context.Context) (IConnection, error) {
+	opts := *client.opts
+	opts.ReadTimeout = -1
+	return client.dialWith(ctx, client.address, opts)
+}
+
+// timeoutArg formats wait as the TIMEOUT argument of a blocking command, in seconds to the millisecond
+// (Redis 6.0+). It is rounded down so the server gives up before the deadline, 0 (forever) stays 0.
+func timeoutArg(wait time.Duration) string {
+	if wait <= 0 {
+		return "0"
+	}
+	wait = max(wait.Truncate(time.Millisecond), time.Millisecond)
+	return strconv.FormatFloat(wait.Seconds(), 'f', 3, 64)
+}
+
+// cancelErr returns the error of ctx instead of err once it was cancelled, the read or write was interrupted.
+func cancelErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); errors.Is(ctxErr, context.Canceled) {
+		return ctxErr
+	}
+	return err
+}
+
+// blockingErr returns ErrNil when err, reading the reply, is the deadline of ctx: the server timed out as well.
+func blockingErr(ctx context.Context, err error) error {
+	if deadline, ok := ctx.Deadline(); ok && errors.Is(err, context.DeadlineExceeded) && !time.Now().Before(deadline) {
+		return ErrNil
+	}
+	return err
+}
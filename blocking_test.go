@@ -0,0 +1,221 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serveBlocking answers the blocking command read on server with reply and returns its arguments.
+func serveBlocking(t *testing.T, servers <-chan *Connection, reply string) <-chan []string {
+	received := make(chan []string, 1)
+	go func() {
+		server := <-servers
+		value, err := server.ReceiveValue(context.Background())
+		if err != nil {
+			t.Errorf("server failed to read the command: %v", err)
+			close(received)
+			return
+		}
+		args, _ := NewReply(value).StringSlice()
+		received <- args
+		if reply != "" {
+			_, _ = server.rw.WriteString(reply)
+			_ = server.rw.Flush()
+		}
+		// the connection is closed once the reply is read
+		if _, err := server.ReceiveValue(context.Background()); !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("server read %v, want the connection closed", err)
+		}
+	}()
+	return received
+}
+
+// checkTimeout checks that timeout, the TIMEOUT argument of a blocking command, is a little less than wait.
+func checkTimeout(t *testing.T, timeout string, wait time.Duration) {
+	t.Helper()
+	seconds, err := strconv.ParseFloat(timeout, 64)
+	if got := time.Duration(seconds * float64(time.Second)); err != nil || got > wait || got < wait-100*time.Millisecond {
+		t.Errorf("sent the timeout %q, want a little less than %v", timeout, wait)
+	}
+}
+
+func TestClient_BLPop(t *testing.T) {
+	client, servers := newPipeClient("")
+	received := serveBlocking(t, servers, "*2\r\n$2\r\nq2\r\n$3\r\njob\r\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+	key, element, err := client.BLPop(ctx, "q1", "q2")
+	if err != nil || key != "q2" || element != "job" {
+		t.Errorf("BLPop() got = %q, %q, %v", key, element, err)
+	}
+	args := <-received
+	if want := []string{"BLPOP", "q1", "q2"}; len(args) != 4 || !reflect.DeepEqual(args[:3], want) {
+		t.Errorf("BLPop() sent %q, want %q and the timeout", args, want)
+	} else {
+		checkTimeout(t, args[3], 2500*time.Millisecond)
+	}
+	if stats := client.PoolStats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("PoolStats() got %+v, want the pool unused", stats)
+	}
+}
+
+func TestClient_BRPopNoDeadline(t *testing.T) {
+	client, servers := newPipeClient("")
+	received := serveBlocking(t, servers, "*-1\r\n")
+
+	if _, _, err := client.BRPop(context.Background(), "q"); !errors.Is(err, ErrNil) {
+		t.Errorf("BRPop() error = %v, want %v", err, ErrNil)
+	}
+	if args, want := <-received, []string{"BRPOP", "q", "0"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("BRPop() sent %q, want %q", args, want)
+	}
+}
+
+func TestClient_BLMoveDeadline(t *testing.T) {
+	client, servers := newPipeClient("")
+	received := serveBlocking(t, servers, "") // the server never answers
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.BLMove(ctx, "queue", "processing", ListRight, ListLeft); !errors.Is(err, ErrNil) {
+		t.Errorf("BLMove() error = %v, want %v once the deadline passes", err, ErrNil)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("BLMove() took %v, want the context deadline", elapsed)
+	}
+	args := <-received
+	if want := []string{"BLMOVE", "queue", "processing", "RIGHT", "LEFT"}; len(args) != 6 || !reflect.DeepEqual(args[:5], want) {
+		t.Errorf("BLMove() sent %q, want %q and the timeout", args, want)
+	} else {
+		checkTimeout(t, args[5], 50*time.Millisecond)
+	}
+}
+
+func TestClient_BLPopCancel(t *testing.T) {
+	client, servers := newPipeClient("")
+	received := serveBlocking(t, servers, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-received
+		cancel()
+	}()
+	if _, _, err := client.BLPop(ctx, "q"); !errors.Is(err, context.Canceled) {
+		t.Errorf("BLPop() error = %v, want %v", err, context.Canceled)
+	}
+	if _, _, err := client.BLPop(context.Background()); err == nil {
+		t.Error("BLPop() expected an error without keys")
+	}
+}
+
+func TestClient_BZPopMin(t *testing.T) {
+	client, servers := newPipeClient("")
+	received := serveBlocking(t, servers, "*3\r\n$4\r\njobs\r\n$3\r\nada\r\n$3\r\n1.5\r\n")
+
+	key, member, err := client.BZPopMin(context.Background(), "jobs")
+	if err != nil || key != "jobs" || member != (Member{Value: "ada", Score: 1.5}) {
+		t.Errorf("BZPopMin() got = %q, %+v, %v", key, member, err)
+	}
+	if args, want := <-received, []string{"BZPOPMIN", "jobs", "0"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("BZPopMin() sent %q, want %q", args, want)
+	}
+}
+
+func TestClient_BZMPop(t *testing.T) {
+	client, servers := newPipeClient("")
+	received := serveBlocking(t, servers, "*2\r\n$2\r\nq2\r\n*1\r\n*2\r\n$3\r\nbob\r\n$1\r\n9\r\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	got, err := client.BZMPop(ctx, ZPopMax, 1, "q1", "q2")
+	if want := (ZPopResult{Key: "q2", Members: []Member{{Value: "bob", Score: 9}}}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("BZMPop() got = %+v, %v, want %+v", got, err, want)
+	}
+	args := <-received
+	if want := []string{"BZMPOP", "2", "q1", "q2", "MAX", "COUNT", "1"}; len(args) != 8 || !reflect.DeepEqual(append(args[:1:1], args[2:]...), want) {
+		t.Errorf("BZMPop() sent %q, want %q with the timeout first", args, want)
+	} else {
+		checkTimeout(t, args[1], 1500*time.Millisecond)
+	}
+}
+
+func TestClient_BZPopMaxDeadline(t *testing.T) {
+	client, servers := newPipeClient("")
+	received := serveBlocking(t, servers, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := client.BZPopMax(ctx, "jobs"); !errors.Is(err, ErrNil) {
+		t.Errorf("BZPopMax() error = %v, want %v once the deadline passes", err, ErrNil)
+	}
+	<-received
+}
+
+func TestClient_BLPopUnreachable(t *testing.T) {
+	client := newMockClient(1, "")
+	// the server never accepts the connection, the dial gives up at the deadline
+	client.opts.Dialer = &MockDialer{DialFunc: func(ctx context.Context, network string, address string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := client.BLPop(ctx, "q"); err == nil || errors.Is(err, ErrNil) {
+		t.Errorf("BLPop() error = %v, want the dial error", err)
+	}
+	if _, _, err := client.BLPop(ctx, "q"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("BLPop() error = %v, want %v after the deadline", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClient_BLPopServerTimeout(t *testing.T) {
+	client, servers := newPipeClient("")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server := <-servers
+		if _, err := server.ReceiveValue(context.Background()); err != nil {
+			t.Errorf("server failed to read the command: %v", err)
+			return
+		}
+		// the server times out right at the deadline, its nil reply ends the call
+		time.Sleep(120 * time.Millisecond)
+		_, _ = server.rw.WriteString("*-1\r\n")
+		if err := server.rw.Flush(); err != nil {
+			t.Errorf("the client stopped reading before the server timed out: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, _, err := client.BLPop(ctx, "q"); !errors.Is(err, ErrNil) {
+		t.Errorf("BLPop() error = %v, want %v", err, ErrNil)
+	}
+	<-done
+}
+
+func TestTimeoutArg(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want string
+	}{
+		{wait: 0, want: "0"},
+		{wait: 2*time.Second + 499999*time.Microsecond, want: "2.499"},
+		{wait: 1500 * time.Microsecond, want: "0.001"},
+		{wait: 200 * time.Microsecond, want: "0.001"},
+	}
+	for _, tt := range tests {
+		if got := timeoutArg(tt.wait); got != tt.want {
+			t.Errorf("timeoutArg(%v) = %q, want %q", tt.wait, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned right away, without reaching the server, while the circuit breaker of
+// a client is open, see Options.CircuitBreakerThreshold.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// DefaultCircuitBreakerCooldown is how long an open circuit breaker fails fast when no cooldown is configured.
+const DefaultCircuitBreakerCooldown = 5 * time.Second
+
+// circuitBreaker stops sending commands to a server after threshold consecutive failures, so callers fail
+// fast instead of piling up on timeouts while it is down. Once cooldown has passed the next caller probes
+// the server with PING: the breaker closes again when it replies, and stays open for another cooldown otherwise.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int       // consecutive failures
+	openUntil time.Time // zero while closed
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow returns nil when a command can be sent, running probe first when the cooldown is over,
+// or an error wrapping ErrCircuitOpen. A nil breaker allows everything.
+func (b *circuitBreaker) allow(ctx context.Context, probe func(ctx context.Context) error) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	if b.openUntil.IsZero() {
+		b.mu.Unlock()
+		return nil
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		b.mu.Unlock()
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	b.mu.Unlock()
+
+	err := probe(ctx)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err != nil {
+		b.openUntil = time.Now().Add(b.cooldown)
+		return fmt.Errorf("%w: %w", ErrCircuitOpen, err)
+	}
+	b.failures = 0
+	b.openUntil = time.Time{}
+	return nil
+}
+
+// record counts the outcome of a command, failed tells whether the server couldn't be reached.
+func (b *circuitBreaker) record(failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold && b.openUntil.IsZero() {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// isServerFailure reports whether a command failed because the server couldn't be reached or didn't reply
+// in time, as opposed to an error reply, an exhausted pool or a caller giving up. A deadline of ctx counts
+// once the command was written: the server got it and didn't answer before the caller gave up. The
+// deadlines of Options.ReadTimeout and WriteTimeout always count, ctx is still alive then.
+func isServerFailure(ctx context.Context, err error) bool {
+	if err == nil || isReplyError(err) || errors.Is(err, ErrPoolExhausted) || errors.Is(err, ErrClosed) ||
+		errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var timeout *timeoutError
+	return ctx.Err() == nil || (errors.As(err, &timeout) && timeout.reply && errors.Is(ctx.Err(), context.DeadlineExceeded))
+}
+
+// isOpen reports whether the breaker is open, failing commands fast. A nil breaker is never open.
+func (b *circuitBreaker) isOpen() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero()
+}
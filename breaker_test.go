@@ -0,0 +1,135 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := newCircuitBreaker(2, 20*time.Millisecond)
+	probed := 0
+	probeErr := errors.New("connection refused")
+	probe := func(ctx context.Context) error {
+		probed++
+		return probeErr
+	}
+
+	b.record(true)
+	b.record(false) // failures must be consecutive
+	b.record(true)
+	if err := b.allow(context.Background(), probe); err != nil {
+		t.Fatalf("allow() error = %v after a single consecutive failure", err)
+	}
+	b.record(true)
+	if err := b.allow(context.Background(), probe); !errors.Is(err, ErrCircuitOpen) || probed != 0 {
+		t.Fatalf("allow() error = %v after %d probes, want %v without probing", err, probed, ErrCircuitOpen)
+	}
+
+	// the cooldown is over but the server is still down
+	time.Sleep(20 * time.Millisecond)
+	if err := b.allow(context.Background(), probe); !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, probeErr) || probed != 1 {
+		t.Fatalf("allow() error = %v after %d probes, want the probe error", err, probed)
+	}
+	if err := b.allow(context.Background(), probe); !errors.Is(err, ErrCircuitOpen) || probed != 1 {
+		t.Fatalf("allow() error = %v after %d probes, want another cooldown", err, probed)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	probeErr = nil
+	if err := b.allow(context.Background(), probe); err != nil || probed != 2 {
+		t.Fatalf("allow() error = %v after %d probes, want the breaker closed", err, probed)
+	}
+	b.record(true)
+	if err := b.allow(context.Background(), probe); err != nil {
+		t.Errorf("allow() error = %v, the failures before the breaker closed still count", err)
+	}
+
+	var disabled *circuitBreaker
+	disabled.record(true)
+	if err := disabled.allow(context.Background(), probe); err != nil {
+		t.Errorf("allow() error = %v without a breaker", err)
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	CloseFunc = func() error { return nil }
+	client := newMockClient(2, "")
+	client.breaker = newCircuitBreaker(3, time.Hour)
+	dialed := 0
+	client.pool.dial = func(ctx context.Context) (IConnection, error) {
+		dialed++
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Do(context.Background(), "PING"); !errors.Is(err, syscall.ECONNREFUSED) {
+			t.Fatalf("Do() error = %v, want %v", err, syscall.ECONNREFUSED)
+		}
+	}
+	if _, err := client.Do(context.Background(), "PING"); !errors.Is(err, ErrCircuitOpen) || dialed != 3 {
+		t.Errorf("Do() error = %v after %d dials, want %v without dialing", err, dialed, ErrCircuitOpen)
+	}
+	if stats := client.PoolStats(); !stats.CircuitOpen {
+		t.Errorf("PoolStats() got %+v, want the circuit open", stats)
+	}
+}
+
+func TestIsServerFailure(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	netTimeout := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "success", ctx: context.Background(), err: nil, want: false},
+		{name: "error reply", ctx: context.Background(), err: RedisError("ERR wrong number of arguments"), want: false},
+		{name: "refused", ctx: context.Background(), err: syscall.ECONNREFUSED, want: true},
+		{name: "read timeout", ctx: context.Background(), err: &timeoutError{err: netTimeout, reply: true}, want: true},
+		{name: "write timeout", ctx: context.Background(), err: &timeoutError{err: netTimeout}, want: true},
+		{name: "deadline waiting for the reply", ctx: expired, err: &CommandError{Err: &timeoutError{err: netTimeout, reply: true}}, want: true},
+		{name: "deadline before the command was written", ctx: expired, err: &timeoutError{err: netTimeout}, want: false},
+		{name: "deadline before sending", ctx: expired, err: context.DeadlineExceeded, want: false},
+		{name: "cancelled waiting for the reply", ctx: cancelled, err: context.Canceled, want: false},
+		{name: "pool exhausted", ctx: context.Background(), err: ErrPoolExhausted, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isServerFailure(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("isServerFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_CircuitBreakerDeadline(t *testing.T) {
+	client, servers := newPipeClient("")
+	client.pool = newPool(client.newConn, &Options{MaxActive: 1, MaxIdle: 1, PoolTimeout: DefaultPoolTimeout})
+	client.breaker = newCircuitBreaker(1, time.Hour)
+	defer client.Close()
+	go func() {
+		server := <-servers
+		// the command is read but never answered before the deadline
+		if _, err := server.ReceiveValue(context.Background()); err != nil {
+			t.Errorf("server failed to read GET: %s", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Do(ctx, "GET", "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if stats := client.PoolStats(); !stats.CircuitOpen {
+		t.Errorf("PoolStats() got %+v, want the circuit open after the server didn't answer", stats)
+	}
+}
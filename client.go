@@ -4,181 +4,1041 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
-	"sync"
-)
-
-const (
-	PingCmd       = "PING\n"
-	SendCmd       = "*3\r\n$3\r\nSET\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n"
-	IncrCmd       = "*2\r\n$4\r\nINCR\r\n$%d\r\n%s\r\n"
-	ExpireCmd     = "*3\r\n$6\r\nEXPIRE\r\n$%d\r\n%s\r\n$%d\r\n%d\r\n"
-	SetWithTTLCmd = "*5\r\n$3\r\nSET\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n$2\r\nEX\r\n$%d\r\n%d\r\n"
-	GetCmd        = "*2\r\n$3\r\nGET\r\n$%d\r\n%s\r\n"
-	DeleteCmd     = "*2\r\n$3\r\nDEL\r\n$%d\r\n%s\r\n"
+	"io"
+	"math"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type IClient interface {
-	Do(ctx context.Context, command string) (string, error)
+	Do(ctx context.Context, args ...interface{}) (*Reply, error)
+	AddReplica(address string) error
+	Pipeline() *Pipeline
+	Watch(ctx context.Context, fn func(tx *Tx) error, keys ...string) error
+	Subscribe(ctx context.Context, channels ...string) (*PubSub, error)
+	PSubscribe(ctx context.Context, patterns ...string) (*PubSub, error)
+	SSubscribe(ctx context.Context, channels ...string) (*PubSub, error)
+	Publish(ctx context.Context, channel string, message string) (int, error)
+	SPublish(ctx context.Context, channel string, message string) (int, error)
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (*Reply, error)
+	EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) (*Reply, error)
+	ScriptLoad(ctx context.Context, script string) (string, error)
+	ScriptExists(ctx context.Context, hashes ...string) ([]bool, error)
+	ScriptFlush(ctx context.Context, async bool) error
+	ScriptKill(ctx context.Context) error
+	FunctionLoad(ctx context.Context, code string, replace bool) (string, error)
+	FunctionDump(ctx context.Context) ([]byte, error)
+	FunctionList(ctx context.Context, libraryPattern string, withCode bool) ([]FunctionLibrary, error)
+	FCall(ctx context.Context, function string, keys []string, args ...interface{}) (*Reply, error)
+	FCallRO(ctx context.Context, function string, keys []string, args ...interface{}) (*Reply, error)
+	EnableKeyEvents(ctx context.Context, events ...KeyEventType) error
+	SubscribeKeyEvents(ctx context.Context, db int, pattern string, events ...KeyEventType) (*KeyEventSubscriber, error)
 	Ping(ctx context.Context) (string, error)
 	Set(ctx context.Context, key string, value string) error
 	SetWithTTL(ctx context.Context, key string, value string, ttl int) error
+	SetWithExpiry(ctx context.Context, key string, value string, expiry time.Duration) error
+	SetArgs(ctx context.Context, key string, value string, opts SetOptions) (SetResult, error)
+	GetDel(ctx context.Context, key string) (string, error)
+	GetEx(ctx context.Context, key string, opts GetExOptions) (string, error)
 	Get(ctx context.Context, key string) (string, error)
+	SetBytes(ctx context.Context, key string, value []byte) error
+	GetBytes(ctx context.Context, key string) ([]byte, error)
+	GetTo(ctx context.Context, key string, w io.Writer) (int64, error)
+	SetFrom(ctx context.Context, key string, r io.Reader, size int64) error
+	MGet(ctx context.Context, keys ...string) ([]*string, error)
+	MSet(ctx context.Context, pairs map[string]string) error
+	SetNX(ctx context.Context, key string, value string) (bool, error)
+	MSetNX(ctx context.Context, pairs map[string]string) (bool, error)
+	Exists(ctx context.Context, keys ...string) (int, error)
+	Touch(ctx context.Context, keys ...string) (int, error)
+	Unlink(ctx context.Context, keys ...string) (int, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	PTTL(ctx context.Context, key string) (time.Duration, error)
+	Persist(ctx context.Context, key string) (bool, error)
+	ExpireTime(ctx context.Context, key string) (time.Time, error)
+	PExpireTime(ctx context.Context, key string) (time.Time, error)
+	Rename(ctx context.Context, key string, newKey string) error
+	RenameNX(ctx context.Context, key string, newKey string) (bool, error)
+	Copy(ctx context.Context, source string, destination string, replace bool) (bool, error)
+	CopyToDB(ctx context.Context, source string, destination string, db int, replace bool) (bool, error)
+	Move(ctx context.Context, key string, db int) (bool, error)
+	Type(ctx context.Context, key string) (string, error)
+	ObjectEncoding(ctx context.Context, key string) (string, error)
+	ObjectFreq(ctx context.Context, key string) (int, error)
+	ObjectIdleTime(ctx context.Context, key string) (time.Duration, error)
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	Scan(ctx context.Context, opts ScanOptions) *ScanIterator
+	HScan(ctx context.Context, key string, opts HScanOptions) *HScanIterator
+	SScan(ctx context.Context, key string, opts MemberScanOptions) *SScanIterator
+	ZScan(ctx context.Context, key string, opts MemberScanOptions) *ZScanIterator
+	HMGetStruct(ctx context.Context, key string, dst interface{}) error
+	HSetStruct(ctx context.Context, key string, v interface{}) (int, error)
+	HRandField(ctx context.Context, key string) (string, error)
+	HRandFields(ctx context.Context, key string, count int) ([]string, error)
+	HRandFieldsWithValues(ctx context.Context, key string, count int) ([]FieldValue, error)
+	LPush(ctx context.Context, key string, values ...string) (int, error)
+	RPush(ctx context.Context, key string, values ...string) (int, error)
+	LPushCapped(ctx context.Context, key string, maxLen int, values ...string) (int, error)
+	LPop(ctx context.Context, key string) (string, error)
+	RPop(ctx context.Context, key string) (string, error)
+	LPopCount(ctx context.Context, key string, count int) ([]string, error)
+	RPopCount(ctx context.Context, key string, count int) ([]string, error)
+	LRange(ctx context.Context, key string, start int, stop int) ([]string, error)
+	LLen(ctx context.Context, key string) (int, error)
+	LRem(ctx context.Context, key string, count int, element string) (int, error)
+	LInsert(ctx context.Context, key string, position InsertPosition, pivot string, element string) (int, error)
+	LSet(ctx context.Context, key string, index int, element string) error
+	LTrim(ctx context.Context, key string, start int, stop int) error
+	LMove(ctx context.Context, source string, destination string, from ListSide, to ListSide) (string, error)
+	LPos(ctx context.Context, key string, element string, opts LPosOptions) (int, error)
+	LPosCount(ctx context.Context, key string, element string, count int, opts LPosOptions) ([]int, error)
+	LMPop(ctx context.Context, from ListSide, count int, keys ...string) (ListPopResult, error)
+	ZMPop(ctx context.Context, order ZPopOrder, count int, keys ...string) (ZPopResult, error)
+	ZAdd(ctx context.Context, key string, members ...Member) (int, error)
+	ZAddArgs(ctx context.Context, key string, opts ZAddOptions, members ...Member) (int, error)
+	ZAddIncr(ctx context.Context, key string, opts ZAddOptions, member Member) (float64, error)
+	ZScore(ctx context.Context, key string, member string) (float64, error)
+	ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error)
+	ZRem(ctx context.Context, key string, members ...string) (int, error)
+	ZCard(ctx context.Context, key string) (int, error)
+	ZRank(ctx context.Context, key string, member string) (int, error)
+	ZRevRank(ctx context.Context, key string, member string) (int, error)
+	ZRankWithScore(ctx context.Context, key string, member string) (int, float64, error)
+	ZRevRankWithScore(ctx context.Context, key string, member string) (int, float64, error)
+	ZRange(ctx context.Context, key string, start int, stop int) ([]string, error)
+	ZRangeWithScores(ctx context.Context, key string, start int, stop int) ([]Member, error)
+	ZRangeArgs(ctx context.Context, key string, opts ZRangeOptions) ([]string, error)
+	ZRangeArgsWithScores(ctx context.Context, key string, opts ZRangeOptions) ([]Member, error)
+	ZRangeStore(ctx context.Context, destination string, source string, opts ZRangeOptions) (int, error)
+	ZPopMin(ctx context.Context, key string, count int) ([]Member, error)
+	ZPopMax(ctx context.Context, key string, count int) ([]Member, error)
+	ZRandMember(ctx context.Context, key string) (string, error)
+	ZRandMembers(ctx context.Context, key string, count int) ([]string, error)
+	ZRandMembersWithScores(ctx context.Context, key string, count int) ([]Member, error)
+	SInter(ctx context.Context, keys ...string) ([]string, error)
+	SUnion(ctx context.Context, keys ...string) ([]string, error)
+	SDiff(ctx context.Context, keys ...string) ([]string, error)
+	SInterStore(ctx context.Context, destination string, keys ...string) (int, error)
+	SUnionStore(ctx context.Context, destination string, keys ...string) (int, error)
+	SDiffStore(ctx context.Context, destination string, keys ...string) (int, error)
+	SInterCard(ctx context.Context, limit int, keys ...string) (int, error)
+	SRandMember(ctx context.Context, key string) (string, error)
+	SRandMembers(ctx context.Context, key string, count int) ([]string, error)
+	BLPop(ctx context.Context, keys ...string) (string, string, error)
+	BRPop(ctx context.Context, keys ...string) (string, string, error)
+	BLMove(ctx context.Context, source string, destination string, from ListSide, to ListSide) (string, error)
+	BZPopMin(ctx context.Context, keys ...string) (string, Member, error)
+	BZPopMax(ctx context.Context, keys ...string) (string, Member, error)
+	BZMPop(ctx context.Context, order ZPopOrder, count int, keys ...string) (ZPopResult, error)
+	XAdd(ctx context.Context, key string, id string, fields map[string]string) (string, error)
+	XAddArgs(ctx context.Context, key string, opts XAddOptions, fields map[string]string) (string, error)
+	XTrim(ctx context.Context, key string, opts XTrimOptions) (int, error)
+	XLen(ctx context.Context, key string) (int, error)
+	XDel(ctx context.Context, key string, ids ...string) (int, error)
+	XRange(ctx context.Context, key string, start string, end string) ([]StreamEntry, error)
+	XRangeCount(ctx context.Context, key string, start string, end string, count int) ([]StreamEntry, error)
+	XRevRange(ctx context.Context, key string, end string, start string) ([]StreamEntry, error)
+	XRevRangeCount(ctx context.Context, key string, end string, start string, count int) ([]StreamEntry, error)
+	XRead(ctx context.Context, opts XReadOptions, streams map[string]string) (map[string][]StreamEntry, error)
+	XGroupCreate(ctx context.Context, key string, group string, id string, mkStream bool) (bool, error)
+	XGroupDestroy(ctx context.Context, key string, group string) (bool, error)
+	XReadGroup(ctx context.Context, group string, consumer string, opts XReadGroupOptions, streams map[string]string) (map[string][]StreamEntry, error)
+	XAck(ctx context.Context, key string, group string, ids ...string) (int, error)
+	XClaim(ctx context.Context, key string, group string, consumer string, minIdle time.Duration, ids ...string) ([]StreamEntry, error)
+	XAutoClaim(ctx context.Context, key string, group string, consumer string, minIdle time.Duration, start string, count int) (XAutoClaimResult, error)
+	XPending(ctx context.Context, key string, group string) (XPendingSummary, error)
+	XPendingExt(ctx context.Context, key string, group string, opts XPendingOptions) ([]XPendingEntry, error)
+	GeoAdd(ctx context.Context, key string, locations ...GeoLocation) (int, error)
+	GeoPos(ctx context.Context, key string, members ...string) ([]*GeoCoord, error)
+	GeoDist(ctx context.Context, key string, member1 string, member2 string, unit GeoUnit) (float64, error)
+	GeoSearch(ctx context.Context, key string, opts GeoSearchOptions) ([]string, error)
+	GeoSearchLocations(ctx context.Context, key string, opts GeoSearchOptions) ([]GeoSearchResult, error)
+	GeoSearchStore(ctx context.Context, destination string, source string, opts GeoSearchOptions, storeDist bool) (int, error)
+	NewConsumer(ctx context.Context, opts ConsumerOptions, handler StreamHandler) (*Consumer, error)
 	Delete(ctx context.Context, key string) error
 	Incr(ctx context.Context, key string) (int, error)
-	Expire(ctx context.Context, key string, seconds int) (bool, error)
+	IncrBy(ctx context.Context, key string, increment int) (int, error)
+	Decr(ctx context.Context, key string) (int, error)
+	DecrBy(ctx context.Context, key string, decrement int) (int, error)
+	IncrByFloat(ctx context.Context, key string, increment float64) (float64, error)
+	Expire(ctx context.Context, key string, seconds int, conditions ...ExpireCondition) (bool, error)
+	PExpire(ctx context.Context, key string, expiry time.Duration, conditions ...ExpireCondition) (bool, error)
+	ExpireAt(ctx context.Context, key string, at time.Time, conditions ...ExpireCondition) (bool, error)
+	PExpireAt(ctx context.Context, key string, unixMillis int64, conditions ...ExpireCondition) (bool, error)
+	CommandCount(ctx context.Context) (int, error)
+	CommandExists(ctx context.Context, name string) (bool, error)
+	Warmup(ctx context.Context, n int) error
+	PoolStats() PoolStats
+	ReAuth(ctx context.Context) error
+	Select(ctx context.Context, db int) error
 	Close() error
 }
 
 type Client struct {
-	conn    IConnection
-	address string
-	mu      sync.Mutex
-	auth    string
-	dialer  IDialer
+	pool     *pool
+	address  string
+	opts     *Options     // how connections are opened, with the defaults filled in
+	db       atomic.Int64 // the selected database, opts.DB until Select is called
+	replicas replicaSet
+	breaker  *circuitBreaker // nil without a circuit breaker
+	mux      *mux            // nil unless Options.Multiplex is set
+}
+
+// NewClient creates a client connected to address, configured by opts, e.g.
+//
+//	resp.NewClient("localhost:6379", resp.WithAuth("secret"), resp.WithDB(1))
+func NewClient(address string, opts ...Option) (IClient, error) {
+	options := &Options{Address: address}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return NewClientWithOptions(options)
 }
 
+// Deprecated: use NewClient(address, WithAuth(auth)).
 func NewRedisClient(address string, auth string) (IClient, error) {
+	return NewClient(address, WithAuth(auth))
+}
+
+// NewClientWithOptions creates a client and warms up its pool, see Options.Warmup.
+// Creating the client only fails when none of the warmup connections could be opened,
+// so an unreachable server or bad credentials are reported right away; with LazyConnect it never fails.
+func NewClientWithOptions(opts *Options) (IClient, error) {
+	opts = opts.withDefaults()
+	client := newClient(opts)
+
+	if opts.LazyConnect {
+		go func() {
+			_ = client.Warmup(context.Background(), opts.Warmup)
+		}()
+		return client, nil
+	}
+
+	var warmupErr *WarmupError
+	if err := client.Warmup(context.Background(), opts.Warmup); errors.As(err, &warmupErr) && len(warmupErr.Errors) == warmupErr.Requested {
+		_ = client.pool.close()
+		return nil, fmt.Errorf("can't create redis connection: %w", err)
+	}
+
+	return client, nil
+}
+
+// newClient returns a client for opts.Address, opts must have the defaults filled in. No connection is opened yet.
+func newClient(opts *Options) *Client {
 	client := &Client{
-		address: address,
-		auth:    auth,
-		dialer:  NewDialer(),
+		address: opts.Address,
+		opts:    opts,
+	}
+	client.db.Store(int64(opts.DB))
+	client.pool = newPool(client.newConn, opts)
+	client.breaker = newCircuitBreaker(opts.CircuitBreakerThreshold, opts.CircuitBreakerCooldown)
+	if opts.Multiplex {
+		client.mux = newMux(client.pool)
 	}
+	return client
+}
 
-	conn, err := NewRedisConnection(client.dialer, address, auth)
+// NewClientFromURL creates a client from a connection URL such as redis://:password@localhost:6379/0,
+// see ParseURL for the supported formats.
+func NewClientFromURL(rawURL string) (IClient, error) {
+	opts, err := ParseURL(rawURL)
 	if err != nil {
-		return nil, errors.New("can't create redis connection")
+		return nil, err
 	}
+	return NewClientWithOptions(opts)
+}
 
-	client.conn = conn
+// Do sends a command built from args, e.g. Do(ctx, "SET", "key", "value"); arguments are encoded
+// as RESP bulk strings, so strings, []byte, integers, floats and booleans are accepted.
+// Read-only commands are sent to a replica when replicas were added, see AddReplica.
+func (client *Client) Do(ctx context.Context, args ...interface{}) (*Reply, error) {
+	if len(args) == 0 {
+		return nil, errNoCommand
+	}
+	if replica := client.replicas.pick(args); replica != nil {
+		reply, err := replica.do(ctx, args)
+		if !fallsBack(ctx, err) {
+			return reply, err
+		}
+		client.replicas.failed(replica)
+	}
+	return client.do(ctx, args)
+}
 
-	return client, nil
+// fallsBack reports whether a read failed on a replica with err should run on the primary instead: the
+// replica is unreachable or not ready (loading, lost its master).
+func fallsBack(ctx context.Context, err error) bool {
+	return err != nil && (!isReplyError(err) || isReplicaUnavailable(err)) && ctx.Err() == nil
 }
 
-func (client *Client) Do(ctx context.Context, command string) (string, error) {
-	errChan := make(chan error, 1)
-	replyChan := make(chan string, 1)
-	go func() {
-		err := client.conn.Send(ctx, command)
-		if err != nil {
-			errChan <- err
-			return
+func (client *Client) do(ctx context.Context, args []interface{}) (*Reply, error) {
+	if err := client.breaker.allow(ctx, client.probe); err != nil {
+		return nil, client.commandError(args, 1, err)
+	}
+	getConn := client.getConn
+	for attempt := 0; ; attempt++ {
+		reply, err := client.doWith(ctx, getConn, args)
+		client.breaker.record(isServerFailure(ctx, err))
+		if err == nil || attempt >= client.opts.MaxRetries || !isTransient(err) || ctx.Err() != nil {
+			if err != nil && !isReplyError(err) {
+				err = client.commandError(args, attempt+1, err)
+			}
+			return reply, err
+		}
+		switch {
+		case isBrokenConn(err):
+			// The server closed the connection, the idle ones are likely gone as well (restart, failover)
+			getConn = client.pool.getNew
+		case replyErrorKind(err) == errorReadOnly:
+			// The server was demoted, new connections may reach the new master (DNS, virtual IP, proxy)
+			client.pool.retire()
+		}
+		if err := sleepCtx(ctx, retryBackoff(attempt, client.opts.MinRetryBackoff, client.opts.MaxRetryBackoff)); err != nil {
+			return nil, client.commandError(args, attempt+1, err)
 		}
+	}
+}
 
-		reply, err := client.conn.Receive(ctx)
-		if err != nil {
-			errChan <- err
-		} else {
-			replyChan <- reply
+// CommandError is returned by Do when a command fails for another reason than an error reply, which is
+// returned as is: the connection broke, timed out or couldn't be opened, the pool was exhausted... It tells
+// which command failed where, and wraps the cause for errors.Is and errors.As.
+type CommandError struct {
+	Command string   // the command name, e.g. GET
+	Args    []string // the arguments after the name, only set with Options.ErrorArgs since they may be sensitive
+	Addr    string   // the address of the server
+	Attempt int      // 1 for the first try, more when retried, see Options.MaxRetries
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	command := e.Command
+	if len(e.Args) > 0 {
+		command += " " + strings.Join(e.Args, " ")
+	}
+	return fmt.Sprintf("resp: %s on %s attempt %d: %v", command, e.Addr, e.Attempt, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+func (client *Client) commandError(args []interface{}, attempt int, err error) error {
+	cmdErr := &CommandError{Addr: client.address, Attempt: attempt, Err: err}
+	if len(args) > 0 {
+		cmdErr.Command = strings.ToUpper(fmt.Sprint(args[0]))
+	}
+	if client.opts.ErrorArgs && len(args) > 1 {
+		for _, arg := range args[1:] {
+			if b, ok := arg.([]byte); ok {
+				arg = string(b)
+			}
+			cmdErr.Args = append(cmdErr.Args, fmt.Sprint(arg))
 		}
-	}()
+	}
+	return cmdErr
+}
 
-	select {
-	case <-ctx.Done():
-		return "", ctx.Err() // The context was cancelled
-	case err := <-errChan:
-		return "", err // The redis operation returned an error
-	case reply := <-replyChan:
-		return reply, nil // The redis operation was successful
+// probe checks whether the server is back with a PING on a new connection, see circuitBreaker.
+func (client *Client) probe(ctx context.Context) error {
+	reply, err := client.doWith(ctx, client.pool.getNew, []interface{}{"PING"})
+	if err != nil {
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "PONG" {
+		return fmt.Errorf("ping: unexpected response from server %v", reply.Value())
 	}
+	return nil
+}
 
+// doWith runs args on a connection taken from getConn. The deadline of ctx applies to the reads and
+// writes and cancelling ctx interrupts them (see Connection), the connection is closed after either
+// since the reply may be left unread.
+func (client *Client) doWith(ctx context.Context, getConn func(ctx context.Context) (IConnection, error), args []interface{}) (*Reply, error) {
+	var value interface{}
+	var err error
+	if client.mux != nil && multiplexed(args) {
+		value, err = client.mux.do(ctx, args)
+	} else {
+		value, err = client.roundTrip(ctx, getConn, args)
+	}
+	if err != nil {
+		var ask *AskError
+		if errors.As(err, &ask) {
+			// The slot is being migrated, the key may already live on the importing node
+			return client.doAsking(ctx, ask.Addr, args)
+		}
+		return nil, err
+	}
+	return NewReply(value), nil
+}
+
+// roundTrip sends args on a connection from getConn and reads the reply.
+func (client *Client) roundTrip(ctx context.Context, getConn func(ctx context.Context) (IConnection, error), args []interface{}) (interface{}, error) {
+	conn, err := getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err = conn.SendCommand(ctx, args...); err == nil {
+		value, err = conn.ReceiveValue(ctx)
+	}
+	client.releaseConn(conn, err)
+	return value, err
 }
 
 func (client *Client) Ping(ctx context.Context) (string, error) {
-	response, err := client.Do(ctx, PingCmd)
+	reply, err := client.Do(ctx, "PING")
 	if err != nil {
 		return "", err
 	}
-	if response != "PONG" {
+	response, err := reply.Text()
+	if err != nil || response != "PONG" {
 		return "", errors.New("unexpected response from server")
 	}
 	return response, nil
 }
 
 func (client *Client) Set(ctx context.Context, key string, value string) error {
-	cmd := fmt.Sprintf(SendCmd, len(key), key, len(value), value)
-	response, err := client.Do(ctx, cmd)
+	reply, err := client.Do(ctx, "SET", key, value)
 	if err != nil {
 		return err
 	}
-	if response != "OK" {
-		return fmt.Errorf("set: unexpected response from server %s", response)
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("set: unexpected response from server %v", reply.Value())
 	}
 	return nil
 }
 
 func (client *Client) Incr(ctx context.Context, key string) (int, error) {
-	cmd := fmt.Sprintf(IncrCmd, len(key), key)
-	response, err := client.Do(ctx, cmd)
+	return client.incr(ctx, "INCR", key)
+}
+
+// IncrBy adds increment to the integer value of key, 0 when it doesn't exist, and returns the new value.
+func (client *Client) IncrBy(ctx context.Context, key string, increment int) (int, error) {
+	return client.incr(ctx, "INCRBY", key, increment)
+}
+
+// Decr subtracts one from the integer value of key, 0 when it doesn't exist, and returns the new value.
+func (client *Client) Decr(ctx context.Context, key string) (int, error) {
+	return client.incr(ctx, "DECR", key)
+}
+
+// DecrBy subtracts decrement from the integer value of key, 0 when it doesn't exist, and returns the new value.
+func (client *Client) DecrBy(ctx context.Context, key string, decrement int) (int, error) {
+	return client.incr(ctx, "DECRBY", key, decrement)
+}
+
+func (client *Client) incr(ctx context.Context, args ...interface{}) (int, error) {
+	reply, err := client.Do(ctx, args...)
 	if err != nil {
 		return 0, err
 	}
 
-	// Parse the response => should be in the format: ":<number>\r\n" for a successful INCR command
-	var newValue int
-	if _, err := fmt.Sscanf(response, ":%d\r\n", &newValue); err != nil {
-		return 0, fmt.Errorf("incr: unexpected response from server %s", response)
+	// The reply holds the value of the key after the increment
+	newValue, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(args[0].(string)), reply.Value())
 	}
+	return newValue, nil
+}
 
-	// Return the new value
+// IncrByFloat adds increment, which may be negative, to the value of key, 0 when it doesn't exist, and
+// returns the new value. The server replies with the value as a string, which is parsed back into a float64.
+func (client *Client) IncrByFloat(ctx context.Context, key string, increment float64) (float64, error) {
+	if math.IsNaN(increment) || math.IsInf(increment, 0) {
+		return 0, fmt.Errorf("incrByFloat: invalid increment %v", increment)
+	}
+	reply, err := client.Do(ctx, "INCRBYFLOAT", key, increment)
+	if err != nil {
+		return 0, err
+	}
+	newValue, err := reply.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("incrByFloat: unexpected response from server %v", reply.Value())
+	}
 	return newValue, nil
 }
 
-func (client *Client) Expire(ctx context.Context, key string, seconds int) (bool, error) {
-	cmd := fmt.Sprintf(ExpireCmd, len(key), key, len(fmt.Sprintf("%d", seconds)), seconds)
-	response, err := client.Do(ctx, cmd)
+// ExpireCondition restricts when the expiration commands change the expiration of a key (Redis 7+).
+type ExpireCondition string
+
+const (
+	ExpireNX ExpireCondition = "NX" // only when the key has no expiration
+	ExpireXX ExpireCondition = "XX" // only when the key has an expiration
+	ExpireGT ExpireCondition = "GT" // only when the new expiration is later, extending it
+	ExpireLT ExpireCondition = "LT" // only when the new expiration is earlier, shortening it
+)
+
+// Expire sets key to expire after seconds, and reports whether it was set: false when the key doesn't
+// exist or a condition wasn't met. A key without an expiration counts as expiring never for GT and LT.
+func (client *Client) Expire(ctx context.Context, key string, seconds int, conditions ...ExpireCondition) (bool, error) {
+	return client.expire(ctx, "EXPIRE", key, int64(seconds), conditions)
+}
+
+// PExpire is like Expire with an expiry to the millisecond, a positive expiry under 1ms is rounded up to 1ms
+// so the key isn't deleted right away.
+func (client *Client) PExpire(ctx context.Context, key string, expiry time.Duration, conditions ...ExpireCondition) (bool, error) {
+	millis := expiry.Milliseconds()
+	if expiry > 0 && millis == 0 {
+		millis = 1
+	}
+	return client.expire(ctx, "PEXPIRE", key, millis, conditions)
+}
+
+// ExpireAt is like Expire with an absolute expiration, to the second.
+func (client *Client) ExpireAt(ctx context.Context, key string, at time.Time, conditions ...ExpireCondition) (bool, error) {
+	if at.Unix() <= 0 {
+		return false, fmt.Errorf("expireAt: invalid expiration time %v", at)
+	}
+	return client.expire(ctx, "EXPIREAT", key, at.Unix(), conditions)
+}
+
+// PExpireAt is like Expire with an absolute expiration given as a unix timestamp in milliseconds.
+func (client *Client) PExpireAt(ctx context.Context, key string, unixMillis int64, conditions ...ExpireCondition) (bool, error) {
+	if unixMillis <= 0 {
+		return false, fmt.Errorf("pExpireAt: invalid unix timestamp %d", unixMillis)
+	}
+	return client.expire(ctx, "PEXPIREAT", key, unixMillis, conditions)
+}
+
+func (client *Client) expire(ctx context.Context, command string, key string, amount int64, conditions []ExpireCondition) (bool, error) {
+	args := make([]interface{}, 0, 3+len(conditions))
+	args = append(args, command, key, amount)
+	for _, condition := range conditions {
+		args = append(args, string(condition))
+	}
+	reply, err := client.Do(ctx, args...)
 	if err != nil {
 		return false, err
 	}
 
-	// Parse the response => should be in the format: ":1" for a successful EXPIRE command (if the key exists), or ":0" if it does not.
-	if response == ":1" {
-		return true, nil
-	} else if response == ":0" {
-		return false, nil
-	} else {
-		return false, fmt.Errorf("expire: unexpected response from server %s", response)
+	// The reply is 1 when the expiration was set, 0 when the key does not exist or a condition was not met.
+	set, err := reply.Bool()
+	if err != nil {
+		return false, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
 	}
+	return set, nil
 }
 
+// SetWithTTL sets key to value expiring after ttl seconds, see SetWithExpiry for finer precision.
 func (client *Client) SetWithTTL(ctx context.Context, key string, value string, ttl int) error {
-	cmd := fmt.Sprintf(SetWithTTLCmd, len(key), key, len(value), value, len(strconv.Itoa(ttl)), ttl)
-	response, err := client.Do(ctx, cmd)
+	reply, err := client.Do(ctx, "SET", key, value, "EX", ttl)
 	if err != nil {
 		return err
 	}
-	if response != "OK" {
-		return fmt.Errorf("setWithTTL: unexpected response from server %s", response)
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("setWithTTL: unexpected response from server %v", reply.Value())
 	}
 	return nil
 }
 
+// SetWithExpiry sets key to value expiring after expiry, which must be at least a millisecond.
+// Whole seconds are sent with EX, other durations with PX and rounded down to the millisecond.
+func (client *Client) SetWithExpiry(ctx context.Context, key string, value string, expiry time.Duration) error {
+	unit, amount, err := expiryArgs(expiry)
+	if err != nil {
+		return fmt.Errorf("setWithExpiry: %w", err)
+	}
+	reply, err := client.Do(ctx, "SET", key, value, unit, amount)
+	if err != nil {
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("setWithExpiry: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// SetOptions are the options of SET used by SetArgs, the zero value sets the key unconditionally without expiry.
+// At most one of Expiry, ExpireAt and KeepTTL can be set, and NX and XX are exclusive.
+type SetOptions struct {
+	NX bool // only set the key when it doesn't exist
+	XX bool // only set the key when it exists
+	// Expiry expires the key after it, sent like SetWithExpiry does
+	Expiry time.Duration
+	// ExpireAt expires the key at that time, sent with EXAT when it is a whole second and PXAT otherwise
+	ExpireAt time.Time
+	KeepTTL  bool // keep the expiration of the key instead of clearing it
+	Get      bool // return the previous value of the key, see SetResult.Old
+}
+
+// SetResult is the outcome of SetArgs.
+type SetResult struct {
+	Set bool    // false when the NX or XX condition was not met
+	Old *string // the previous value when SetOptions.Get is set, nil when the key didn't exist
+}
+
+// SetArgs sets key to value with the SET options in opts, e.g. a lock taken with NX and an expiry,
+// or a value swapped with GET in one atomic command.
+func (client *Client) SetArgs(ctx context.Context, key string, value string, opts SetOptions) (SetResult, error) {
+	args, err := opts.args(key, value)
+	if err != nil {
+		return SetResult{}, fmt.Errorf("setArgs: %w", err)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return SetResult{}, err
+	}
+
+	if !opts.Get {
+		// OK, or nil when the condition was not met
+		if reply.IsNil() {
+			return SetResult{}, nil
+		}
+		if response, err := reply.Text(); err != nil || response != "OK" {
+			return SetResult{}, fmt.Errorf("setArgs: unexpected response from server %v", reply.Value())
+		}
+		return SetResult{Set: true}, nil
+	}
+
+	// The previous value, or nil when there was none; the key was set unless the condition says otherwise
+	var result SetResult
+	if !reply.IsNil() {
+		old, err := reply.Text()
+		if err != nil {
+			return SetResult{}, fmt.Errorf("setArgs: unexpected response from server %v", reply.Value())
+		}
+		result.Old = &old
+	}
+	switch {
+	case opts.NX:
+		result.Set = result.Old == nil
+	case opts.XX:
+		result.Set = result.Old != nil
+	default:
+		result.Set = true
+	}
+	return result, nil
+}
+
+// args returns the SET command for key and value with the options.
+func (opts SetOptions) args(key string, value string) ([]interface{}, error) {
+	if opts.NX && opts.XX {
+		return nil, errors.New("NX and XX are exclusive")
+	}
+	if countTrue(opts.Expiry != 0, !opts.ExpireAt.IsZero(), opts.KeepTTL) > 1 {
+		return nil, errors.New("only one of Expiry, ExpireAt and KeepTTL can be set")
+	}
+
+	args := []interface{}{"SET", key, value}
+	switch {
+	case opts.NX:
+		args = append(args, "NX")
+	case opts.XX:
+		args = append(args, "XX")
+	}
+	switch {
+	case opts.Expiry != 0:
+		unit, amount, err := expiryArgs(opts.Expiry)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, unit, amount)
+	case !opts.ExpireAt.IsZero():
+		unit, amount, err := expireAtArgs(opts.ExpireAt)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, unit, amount)
+	case opts.KeepTTL:
+		args = append(args, "KEEPTTL")
+	}
+	if opts.Get {
+		args = append(args, "GET")
+	}
+	return args, nil
+}
+
+func countTrue(conditions ...bool) int {
+	count := 0
+	for _, condition := range conditions {
+		if condition {
+			count++
+		}
+	}
+	return count
+}
+
+// expireAtArgs returns the option and timestamp for an expiration at a point in time: EXAT in seconds
+// when it is a whole second, PXAT in milliseconds otherwise.
+func expireAtArgs(at time.Time) (string, int64, error) {
+	if at.UnixMilli() <= 0 {
+		return "", 0, fmt.Errorf("invalid expiration time %v", at)
+	}
+	if at.Nanosecond() == 0 {
+		return "EXAT", at.Unix(), nil
+	}
+	return "PXAT", at.UnixMilli(), nil
+}
+
+// expiryArgs returns the SET option and amount for expiry: EX in seconds when it is a whole number of seconds,
+// PX in milliseconds otherwise.
+func expiryArgs(expiry time.Duration) (string, int64, error) {
+	if expiry < time.Millisecond {
+		return "", 0, fmt.Errorf("invalid expiry %v, the minimum is 1ms", expiry)
+	}
+	if expiry%time.Second == 0 {
+		return "EX", int64(expiry / time.Second), nil
+	}
+	return "PX", expiry.Milliseconds(), nil
+}
+
+// Get returns the value of key, or ErrNil when the key doesn't exist.
 func (client *Client) Get(ctx context.Context, key string) (string, error) {
-	cmd := fmt.Sprintf(GetCmd, len(key), key)
-	response, err := client.Do(ctx, cmd)
+	reply, err := client.Do(ctx, "GET", key)
 	if err != nil {
 		return "", err
 	}
-	return response, nil
+	return reply.Text()
+}
+
+// GetDel returns the value of key and deletes it in one atomic command, or returns ErrNil when the key
+// doesn't exist. It suits one-shot tokens, which must not be used twice.
+func (client *Client) GetDel(ctx context.Context, key string) (string, error) {
+	reply, err := client.Do(ctx, "GETDEL", key)
+	if err != nil {
+		return "", err
+	}
+	return reply.Text()
+}
+
+// GetExOptions change the expiration of the key read by GetEx, at most one of them can be set.
+// The expiration is left as is when none is.
+type GetExOptions struct {
+	// Expiry expires the key after it, sent like SetWithExpiry does
+	Expiry time.Duration
+	// ExpireAt expires the key at that time, sent with EXAT when it is a whole second and PXAT otherwise
+	ExpireAt time.Time
+	Persist  bool // remove the expiration of the key
+}
+
+// GetEx returns the value of key and changes its expiration, e.g. pushing it back on every read for a
+// sliding-TTL cache. It returns ErrNil when the key doesn't exist.
+func (client *Client) GetEx(ctx context.Context, key string, opts GetExOptions) (string, error) {
+	args, err := opts.args(key)
+	if err != nil {
+		return "", fmt.Errorf("getEx: %w", err)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	return reply.Text()
+}
+
+func (opts GetExOptions) args(key string) ([]interface{}, error) {
+	if countTrue(opts.Expiry != 0, !opts.ExpireAt.IsZero(), opts.Persist) > 1 {
+		return nil, errors.New("only one of Expiry, ExpireAt and Persist can be set")
+	}
+	args := []interface{}{"GETEX", key}
+	switch {
+	case opts.Expiry != 0:
+		unit, amount, err := expiryArgs(opts.Expiry)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, unit, amount)
+	case !opts.ExpireAt.IsZero():
+		unit, amount, err := expireAtArgs(opts.ExpireAt)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, unit, amount)
+	case opts.Persist:
+		args = append(args, "PERSIST")
+	}
+	return args, nil
+}
+
+// SetBytes sets key to value, which may hold any bytes (CRLF, NUL...): it is sent length-prefixed as is.
+func (client *Client) SetBytes(ctx context.Context, key string, value []byte) error {
+	reply, err := client.Do(ctx, "SET", key, value)
+	if err != nil {
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("setBytes: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// GetBytes returns the value of key as stored, or ErrNil when the key doesn't exist.
+func (client *Client) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	reply, err := client.Do(ctx, "GET", key)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Bytes()
+}
+
+// GetTo copies the value of key to w as it is read, in chunks, instead of holding it in memory,
+// and returns the number of bytes copied. It returns ErrNil when the key doesn't exist.
+func (client *Client) GetTo(ctx context.Context, key string, w io.Writer) (int64, error) {
+	conn, err := client.getConn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	if err = conn.SendCommand(ctx, "GET", key); err == nil {
+		n, err = conn.ReceiveTo(ctx, w)
+	}
+	if errors.Is(err, ErrNil) {
+		client.releaseConn(conn, nil)
+	} else {
+		// a failure while copying leaves the rest of the value unread, the connection is closed
+		client.releaseConn(conn, err)
+	}
+	return n, err
+}
+
+// SetFrom sets key to size bytes read from r, which are copied to the connection in chunks
+// instead of being held in memory.
+func (client *Client) SetFrom(ctx context.Context, key string, r io.Reader, size int64) error {
+	conn, err := client.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err = conn.SendCommandFrom(ctx, r, size, "SET", key); err == nil {
+		value, err = conn.ReceiveValue(ctx)
+	}
+	client.releaseConn(conn, err)
+	if err != nil {
+		return err
+	}
+	if response, ok := value.(string); !ok || response != "OK" {
+		return fmt.Errorf("setFrom: unexpected response from server %v", value)
+	}
+	return nil
+}
+
+// MGet returns the values of keys in the same order, with nil for the keys that don't exist.
+func (client *Client) MGet(ctx context.Context, keys ...string) ([]*string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	reply, err := client.Do(ctx, keyArgs("MGET", keys)...)
+	if err != nil {
+		return nil, err
+	}
+	elements, err := reply.Array()
+	if err != nil || len(elements) != len(keys) {
+		return nil, fmt.Errorf("mget: unexpected response from server %v", reply.Value())
+	}
+	values := make([]*string, len(elements))
+	for i, element := range elements {
+		if element.IsNil() {
+			continue
+		}
+		value, err := element.Text()
+		if err != nil {
+			return nil, fmt.Errorf("mget: unexpected response from server %v", reply.Value())
+		}
+		values[i] = &value
+	}
+	return values, nil
+}
+
+// MSet sets every key of pairs to its value in one command, atomically.
+func (client *Client) MSet(ctx context.Context, pairs map[string]string) error {
+	if len(pairs) == 0 {
+		return nil
+	}
+	reply, err := client.Do(ctx, pairArgs("MSET", pairs)...)
+	if err != nil {
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("mset: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// SetNX sets key to value only when it doesn't exist, and reports whether it was set.
+func (client *Client) SetNX(ctx context.Context, key string, value string) (bool, error) {
+	reply, err := client.Do(ctx, "SETNX", key, value)
+	if err != nil {
+		return false, err
+	}
+	set, err := reply.Bool()
+	if err != nil {
+		return false, fmt.Errorf("setNX: unexpected response from server %v", reply.Value())
+	}
+	return set, nil
+}
+
+// MSetNX sets every key of pairs only when none of them exists, atomically, and reports whether they were set.
+func (client *Client) MSetNX(ctx context.Context, pairs map[string]string) (bool, error) {
+	if len(pairs) == 0 {
+		return false, nil
+	}
+	reply, err := client.Do(ctx, pairArgs("MSETNX", pairs)...)
+	if err != nil {
+		return false, err
+	}
+	set, err := reply.Bool()
+	if err != nil {
+		return false, fmt.Errorf("mSetNX: unexpected response from server %v", reply.Value())
+	}
+	return set, nil
 }
 
 func (client *Client) Delete(ctx context.Context, key string) error {
-	cmd := fmt.Sprintf(DeleteCmd, len(key), key)
-	response, err := client.Do(ctx, cmd)
+	reply, err := client.Do(ctx, "DEL", key)
 	if err != nil {
 		return err
 	}
-	// ":1" for successful deletion of one key.
-	// ":0" If the key does not exist
-	if response != ":1" && response != ":0" {
-		return fmt.Errorf("delete: unexpected response from server %s", response)
+	// 1 for successful deletion of one key.
+	// 0 If the key does not exist
+	if _, err := reply.Int(); err != nil {
+		return fmt.Errorf("delete: unexpected response from server %v", reply.Value())
 	}
 
 	return nil
 }
 
+func (client *Client) CommandCount(ctx context.Context) (int, error) {
+	reply, err := client.Do(ctx, "COMMAND", "COUNT")
+	if err != nil {
+		return 0, err
+	}
+
+	// The reply holds the total number of commands
+	count, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("commandCount: unexpected response from server %v", reply.Value())
+	}
+	return count, nil
+}
+
+// CommandExists reports whether the server knows the command name, e.g. to check for a module command.
+func (client *Client) CommandExists(ctx context.Context, name string) (bool, error) {
+	reply, err := client.Do(ctx, "COMMAND", "INFO", name)
+	if err != nil {
+		return false, err
+	}
+
+	// The reply holds the details of the command, nil when the server doesn't know it
+	infos, err := reply.Array()
+	if err != nil || len(infos) != 1 {
+		return false, fmt.Errorf("commandExists: unexpected response from server %v", reply.Value())
+	}
+	return !infos[0].IsNil(), nil
+}
+
+// Warmup opens n connections concurrently and leaves them idle in the pool (up to MaxIdle), so the first
+// commands don't pay for dialing. The dials that failed are reported with a *WarmupError.
+func (client *Client) Warmup(ctx context.Context, n int) error {
+	if n > client.opts.MaxIdle {
+		n = client.opts.MaxIdle
+	}
+	if n <= 0 {
+		return nil
+	}
+	if errs := client.pool.warmup(ctx, n); len(errs) > 0 {
+		return &WarmupError{Requested: n, Errors: errs}
+	}
+	return nil
+}
+
+// PoolStats returns the connection pool counters, to monitor the pool and size it, and the circuit breaker state.
+func (client *Client) PoolStats() PoolStats {
+	stats := client.pool.statsSnapshot()
+	stats.CircuitOpen = client.breaker.isOpen()
+	return stats
+}
+
+// ReAuth checks the current credentials with AUTH, then replaces the pooled connections so that they all
+// authenticate with them, e.g. before a token from the CredentialsProvider expires.
+// New connections always authenticate with the current credentials.
+func (client *Client) ReAuth(ctx context.Context) error {
+	username, password, err := client.opts.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn, err := client.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	err = authenticate(ctx, conn, username, password)
+	client.releaseConn(conn, err)
+	if err != nil {
+		return err
+	}
+	client.pool.retire()
+
+	for _, replica := range client.replicas.all() {
+		if err := replica.ReAuth(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Select switches the client, and its replicas, to database db. The pooled connections are replaced
+// by new ones selecting db, like every connection opened afterwards (pub/sub, redirects, replicas).
+func (client *Client) Select(ctx context.Context, db int) error {
+	conn, err := client.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	// SELECT checks db is valid before every connection is replaced
+	err = selectDB(ctx, conn, db)
+	client.releaseConn(conn, err)
+	if err != nil {
+		return err
+	}
+	client.db.Store(int64(db))
+	client.pool.retire()
+
+	for _, replica := range client.replicas.all() {
+		if err := replica.Select(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dial opens a new connection to address, authenticated and with the configured database selected.
+// It gives up after the dial timeout or once ctx is done.
+func (client *Client) dial(ctx context.Context, address string) (IConnection, error) {
+	return client.dialWith(ctx, address, *client.opts)
+}
+
+// dialWith opens a connection to address with opts, a modified copy of the client options.
+func (client *Client) dialWith(ctx context.Context, address string, opts Options) (IConnection, error) {
+	ctx, cancel := context.WithTimeout(ctx, client.opts.DialTimeout)
+	defer cancel()
+	opts.DB = int(client.db.Load())
+	conn, err := newConnection(ctx, &opts, address)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// newConn opens a pooled connection to the client address.
+func (client *Client) newConn(ctx context.Context) (IConnection, error) {
+	return client.dial(ctx, client.address)
+}
+
+// getConn takes a connection from the pool for exclusive use until releaseConn, see pool.get.
+func (client *Client) getConn(ctx context.Context) (IConnection, error) {
+	return client.pool.get(ctx)
+}
+
+// releaseConn gives conn back to the pool, err is the error it was last used with (nil if none).
+func (client *Client) releaseConn(conn IConnection, err error) {
+	client.pool.put(conn, err)
+}
+
 func (client *Client) Close() error {
-	client.mu.Lock()
-	defer client.mu.Unlock()
-	return client.conn.Close()
+	client.replicas.close()
+	err := client.pool.close()
+	if client.mux != nil {
+		client.mux.close()
+	}
+	return err
 }
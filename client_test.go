@@ -1,8 +1,19 @@
 package resp
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // Mock objects and helpers
@@ -10,7 +21,7 @@ var (
 	AuthFunc    func(password string) error
 	PingFunc    func(ctx context.Context) error
 	SendFunc    func(command string) error
-	ReceiveFunc func() (string, error)
+	ReceiveFunc func() (interface{}, error)
 	CloseFunc   func() error
 )
 
@@ -26,27 +37,101 @@ func (m *mockConnection) Auth(ctx context.Context, password string) error {
 	return AuthFunc(password)
 }
 
+func (m *mockConnection) AuthUser(ctx context.Context, username string, password string) error {
+	return AuthFunc(username + " " + password)
+}
+
 func (m *mockConnection) Send(ctx context.Context, command string) error {
 	return SendFunc(command)
 }
 
+func (m *mockConnection) SendCommand(ctx context.Context, args ...interface{}) error {
+	cmd, err := encodeCommand(args...)
+	if err != nil {
+		return err
+	}
+	return SendFunc(string(cmd))
+}
+
+func (m *mockConnection) SendCommandFrom(ctx context.Context, r io.Reader, size int64, args ...interface{}) error {
+	value := make([]byte, size)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return err
+	}
+	return m.SendCommand(ctx, append(args, value)...)
+}
+
+func (m *mockConnection) WriteCommand(ctx context.Context, args ...interface{}) error {
+	return m.SendCommand(ctx, args...)
+}
+
+func (m *mockConnection) Flush(ctx context.Context) error {
+	return nil
+}
+
 func (m *mockConnection) Receive(ctx context.Context) (string, error) {
+	value, err := ReceiveFunc()
+	if err != nil {
+		return "", err
+	}
+	return flattenReply(value)
+}
+
+func (m *mockConnection) ReceiveValue(ctx context.Context) (interface{}, error) {
 	return ReceiveFunc()
 }
 
+func (m *mockConnection) ReceiveTo(ctx context.Context, w io.Writer) (int64, error) {
+	value, err := ReceiveFunc()
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return 0, ErrNil
+	}
+	n, err := io.WriteString(w, value.(string))
+	return int64(n), err
+}
+
 func (m *mockConnection) Close() error {
 	return CloseFunc()
 }
 
+// replySequence returns a ReceiveFunc handing out values one call at a time, error values are returned as errors
+func replySequence(values ...interface{}) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		value := values[0]
+		values = values[1:]
+		if err, ok := value.(error); ok {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// decodeCommand returns the arguments of command, as passed to SendFunc.
+func decodeCommand(command string) []string {
+	value, _ := newMockConnection(command, new(bytes.Buffer), time.Time{}).ReceiveValue(context.Background())
+	args, _ := NewReply(value).StringSlice()
+	return args
+}
+
+// recordCommand returns a SendFunc storing the arguments of the last command sent in sent.
+func recordCommand(sent *[]string) func(command string) error {
+	return func(command string) error {
+		*sent = decodeCommand(command)
+		return nil
+	}
+}
+
 // Helper function to create a Client with a mock dialer and connection
 func newMockClient(poolSize int, auth string) *Client {
-	client := &Client{
-		address: "localhost:6379",
-		auth:    auth,
-		dialer:  &MockDialer{},
-	}
+	opts := &Options{Address: "localhost:6379", Password: auth, Dialer: &MockDialer{}, MaxActive: poolSize}
+	client := newClient(opts.withDefaults())
 
-	client.conn = &mockConnection{}
+	client.pool.dial = func(ctx context.Context) (IConnection, error) {
+		return &mockConnection{}, nil
+	}
 
 	return client
 }
@@ -56,24 +141,59 @@ func TestClient_Do(t *testing.T) {
 	SendFunc = func(command string) error {
 		return nil
 	}
-	ReceiveFunc = func() (string, error) {
+	ReceiveFunc = func() (interface{}, error) {
 		return "OK", nil
 	}
 	client := newMockClient(2, "password")
-	response, err := client.Do(context.Background(), "PING")
+	reply, err := client.Do(context.Background(), "PING")
 	if err != nil {
 		t.Errorf("Do returned error: %s", err)
 	}
-	if response != "OK" {
+	if response, _ := reply.Text(); response != "OK" {
 		t.Errorf("Do did not return +OK, got: %s", response)
 	}
+
+	t.Run("encodes arguments", func(t *testing.T) {
+		var sent string
+		SendFunc = func(command string) error {
+			sent = command
+			return nil
+		}
+		if _, err := client.Do(context.Background(), "SET", "key", []byte("a\r\nb"), 10); err != nil {
+			t.Fatalf("Do returned error: %s", err)
+		}
+		if want := "*4\r\n$3\r\nSET\r\n$3\r\nkey\r\n$4\r\na\r\nb\r\n$2\r\n10\r\n"; sent != want {
+			t.Errorf("Do sent %q, want %q", sent, want)
+		}
+	})
+}
+
+func TestClient_Do_ArrayReply(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return []interface{}{"a", nil, int64(1)}, nil
+	}
+	client := newMockClient(2, "password")
+	reply, err := client.Do(context.Background(), "MGET", "a", "b", "c")
+	if err != nil {
+		t.Fatalf("Do returned error: %s", err)
+	}
+	values, err := reply.StringSlice()
+	if err != nil {
+		t.Fatalf("StringSlice returned error: %s", err)
+	}
+	if len(values) != 3 || values[0] != "a" || values[1] != "" || values[2] != "1" {
+		t.Errorf("Do did not return the array reply, got: %#v", values)
+	}
 }
 
 func TestClient_Set(t *testing.T) {
 	SendFunc = func(command string) error {
 		return nil
 	}
-	ReceiveFunc = func() (string, error) {
+	ReceiveFunc = func() (interface{}, error) {
 		return "OK", nil
 	}
 	client := newMockClient(2, "password")
@@ -87,8 +207,8 @@ func TestClient_Incr(t *testing.T) {
 	SendFunc = func(command string) error {
 		return nil
 	}
-	ReceiveFunc = func() (string, error) {
-		return ":1\r\n", nil
+	ReceiveFunc = func() (interface{}, error) {
+		return int64(1), nil
 	}
 	client := newMockClient(2, "password")
 	resp, err := client.Incr(context.Background(), "key")
@@ -100,12 +220,55 @@ func TestClient_Incr(t *testing.T) {
 	}
 }
 
+func TestClient_IncrBy(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	tests := []struct {
+		name string
+		call func() (int, error)
+		want string
+	}{
+		{name: "IncrBy", call: func() (int, error) { return client.IncrBy(context.Background(), "hits", 5) }, want: "INCRBY hits 5"},
+		{name: "Decr", call: func() (int, error) { return client.Decr(context.Background(), "hits") }, want: "DECR hits"},
+		{name: "DecrBy", call: func() (int, error) { return client.DecrBy(context.Background(), "hits", 3) }, want: "DECRBY hits 3"},
+	}
+	for _, tt := range tests {
+		ReceiveFunc = replySequence(int64(7))
+		if got, err := tt.call(); err != nil || got != 7 {
+			t.Errorf("%s got = %d, %v", tt.name, got, err)
+		}
+		if args := decodeCommand(sent); strings.Join(args, " ") != tt.want {
+			t.Errorf("%s sent %v, want %s", tt.name, args, tt.want)
+		}
+	}
+
+	ReceiveFunc = replySequence("10.5", "5.0e3", "not a number")
+	if got, err := client.IncrByFloat(context.Background(), "balance", 0.1); err != nil || got != 10.5 {
+		t.Errorf("IncrByFloat got = %v, %v", got, err)
+	}
+	if got, err := client.IncrByFloat(context.Background(), "balance", -1); err != nil || got != 5000 {
+		t.Errorf("IncrByFloat got = %v, %v", got, err)
+	}
+	if _, err := client.IncrByFloat(context.Background(), "balance", 1); err == nil {
+		t.Error("IncrByFloat expected an error for a reply that is not a number")
+	}
+	sent = ""
+	if _, err := client.IncrByFloat(context.Background(), "balance", math.Inf(1)); err == nil || sent != "" {
+		t.Errorf("IncrByFloat(+Inf) error = %v, sent %q", err, sent)
+	}
+}
+
 func TestClient_Expire(t *testing.T) {
 	SendFunc = func(command string) error {
 		return nil
 	}
-	ReceiveFunc = func() (string, error) {
-		return ":1", nil
+	ReceiveFunc = func() (interface{}, error) {
+		return int64(1), nil
 	}
 	client := newMockClient(2, "password")
 	success, err := client.Expire(context.Background(), "key", 1)
@@ -117,11 +280,78 @@ func TestClient_Expire(t *testing.T) {
 	}
 
 }
+func TestClient_PExpireAt(t *testing.T) {
+	t.Run("key exists", func(t *testing.T) {
+		var sent string
+		SendFunc = func(command string) error {
+			sent = command
+			return nil
+		}
+		ReceiveFunc = func() (interface{}, error) {
+			return int64(1), nil
+		}
+		client := newMockClient(2, "password")
+		success, err := client.PExpireAt(context.Background(), "key", 1700000000000)
+		if err != nil {
+			t.Errorf("PExpireAt returned error: %s", err)
+		}
+		if !success {
+			t.Errorf("invalid PExpireAt reponse")
+		}
+		if want := "*3\r\n$9\r\nPEXPIREAT\r\n$3\r\nkey\r\n$13\r\n1700000000000\r\n"; sent != want {
+			t.Errorf("PExpireAt sent %q, want %q", sent, want)
+		}
+	})
+
+	t.Run("invalid timestamp", func(t *testing.T) {
+		client := newMockClient(2, "password")
+		if _, err := client.PExpireAt(context.Background(), "key", 0); err == nil {
+			t.Errorf("PExpireAt expected error for non-positive timestamp")
+		}
+	})
+}
+
+func TestClient_ExpireConditions(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+	ctx := context.Background()
+	at := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name string
+		call func() (bool, error)
+		want string
+	}{
+		{name: "Expire GT", call: func() (bool, error) { return client.Expire(ctx, "key", 60, ExpireGT) }, want: "EXPIRE key 60 GT"},
+		{name: "PExpire", call: func() (bool, error) { return client.PExpire(ctx, "key", 1500*time.Millisecond) }, want: "PEXPIRE key 1500"},
+		{name: "PExpire under 1ms", call: func() (bool, error) { return client.PExpire(ctx, "key", 300*time.Microsecond) }, want: "PEXPIRE key 1"},
+		{name: "PExpire XX LT", call: func() (bool, error) { return client.PExpire(ctx, "key", time.Second, ExpireXX, ExpireLT) }, want: "PEXPIRE key 1000 XX LT"},
+		{name: "ExpireAt NX", call: func() (bool, error) { return client.ExpireAt(ctx, "key", at, ExpireNX) }, want: "EXPIREAT key 1700000000 NX"},
+		{name: "PExpireAt LT", call: func() (bool, error) { return client.PExpireAt(ctx, "key", at.UnixMilli(), ExpireLT) }, want: "PEXPIREAT key 1700000000000 LT"},
+	}
+	for _, tt := range tests {
+		ReceiveFunc = replySequence(int64(0))
+		if set, err := tt.call(); err != nil || set {
+			t.Errorf("%s got = %v, %v, want the condition not met", tt.name, set, err)
+		}
+		if args := decodeCommand(sent); strings.Join(args, " ") != tt.want {
+			t.Errorf("%s sent %v, want %s", tt.name, args, tt.want)
+		}
+	}
+	if _, err := client.ExpireAt(ctx, "key", time.Time{}); err == nil {
+		t.Error("ExpireAt expected an error for the zero time")
+	}
+}
+
 func TestClient_SetWithTTL(t *testing.T) {
 	SendFunc = func(command string) error {
 		return nil
 	}
-	ReceiveFunc = func() (string, error) {
+	ReceiveFunc = func() (interface{}, error) {
 		return "OK", nil
 	}
 	client := newMockClient(2, "password")
@@ -131,11 +361,142 @@ func TestClient_SetWithTTL(t *testing.T) {
 	}
 }
 
+func TestClient_SetWithExpiry(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return "OK", nil
+	}
+	client := newMockClient(1, "")
+
+	tests := []struct {
+		expiry time.Duration
+		want   string
+	}{
+		{expiry: 2 * time.Second, want: "$2\r\nEX\r\n$1\r\n2\r\n"},
+		{expiry: 1500 * time.Millisecond, want: "$2\r\nPX\r\n$4\r\n1500\r\n"},
+		{expiry: time.Millisecond + time.Microsecond, want: "$2\r\nPX\r\n$1\r\n1\r\n"},
+	}
+	for _, tt := range tests {
+		if err := client.SetWithExpiry(context.Background(), "key", "value", tt.expiry); err != nil {
+			t.Errorf("SetWithExpiry(%v) error = %v", tt.expiry, err)
+		}
+		if !strings.HasSuffix(sent, tt.want) {
+			t.Errorf("SetWithExpiry(%v) sent %q, want it to end with %q", tt.expiry, sent, tt.want)
+		}
+	}
+
+	sent = ""
+	for _, expiry := range []time.Duration{0, -time.Second, time.Microsecond} {
+		if err := client.SetWithExpiry(context.Background(), "key", "value", expiry); err == nil || sent != "" {
+			t.Errorf("SetWithExpiry(%v) error = %v, sent %q", expiry, err, sent)
+		}
+	}
+}
+
+func TestSetOptions_Args(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		opts    SetOptions
+		want    string
+		wantErr bool
+	}{
+		{name: "none", opts: SetOptions{}, want: "SET key value"},
+		{name: "lock", opts: SetOptions{NX: true, Expiry: 30 * time.Second}, want: "SET key value NX EX 30"},
+		{name: "update keeping the ttl", opts: SetOptions{XX: true, KeepTTL: true}, want: "SET key value XX KEEPTTL"},
+		{name: "swap", opts: SetOptions{Get: true, Expiry: 250 * time.Millisecond}, want: "SET key value PX 250 GET"},
+		{name: "expire at", opts: SetOptions{ExpireAt: at}, want: "SET key value EXAT 1700000000"},
+		{name: "expire at millisecond", opts: SetOptions{ExpireAt: at.Add(1500 * time.Millisecond)}, want: "SET key value PXAT 1700000001500"},
+		{name: "nx and xx", opts: SetOptions{NX: true, XX: true}, wantErr: true},
+		{name: "two expirations", opts: SetOptions{Expiry: time.Second, KeepTTL: true}, wantErr: true},
+		{name: "invalid expiry", opts: SetOptions{Expiry: -time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := tt.opts.args("key", "value")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("args() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := strings.TrimSpace(fmt.Sprintln(args...)); err == nil && got != tt.want {
+				t.Errorf("args() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_SetArgs(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("OK", nil)
+	if result, err := client.SetArgs(context.Background(), "lock", "owner", SetOptions{NX: true}); err != nil || !result.Set {
+		t.Errorf("SetArgs got = %+v, %v", result, err)
+	}
+	if result, err := client.SetArgs(context.Background(), "lock", "owner", SetOptions{NX: true}); err != nil || result.Set {
+		t.Errorf("SetArgs got = %+v, %v, want the key not set", result, err)
+	}
+
+	ReceiveFunc = replySequence("previous", nil, "previous")
+	if result, err := client.SetArgs(context.Background(), "key", "value", SetOptions{Get: true}); err != nil || !result.Set || *result.Old != "previous" {
+		t.Errorf("SetArgs got = %+v, %v", result, err)
+	}
+	if result, err := client.SetArgs(context.Background(), "key", "value", SetOptions{Get: true, XX: true}); err != nil || result.Set || result.Old != nil {
+		t.Errorf("SetArgs got = %+v, %v, want the missing key not set", result, err)
+	}
+	if result, err := client.SetArgs(context.Background(), "key", "value", SetOptions{Get: true, NX: true}); err != nil || result.Set || *result.Old != "previous" {
+		t.Errorf("SetArgs got = %+v, %v, want the existing key not set", result, err)
+	}
+}
+
+func TestClient_GetDelGetEx(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("token", nil)
+	if got, err := client.GetDel(context.Background(), "token:1"); err != nil || got != "token" {
+		t.Errorf("GetDel got = %q, %v", got, err)
+	}
+	if _, err := client.GetDel(context.Background(), "token:1"); !errors.Is(err, ErrNil) {
+		t.Errorf("GetDel error = %v, want %v once the token was used", err, ErrNil)
+	}
+
+	tests := []struct {
+		opts GetExOptions
+		want string
+	}{
+		{opts: GetExOptions{}, want: "GETEX key"},
+		{opts: GetExOptions{Expiry: time.Minute}, want: "GETEX key EX 60"},
+		{opts: GetExOptions{Expiry: 1500 * time.Millisecond}, want: "GETEX key PX 1500"},
+		{opts: GetExOptions{ExpireAt: time.UnixMilli(1700000000250)}, want: "GETEX key PXAT 1700000000250"},
+		{opts: GetExOptions{Persist: true}, want: "GETEX key PERSIST"},
+	}
+	for _, tt := range tests {
+		ReceiveFunc = replySequence("value")
+		if got, err := client.GetEx(context.Background(), "key", tt.opts); err != nil || got != "value" {
+			t.Errorf("GetEx(%+v) got = %q, %v", tt.opts, got, err)
+		}
+		if args := decodeCommand(sent); strings.Join(args, " ") != tt.want {
+			t.Errorf("GetEx(%+v) sent %v, want %s", tt.opts, args, tt.want)
+		}
+	}
+	if _, err := client.GetEx(context.Background(), "key", GetExOptions{Expiry: time.Second, Persist: true}); err == nil {
+		t.Error("GetEx expected an error for conflicting options")
+	}
+}
+
 func TestClient_Get(t *testing.T) {
 	SendFunc = func(command string) error {
 		return nil
 	}
-	ReceiveFunc = func() (string, error) {
+	ReceiveFunc = func() (interface{}, error) {
 		return "value", nil
 	}
 	client := newMockClient(2, "password")
@@ -146,14 +507,34 @@ func TestClient_Get(t *testing.T) {
 	if resp != "value" {
 		t.Errorf("invalid Get reponse")
 	}
+
+	t.Run("missing key", func(t *testing.T) {
+		ReceiveFunc = func() (interface{}, error) {
+			return nil, nil
+		}
+		resp, err := client.Get(context.Background(), "missing")
+		if !errors.Is(err, ErrNil) || resp != "" {
+			t.Errorf("Get got = %q, %v, want %v", resp, err, ErrNil)
+		}
+	})
+
+	t.Run("empty value", func(t *testing.T) {
+		ReceiveFunc = func() (interface{}, error) {
+			return "", nil
+		}
+		resp, err := client.Get(context.Background(), "empty")
+		if err != nil || resp != "" {
+			t.Errorf("Get got = %q, %v, want an empty string", resp, err)
+		}
+	})
 }
 
 func TestClient_Delete(t *testing.T) {
 	SendFunc = func(command string) error {
 		return nil
 	}
-	ReceiveFunc = func() (string, error) {
-		return ":1", nil
+	ReceiveFunc = func() (interface{}, error) {
+		return int64(1), nil
 	}
 	client := newMockClient(2, "password")
 	err := client.Delete(context.Background(), "key")
@@ -163,6 +544,58 @@ func TestClient_Delete(t *testing.T) {
 
 }
 
+func TestClient_CommandCount(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return int64(240), nil
+	}
+	client := newMockClient(2, "password")
+	count, err := client.CommandCount(context.Background())
+	if err != nil {
+		t.Errorf("CommandCount returned error: %s", err)
+	}
+	if count != 240 {
+		t.Errorf("CommandCount did not return valid reponse, got: %d", count)
+	}
+}
+
+func TestClient_DoNoCommand(t *testing.T) {
+	SendFunc = func(command string) error {
+		t.Errorf("Do() sent %q for an empty command", command)
+		return nil
+	}
+	client := newMockClient(2, "password")
+	if _, err := client.Do(context.Background()); !errors.Is(err, errNoCommand) {
+		t.Errorf("Do() error = %v, want %v", err, errNoCommand)
+	}
+}
+
+func TestClient_CommandExists(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	ReceiveFunc = replySequence(
+		[]interface{}{[]interface{}{"get", int64(2), []interface{}{"readonly", "fast"}, int64(1), int64(1), int64(1)}},
+		[]interface{}{nil},
+		[]interface{}{},
+	)
+	var client IClient = newMockClient(2, "password")
+
+	if exists, err := client.CommandExists(context.Background(), "get"); err != nil || !exists {
+		t.Errorf("CommandExists() got = %v, %v, want true", exists, err)
+	}
+	if want := []string{"COMMAND", "INFO", "get"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("CommandExists() sent %q, want %q", sent, want)
+	}
+	if exists, err := client.CommandExists(context.Background(), "json.get"); err != nil || exists {
+		t.Errorf("CommandExists() got = %v, %v, want false for an unknown command", exists, err)
+	}
+	if _, err := client.CommandExists(context.Background(), "get"); err == nil {
+		t.Error("CommandExists expected an error for an unexpected reply")
+	}
+}
+
 func TestClose(t *testing.T) {
 	CloseFunc = func() error {
 		return nil
@@ -173,3 +606,420 @@ func TestClose(t *testing.T) {
 		t.Errorf("Close did not close the channel")
 	}
 }
+
+func TestNewClientWithOptions_DialTimeout(t *testing.T) {
+	var network string
+	dialer := &MockDialer{DialFunc: func(ctx context.Context, n string, address string) (net.Conn, error) {
+		// an unreachable host: the dial only returns once the context gives up
+		network = n
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}}
+
+	start := time.Now()
+	_, err := NewClientWithOptions(&Options{Network: "unix", Address: "/tmp/redis.sock", Dialer: dialer, DialTimeout: 50 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("NewClientWithOptions() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("NewClientWithOptions() took %v, the dial timeout wasn't applied", elapsed)
+	}
+	if network != "unix" {
+		t.Errorf("Dial() network = %q, want %q", network, "unix")
+	}
+}
+
+func TestClient_Select(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return "OK", nil
+	}
+	client := newMockClient(2, "")
+	if err := client.Select(context.Background(), 3); err != nil {
+		t.Fatalf("Select returned error: %s", err)
+	}
+	if sent != "*2\r\n$6\r\nSELECT\r\n$1\r\n3\r\n" {
+		t.Errorf("Select sent %q", sent)
+	}
+	if db := client.db.Load(); db != 3 {
+		t.Errorf("selected db = %d, want 3", db)
+	}
+
+	ReceiveFunc = func() (interface{}, error) {
+		return nil, RedisError("ERR DB index is out of range")
+	}
+	if err := client.Select(context.Background(), 99); err == nil {
+		t.Error("Select expected an error")
+	}
+	if db := client.db.Load(); db != 3 {
+		t.Errorf("selected db = %d after a failed Select, want 3", db)
+	}
+}
+
+func TestClient_DialHandshake(t *testing.T) {
+	client, servers := newPipeClient("secret")
+	client.db.Store(2)
+	client.opts.ClientName = "billing-worker"
+	go func() {
+		// the whole handshake is sent before the server answers anything
+		server := <-servers
+		expectCommand(t, server, "AUTH", "")
+		expectCommand(t, server, "SELECT", "")
+		expectCommand(t, server, "CLIENT", "+OK\r\n+OK\r\n+OK\r\n")
+	}()
+
+	conn, err := client.dial(context.Background(), client.address)
+	if err != nil {
+		t.Fatalf("dial() error = %v", err)
+	}
+	_ = conn.Close()
+
+	go func() {
+		server := <-servers
+		expectCommand(t, server, "AUTH", "")
+		expectCommand(t, server, "SELECT", "")
+		expectCommand(t, server, "CLIENT", "+OK\r\n-ERR DB index is out of range\r\n+OK\r\n")
+	}()
+	if _, err := client.dial(context.Background(), client.address); err == nil {
+		t.Error("dial() expected an error for an invalid database")
+	}
+
+	// an ACL user authenticates and names the connection with HELLO
+	client.opts.Username = "billing"
+	go func() {
+		server := <-servers
+		value, err := server.ReceiveValue(context.Background())
+		if err != nil {
+			t.Errorf("server failed to read HELLO: %s", err)
+			return
+		}
+		if args, _ := NewReply(value).StringSlice(); strings.Join(args, " ") != "HELLO 2 AUTH billing secret SETNAME billing-worker" {
+			t.Errorf("server got %q, want HELLO with AUTH and SETNAME", args)
+		}
+		expectCommand(t, server, "SELECT", "*2\r\n$6\r\nserver\r\n$5\r\nredis\r\n+OK\r\n")
+	}()
+	conn, err = client.dial(context.Background(), client.address)
+	if err != nil {
+		t.Fatalf("dial() error = %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestNewClientWithOptions_Warmup(t *testing.T) {
+	var dials atomic.Int32
+	dialer := &MockDialer{DialFunc: func(ctx context.Context, network string, address string) (net.Conn, error) {
+		// every other dial fails
+		if dials.Add(1)%2 == 0 {
+			return nil, errors.New("connection refused")
+		}
+		clientConn, serverConn := net.Pipe()
+		t.Cleanup(func() { _ = serverConn.Close() })
+		return clientConn, nil
+	}}
+
+	client, err := NewClientWithOptions(&Options{Address: "localhost:6379", Dialer: dialer, Warmup: 4})
+	if err != nil {
+		t.Fatalf("NewClientWithOptions() error = %v", err)
+	}
+	if idle := len(client.(*Client).pool.idle); idle != 2 || dials.Load() != 4 {
+		t.Errorf("warmup left %d idle connections after %d dials, want 2 after 4", idle, dials.Load())
+	}
+
+	err = client.Warmup(context.Background(), 4)
+	var warmupErr *WarmupError
+	if !errors.As(err, &warmupErr) || warmupErr.Requested != 4 || len(warmupErr.Errors) != 1 {
+		t.Errorf("Warmup() error = %v, want 1 of 4 failed", err)
+	}
+	_ = client.Close()
+
+	// no connection could be opened at all
+	dialer.DialFunc = func(ctx context.Context, network string, address string) (net.Conn, error) {
+		return nil, errors.New("connection refused")
+	}
+	if _, err := NewClientWithOptions(&Options{Address: "localhost:6379", Dialer: dialer, Warmup: 2}); !errors.As(err, &warmupErr) {
+		t.Errorf("NewClientWithOptions() error = %v, want a warmup error", err)
+	}
+	lazy, err := NewClientWithOptions(&Options{Address: "localhost:6379", Dialer: dialer, LazyConnect: true})
+	if err != nil {
+		t.Errorf("NewClientWithOptions() error = %v, want none with LazyConnect", err)
+	}
+	_ = lazy.Close()
+}
+
+func TestClient_Retry(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	CloseFunc = func() error { return nil }
+	client := newMockClient(2, "")
+	dialed := 0
+	client.pool.dial = func(ctx context.Context) (IConnection, error) {
+		dialed++
+		return &mockConnection{}, nil
+	}
+
+	// the server closes the idle connection every other command
+	ReceiveFunc = replySequence("PONG", io.EOF, "PONG", io.EOF, "PONG")
+	if _, err := client.Do(context.Background(), "PING"); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if _, err := client.Do(context.Background(), "PING"); !errors.Is(err, io.EOF) {
+		t.Fatalf("Do() error = %v, want %v", err, io.EOF)
+	}
+	if _, err := client.Do(context.Background(), "PING"); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	client.opts.MaxRetries = 1
+	reply, err := client.Do(context.Background(), "PING")
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got, _ := reply.Text(); got != "PONG" || dialed != 3 {
+		t.Errorf("Do() got %q after %d dials, want PONG after 3", got, dialed)
+	}
+
+	// error replies don't say anything about the connection
+	ReceiveFunc = replySequence(RedisError("ERR unknown command"))
+	if _, err := client.Do(context.Background(), "PINGG"); err == nil || dialed != 3 {
+		t.Errorf("Do() error = %v after %d dials, want the error reply without a retry", err, dialed)
+	}
+
+	// then the server is gone: dial errors are retried too, up to MaxRetries times
+	client.opts.MaxRetries = 2
+	ReceiveFunc = replySequence(io.EOF)
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	client.pool.dial = func(ctx context.Context) (IConnection, error) {
+		dialed++
+		return nil, refused
+	}
+	if _, err := client.Do(context.Background(), "PING"); !errors.Is(err, syscall.ECONNREFUSED) || dialed != 5 {
+		t.Errorf("Do() error = %v after %d dials, want %v after 5", err, dialed, refused)
+	}
+}
+
+func TestClient_RetryErrorReplies(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	CloseFunc = func() error { return nil }
+	client := newMockClient(2, "")
+	client.opts.MaxRetries = 1
+	dialed := 0
+	client.pool.dial = func(ctx context.Context) (IConnection, error) {
+		dialed++
+		return &mockConnection{}, nil
+	}
+
+	ReceiveFunc = replySequence(RedisError("LOADING Redis is loading the dataset in memory"), "PONG")
+	if _, err := client.Do(context.Background(), "PING"); err != nil || dialed != 1 {
+		t.Errorf("Do() error = %v after %d dials, want the command retried on the same connection", err, dialed)
+	}
+
+	// the primary was demoted, the retry goes through a new connection
+	ReceiveFunc = replySequence(RedisError("READONLY You can't write against a read only replica."), "OK")
+	if _, err := client.Do(context.Background(), "SET", "key", "value"); err != nil || dialed != 2 {
+		t.Errorf("Do() error = %v after %d dials, want the command retried on a new connection", err, dialed)
+	}
+
+	ReceiveFunc = replySequence(RedisError("WRONGTYPE Operation against a key holding the wrong kind of value"))
+	if _, err := client.Do(context.Background(), "GET", "key"); err == nil {
+		t.Error("Do() expected the WRONGTYPE error, it isn't retried")
+	}
+}
+
+func TestClient_CommandError(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	CloseFunc = func() error { return nil }
+	client := newMockClient(2, "")
+	client.opts.MaxRetries = 1
+
+	ReceiveFunc = replySequence(io.EOF, io.EOF)
+	_, err := client.Do(context.Background(), "get", "user:1")
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || !errors.Is(err, io.EOF) {
+		t.Fatalf("Do() error = %v, want a CommandError wrapping %v", err, io.EOF)
+	}
+	if want := "resp: GET on localhost:6379 attempt 2: EOF"; err.Error() != want {
+		t.Errorf("Do() error = %q, want %q", err, want)
+	}
+
+	client.opts.MaxRetries = 0
+	client.opts.ErrorArgs = true
+	ReceiveFunc = replySequence(io.EOF)
+	if _, err := client.Do(context.Background(), "SET", "user:1", []byte("value")); err == nil || err.Error() != "resp: SET user:1 value on localhost:6379 attempt 1: EOF" {
+		t.Errorf("Do() error = %v, want the arguments included", err)
+	}
+
+	// error replies are returned as they are
+	ReceiveFunc = replySequence(RedisError("WRONGTYPE Operation against a key holding the wrong kind of value"))
+	if _, err := client.Do(context.Background(), "GET", "user:1"); !errors.As(err, new(RedisError)) || errors.As(err, &cmdErr) {
+		t.Errorf("Do() error = %#v, want the RedisError", err)
+	}
+}
+
+func TestClient_Bytes(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	value := []byte("line\r\n\x00\xff")
+	ReceiveFunc = replySequence("OK", string(value))
+	client := newMockClient(2, "")
+
+	if err := client.SetBytes(context.Background(), "blob", value); err != nil {
+		t.Fatalf("SetBytes returned error: %s", err)
+	}
+	if want := "*3\r\n$3\r\nSET\r\n$4\r\nblob\r\n$8\r\nline\r\n\x00\xff\r\n"; sent != want {
+		t.Errorf("SetBytes sent %q, want %q", sent, want)
+	}
+	got, err := client.GetBytes(context.Background(), "blob")
+	if err != nil || !bytes.Equal(got, value) {
+		t.Errorf("GetBytes got = %q, %v, want %q", got, err, value)
+	}
+
+	ReceiveFunc = replySequence(nil)
+	if _, err := client.GetBytes(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("GetBytes error = %v, want %v", err, ErrNil)
+	}
+}
+
+func TestClient_GetTo(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	CloseFunc = func() error { return nil }
+	client := newMockClient(1, "")
+	dialed := 0
+	client.pool.dial = func(ctx context.Context) (IConnection, error) {
+		dialed++
+		return &mockConnection{}, nil
+	}
+
+	ReceiveFunc = replySequence("large value", nil)
+	var buf bytes.Buffer
+	if n, err := client.GetTo(context.Background(), "blob", &buf); err != nil || n != 11 || buf.String() != "large value" {
+		t.Errorf("GetTo got %d bytes %q, %v", n, buf.String(), err)
+	}
+	if _, err := client.GetTo(context.Background(), "missing", &buf); !errors.Is(err, ErrNil) {
+		t.Errorf("GetTo error = %v, want %v", err, ErrNil)
+	}
+	if dialed != 1 {
+		t.Errorf("GetTo dialed %d connections, want the connection reused after a nil reply", dialed)
+	}
+}
+
+func TestClient_SetFrom(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	CloseFunc = func() error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("OK", "QUEUED")
+	if err := client.SetFrom(context.Background(), "blob", strings.NewReader("large value"), 11); err != nil {
+		t.Fatalf("SetFrom error = %v", err)
+	}
+	if want := "*3\r\n$3\r\nSET\r\n$4\r\nblob\r\n$11\r\nlarge value\r\n"; sent != want {
+		t.Errorf("SetFrom sent %q, want %q", sent, want)
+	}
+	if err := client.SetFrom(context.Background(), "blob", strings.NewReader("large value"), 11); err == nil {
+		t.Error("SetFrom expected an error for an unexpected reply")
+	}
+}
+
+func TestClient_Cancel(t *testing.T) {
+	client, servers := newPipeClient("")
+	client.pool = newPool(client.newConn, &Options{MaxActive: 1, MaxIdle: 1, PoolTimeout: DefaultPoolTimeout})
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		server := <-servers
+		// the command is read but never answered before the caller gives up
+		if _, err := server.ReceiveValue(context.Background()); err != nil {
+			t.Errorf("server failed to read GET: %s", err)
+		}
+		cancel()
+	}()
+	if _, err := client.Do(ctx, "GET", "slow"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do() error = %v, want %v", err, context.Canceled)
+	}
+	if stats := client.PoolStats(); stats.IdleConns != 0 {
+		t.Errorf("PoolStats() got %+v, want the interrupted connection closed", stats)
+	}
+
+	go func() {
+		expectCommand(t, <-servers, "GET", "$5\r\nfresh\r\n")
+	}()
+	if got, err := client.Get(context.Background(), "fast"); err != nil || got != "fresh" {
+		t.Errorf("Get() got = %q, %v", got, err)
+	}
+}
+
+func TestClient_MGetMSet(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence([]interface{}{"1", nil, "3"}, []interface{}{"1"})
+	values, err := client.MGet(context.Background(), "a", "missing", "c")
+	if err != nil || len(values) != 3 || *values[0] != "1" || values[1] != nil || *values[2] != "3" {
+		t.Fatalf("MGet got = %v, %v", values, err)
+	}
+	if want := "*4\r\n$4\r\nMGET\r\n$1\r\na\r\n$7\r\nmissing\r\n$1\r\nc\r\n"; sent != want {
+		t.Errorf("MGet sent %q, want %q", sent, want)
+	}
+	if _, err := client.MGet(context.Background(), "a", "b"); err == nil {
+		t.Error("MGet expected an error for a reply of the wrong length")
+	}
+
+	ReceiveFunc = replySequence("OK")
+	if err := client.MSet(context.Background(), map[string]string{"a": "1"}); err != nil {
+		t.Fatalf("MSet error = %v", err)
+	}
+	if want := "*3\r\n$4\r\nMSET\r\n$1\r\na\r\n$1\r\n1\r\n"; sent != want {
+		t.Errorf("MSet sent %q, want %q", sent, want)
+	}
+
+	// no command is sent without keys, the server would reject it
+	sent = ""
+	if values, err := client.MGet(context.Background()); err != nil || values != nil || sent != "" {
+		t.Errorf("MGet() got = %v, %v, sent %q", values, err, sent)
+	}
+	if err := client.MSet(context.Background(), nil); err != nil || sent != "" {
+		t.Errorf("MSet(nil) error = %v, sent %q", err, sent)
+	}
+}
+
+func TestClient_SetNX(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(1), int64(0))
+	if set, err := client.SetNX(context.Background(), "lock", "owner"); err != nil || !set {
+		t.Errorf("SetNX got = %v, %v", set, err)
+	}
+	if set, err := client.SetNX(context.Background(), "lock", "owner"); err != nil || set {
+		t.Errorf("SetNX got = %v, %v, want the existing key kept", set, err)
+	}
+
+	ReceiveFunc = replySequence(int64(1), int64(0))
+	if set, err := client.MSetNX(context.Background(), map[string]string{"a": "1"}); err != nil || !set {
+		t.Errorf("MSetNX got = %v, %v", set, err)
+	}
+	if want := "*3\r\n$6\r\nMSETNX\r\n$1\r\na\r\n$1\r\n1\r\n"; sent != want {
+		t.Errorf("MSetNX sent %q, want %q", sent, want)
+	}
+	if set, err := client.MSetNX(context.Background(), map[string]string{"a": "1", "b": "2"}); err != nil || set {
+		t.Errorf("MSetNX got = %v, %v, want nothing set", set, err)
+	}
+}
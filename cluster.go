@@ -0,0 +1,129 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SlotCount is the number of hash slots a cluster shards its keys over.
+const SlotCount = 16384
+
+// KeySlot returns the cluster hash slot of key: CRC16 of the key, or of its hash tag when it has one, modulo SlotCount.
+func KeySlot(key string) int {
+	return int(crc16(hashTag(key)) % SlotCount)
+}
+
+// HashTagKey builds a key hashed on tag only ("{tag}suffix"), so keys sharing a tag land in the same slot
+// and can be used together in multi-key commands.
+func HashTagKey(tag string, suffix string) string {
+	return "{" + tag + "}" + suffix
+}
+
+// SameSlot reports whether all keys hash to the same slot, which multi-key commands require in a cluster.
+func SameSlot(keys ...string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	slot := KeySlot(keys[0])
+	for _, key := range keys[1:] {
+		if KeySlot(key) != slot {
+			return false
+		}
+	}
+	return true
+}
+
+// hashTag returns the part of key that is hashed: the content of the first non-empty {...} section, or the whole key.
+func hashTag(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return key
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 { // no closing brace, or an empty tag "{}"
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
+// crc16 implements CRC16-CCITT (XMODEM), the checksum redis cluster uses to assign slots.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// MovedError is a -MOVED error reply: the slot of the key is now served by the node at Addr
+// and every following request for it should be sent there.
+type MovedError struct {
+	Slot int
+	Addr string
+}
+
+func (e *MovedError) Error() string {
+	return fmt.Sprintf("MOVED %d %s", e.Slot, e.Addr)
+}
+
+func (e *MovedError) replyError() {}
+
+// AskError is a -ASK error reply: the slot is being migrated to the node at Addr and this request
+// must be retried there once, preceded by ASKING. Client.Do follows it on its own.
+type AskError struct {
+	Slot int
+	Addr string
+}
+
+func (e *AskError) Error() string {
+	return fmt.Sprintf("ASK %d %s", e.Slot, e.Addr)
+}
+
+func (e *AskError) replyError() {}
+
+// parseRedirect builds a MovedError or AskError from "MOVED <slot> <addr>" and "ASK <slot> <addr>" error replies,
+// other replies are not redirections.
+func parseRedirect(msg string) (error, bool) {
+	fields := strings.Fields(msg)
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return nil, false
+	}
+	slot, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, false
+	}
+	if fields[0] == "MOVED" {
+		return &MovedError{Slot: slot, Addr: fields[2]}, true
+	}
+	return &AskError{Slot: slot, Addr: fields[2]}, true
+}
+
+// doAsking retries a command redirected by -ASK on the importing node, on a one-off connection
+// since the ASKING flag only applies to the next command.
+func (client *Client) doAsking(ctx context.Context, addr string, args []interface{}) (*Reply, error) {
+	conn, err := client.dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	replies, err := execPipeline(ctx, conn, [][]interface{}{{"ASKING"}, args})
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		if err := reply.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return replies[1], nil
+}
@@ -0,0 +1,107 @@
+package resp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestKeySlot(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"123456789", 12739}, // CRC16 XMODEM check value 0x31C3
+		{"foo", 12182},
+		{"{user1000}.following", 3443},
+		{"{user1000}.followers", 3443},
+		{"foo{}{bar}", KeySlot("foo{}{bar}")},
+		{"foo{{bar}}zap", KeySlot("{bar")},
+		{"foo{bar}{zap}", KeySlot("bar")},
+	}
+	for _, tt := range tests {
+		if got := KeySlot(tt.key); got != tt.want {
+			t.Errorf("KeySlot(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+	if KeySlot("foo{}{bar}") != int(crc16("foo{}{bar}")%SlotCount) {
+		t.Error("KeySlot() hashed an empty tag")
+	}
+}
+
+func TestHashTagKey(t *testing.T) {
+	a := HashTagKey("user:1", ":profile")
+	b := HashTagKey("user:1", ":sessions")
+	if a != "{user:1}:profile" {
+		t.Errorf("HashTagKey() got = %s", a)
+	}
+	if !SameSlot(a, b) {
+		t.Error("SameSlot() got = false for keys sharing a tag")
+	}
+	if SameSlot("foo", "bar") {
+		t.Error("SameSlot() got = true for keys in different slots")
+	}
+	if !SameSlot() || !SameSlot("foo") {
+		t.Error("SameSlot() got = false for fewer than two keys")
+	}
+}
+
+func TestConnection_ReceiveRedirect(t *testing.T) {
+	t.Run("moved", func(t *testing.T) {
+		conn := newMockConnection("-MOVED 3999 127.0.0.1:6381\r\n", new(bytes.Buffer), time.Time{})
+		_, err := conn.ReceiveValue(context.Background())
+		var moved *MovedError
+		if !errors.As(err, &moved) {
+			t.Fatalf("ReceiveValue() got = %v, want MovedError", err)
+		}
+		if moved.Slot != 3999 || moved.Addr != "127.0.0.1:6381" {
+			t.Errorf("ReceiveValue() got = %+v", moved)
+		}
+	})
+
+	t.Run("ask", func(t *testing.T) {
+		conn := newMockConnection("-ASK 3999 127.0.0.1:6381\r\n", new(bytes.Buffer), time.Time{})
+		_, err := conn.ReceiveValue(context.Background())
+		var ask *AskError
+		if !errors.As(err, &ask) {
+			t.Fatalf("ReceiveValue() got = %v, want AskError", err)
+		}
+		if ask.Slot != 3999 || ask.Addr != "127.0.0.1:6381" {
+			t.Errorf("ReceiveValue() got = %+v", ask)
+		}
+	})
+
+	t.Run("other errors", func(t *testing.T) {
+		conn := newMockConnection("-MOVED not a redirect\r\n", new(bytes.Buffer), time.Time{})
+		_, err := conn.ReceiveValue(context.Background())
+		var moved *MovedError
+		if err == nil || errors.As(err, &moved) {
+			t.Errorf("ReceiveValue() got = %v, want plain error", err)
+		}
+	})
+}
+
+func TestClient_Do_FollowsAsk(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return nil, &AskError{Slot: 3999, Addr: "127.0.0.1:6381"}
+	}
+	client, servers := newPipeClient("")
+	go func() {
+		server := <-servers
+		expectCommand(t, server, "ASKING", "+OK\r\n")
+		expectCommand(t, server, "GET", "$3\r\nbar\r\n")
+	}()
+
+	reply, err := client.Do(context.Background(), "GET", "foo")
+	if err != nil {
+		t.Fatalf("Do returned error: %s", err)
+	}
+	if text, _ := reply.Text(); text != "bar" {
+		t.Errorf("Do got = %v, want bar", reply.Value())
+	}
+}
@@ -0,0 +1,92 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// errUnsupportedArg is returned for arguments encodeCommand can't encode, before anything is written.
+var errUnsupportedArg = errors.New("unsupported argument type")
+
+// errNoCommand is returned for an empty command, which the server would wait on forever.
+var errNoCommand = errors.New("no command")
+
+// encodeCommand serializes args into a RESP array of bulk strings, the format redis expects for commands.
+// Strings and byte slices are sent as is, numbers and booleans in their decimal form.
+func encodeCommand(args ...interface{}) ([]byte, error) {
+	if len(args) == 0 {
+		return nil, errNoCommand
+	}
+	buf := make([]byte, 0, 64)
+	buf = appendArrayHeader(buf, len(args))
+	return appendArgs(buf, args)
+}
+
+func appendArrayHeader(buf []byte, length int) []byte {
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(length), 10)
+	return append(buf, '\r', '\n')
+}
+
+// appendArgs appends every argument of a command as a bulk string.
+func appendArgs(buf []byte, args []interface{}) ([]byte, error) {
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case string:
+			buf = appendBulkString(buf, arg)
+		case []byte:
+			buf = appendBulkBytes(buf, arg)
+		case int:
+			buf = appendBulkString(buf, strconv.FormatInt(int64(arg), 10))
+		case int8:
+			buf = appendBulkString(buf, strconv.FormatInt(int64(arg), 10))
+		case int16:
+			buf = appendBulkString(buf, strconv.FormatInt(int64(arg), 10))
+		case int32:
+			buf = appendBulkString(buf, strconv.FormatInt(int64(arg), 10))
+		case int64:
+			buf = appendBulkString(buf, strconv.FormatInt(arg, 10))
+		case uint:
+			buf = appendBulkString(buf, strconv.FormatUint(uint64(arg), 10))
+		case uint8:
+			buf = appendBulkString(buf, strconv.FormatUint(uint64(arg), 10))
+		case uint16:
+			buf = appendBulkString(buf, strconv.FormatUint(uint64(arg), 10))
+		case uint32:
+			buf = appendBulkString(buf, strconv.FormatUint(uint64(arg), 10))
+		case uint64:
+			buf = appendBulkString(buf, strconv.FormatUint(arg, 10))
+		case float32:
+			buf = appendBulkString(buf, strconv.FormatFloat(float64(arg), 'f', -1, 32))
+		case float64:
+			buf = appendBulkString(buf, strconv.FormatFloat(arg, 'f', -1, 64))
+		case bool:
+			if arg {
+				buf = appendBulkString(buf, "1")
+			} else {
+				buf = appendBulkString(buf, "0")
+			}
+		default:
+			return nil, fmt.Errorf("%w %T", errUnsupportedArg, arg)
+		}
+	}
+
+	return buf, nil
+}
+
+func appendBulkString(buf []byte, s string) []byte {
+	buf = append(buf, '$')
+	buf = strconv.AppendInt(buf, int64(len(s)), 10)
+	buf = append(buf, '\r', '\n')
+	buf = append(buf, s...)
+	return append(buf, '\r', '\n')
+}
+
+func appendBulkBytes(buf []byte, b []byte) []byte {
+	buf = append(buf, '$')
+	buf = strconv.AppendInt(buf, int64(len(b)), 10)
+	buf = append(buf, '\r', '\n')
+	buf = append(buf, b...)
+	return append(buf, '\r', '\n')
+}
@@ -0,0 +1,45 @@
+package resp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEncodeCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{"strings", []interface{}{"GET", "key"}, "*2\r\n$3\r\nGET\r\n$3\r\nkey\r\n"},
+		{"binary safe bytes", []interface{}{"SET", "k", []byte("a\r\nb")}, "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$4\r\na\r\nb\r\n"},
+		{"empty string", []interface{}{"SET", "k", ""}, "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$0\r\n\r\n"},
+		{"integers", []interface{}{"EXPIRE", "k", 60, int64(-1), uint8(2)}, "*5\r\n$6\r\nEXPIRE\r\n$1\r\nk\r\n$2\r\n60\r\n$2\r\n-1\r\n$1\r\n2\r\n"},
+		{"floats", []interface{}{"INCRBYFLOAT", "k", 1.5, float32(0.25)}, "*4\r\n$11\r\nINCRBYFLOAT\r\n$1\r\nk\r\n$3\r\n1.5\r\n$4\r\n0.25\r\n"},
+		{"booleans", []interface{}{true, false}, "*2\r\n$1\r\n1\r\n$1\r\n0\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeCommand(tt.args...)
+			if err != nil {
+				t.Fatalf("encodeCommand() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("encodeCommand() got = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("unsupported type", func(t *testing.T) {
+		if _, err := encodeCommand("SET", "k", struct{}{}); err == nil {
+			t.Error("encodeCommand() expected error for unsupported type")
+		}
+	})
+
+	t.Run("no command", func(t *testing.T) {
+		if _, err := encodeCommand(); !errors.Is(err, errNoCommand) {
+			t.Errorf("encodeCommand() error = %v, want %v", err, errNoCommand)
+		}
+	})
+}
@@ -2,58 +2,287 @@ package resp
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 )
 
 type IConnection interface {
 	Auth(ctx context.Context, password string) error
+	AuthUser(ctx context.Context, username string, password string) error
 	Ping(ctx context.Context) error
 	Send(ctx context.Context, command string) error
+	SendCommand(ctx context.Context, args ...interface{}) error
+	SendCommandFrom(ctx context.Context, r io.Reader, size int64, args ...interface{}) error
+	WriteCommand(ctx context.Context, args ...interface{}) error
+	Flush(ctx context.Context) error
 	Receive(ctx context.Context) (string, error)
+	ReceiveValue(ctx context.Context) (interface{}, error)
+	ReceiveTo(ctx context.Context, w io.Writer) (int64, error)
 	Close() error
 }
+
+// replyError is implemented by the errors built from error replies, as opposed to I/O errors.
+type replyError interface {
+	error
+	replyError()
+}
+
+// isReplyError reports whether err is or wraps an error reply, a nil err is not.
+func isReplyError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var replyErr replyError
+	return errors.As(err, &replyErr)
+}
+
+// isBrokenConn reports whether err means the connection was closed, by the server (restart, timeout,
+// CLIENT KILL...) or on the way, as opposed to a timeout or an error reply.
+func isBrokenConn(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// timeoutError is an operation that didn't complete in time. Besides the error it wraps it matches
+// context.DeadlineExceeded with errors.Is and implements net.Error, so both ways of checking for timeouts work.
+type timeoutError struct {
+	err   error
+	reply bool // timed out waiting for a reply, the command was written
+}
+
+func (e *timeoutError) Error() string   { return e.err.Error() }
+func (e *timeoutError) Unwrap() []error { return []error{e.err, context.DeadlineExceeded} }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+// wrapTimeout turns the network timeouts (net.Error) of err into timeoutError, other errors are returned as is.
+func wrapTimeout(err error) error {
+	var netErr net.Error
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &netErr) || !netErr.Timeout() {
+		return err
+	}
+	return &timeoutError{err: err}
+}
+
+// aLongTimeAgo is a deadline in the past, setting it makes the pending reads and writes fail right away.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// interruptOnCancel makes the reads and writes in progress fail as soon as ctx is cancelled, by moving
+// the connection deadline to the past, until the returned func is called. The deadline of ctx itself is
+// applied with SetReadDeadline and SetWriteDeadline before every operation.
+func (rc *Connection) interruptOnCancel(ctx context.Context) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		_ = rc.conn.SetDeadline(aLongTimeAgo)
+		close(interrupted)
+	})
+	return func() {
+		if !stop() {
+			// wait for the deadline to be set, so it can't clobber the one of the next operation
+			<-interrupted
+		}
+	}
+}
+
+// ErrProtocol is wrapped by the errors of replies that don't follow RESP, e.g. an unknown type byte. The rest
+// of the stream can't be made sense of, so the connection is closed.
+var ErrProtocol = errors.New("protocol error")
+
+// ioError reports the error of an interrupted operation as the cancellation of ctx, see interruptOnCancel,
+// and turns network timeouts into timeoutError. The connection must be closed after any of them, it is
+// closed here after a protocol error.
+func (rc *Connection) ioError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, ErrProtocol) {
+		_ = rc.conn.Close()
+		return err
+	}
+	if ctxErr := ctx.Err(); errors.Is(ctxErr, context.Canceled) {
+		return ctxErr
+	}
+	return wrapTimeout(err)
+}
+
+// readError is ioError for the reads of a reply, it marks timeouts as waiting for the reply, see
+// isServerFailure.
+func (rc *Connection) readError(ctx context.Context, err error) error {
+	err = rc.ioError(ctx, err)
+	var timeout *timeoutError
+	if errors.As(err, &timeout) {
+		timeout.reply = true
+	}
+	return err
+}
+
+// RedisError is an error reply sent by the server, e.g. "WRONGTYPE Operation against a key holding
+// the wrong kind of value". Redirections in a cluster are reported as MovedError and AskError instead.
+type RedisError string
+
+func (e RedisError) Error() string {
+	return string(e)
+}
+
+// Code returns the error code, the first word of the reply: ERR, WRONGTYPE, NOAUTH, OOM...
+func (e RedisError) Code() string {
+	code, _, _ := strings.Cut(string(e), " ")
+	return code
+}
+
+func (e RedisError) replyError() {}
+
 type Connection struct {
-	conn net.Conn
-	rw   *bufio.ReadWriter
+	conn         net.Conn
+	rw           *bufio.ReadWriter
+	readTimeout  time.Duration // used when the context has no deadline, DefaultReadTimeout when zero
+	writeTimeout time.Duration // used when the context has no deadline, DefaultWriteTimeout when zero
 }
 
+const (
+	// DefaultDialTimeout bounds dialing and the connection handshake when no dial timeout is configured.
+	DefaultDialTimeout = 5 * time.Second
+	// DefaultReadTimeout and DefaultWriteTimeout bound reads and writes whose context has no deadline.
+	DefaultReadTimeout  = 5 * time.Second
+	DefaultWriteTimeout = 5 * time.Second
+	// DefaultBufferSize is the size of the read and write buffers of a connection when none is configured.
+	DefaultBufferSize = 4096
+)
+
 func NewRedisConnection(dialer IDialer, address string, auth string) (IConnection, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultDialTimeout)
 	defer cancel()
+	return NewRedisConnectionContext(ctx, dialer, "tcp", address, auth)
+}
+
+// NewRedisConnectionContext connects to address and authenticates, giving up once ctx is done.
+func NewRedisConnectionContext(ctx context.Context, dialer IDialer, network string, address string, auth string) (IConnection, error) {
+	return newConnection(ctx, &Options{Network: network, Password: auth, Dialer: dialer}, address)
+}
 
-	conn, err := dialer.Dial(ctx, address)
+// newConnection dials address as configured by opts and runs the connection handshake:
+// AUTH when credentials are set, SELECT when a database other than 0 is and CLIENT SETNAME when a client name is.
+// The handshake commands are pipelined, so it takes a single round trip whatever the configuration.
+func newConnection(ctx context.Context, opts *Options, address string) (*Connection, error) {
+	conn, err := opts.Dialer.Dial(ctx, opts.Network, address)
 	if err != nil {
-		return nil, err
+		return nil, wrapTimeout(err)
 	}
 
 	rc := &Connection{
-		conn: conn,
-		rw:   bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)),
+		conn:         conn,
+		rw:           bufio.NewReadWriter(bufio.NewReaderSize(conn, bufferSize(opts.ReadBufferSize)), bufio.NewWriterSize(conn, bufferSize(opts.WriteBufferSize))),
+		readTimeout:  opts.ReadTimeout,
+		writeTimeout: opts.WriteTimeout,
 	}
 
-	if auth != "" {
-		// Authenticate with Redis using the AUTH command
-		if err := rc.Auth(ctx, auth); err != nil {
-			_ = conn.Close()
-			return nil, err
+	username, password, err := opts.credentials(ctx)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	var cmds [][]interface{}
+	clientName := opts.ClientName
+	if username != "" {
+		// An ACL user means Redis 6+, HELLO 2 authenticates and names the connection at once and stays on RESP2.
+		// Without one the server may be older and not know HELLO.
+		hello := []interface{}{"HELLO", 2, "AUTH", username, password}
+		if clientName != "" {
+			hello = append(hello, "SETNAME", clientName)
+			clientName = ""
 		}
+		cmds = append(cmds, hello)
+	} else if password != "" {
+		cmds = append(cmds, []interface{}{"AUTH", password})
+	}
+	if opts.DB != 0 {
+		cmds = append(cmds, []interface{}{"SELECT", opts.DB})
+	}
+	if clientName != "" {
+		cmds = append(cmds, []interface{}{"CLIENT", "SETNAME", clientName})
+	}
+	if opts.readOnly {
+		cmds = append(cmds, []interface{}{"READONLY"})
+	}
+	if err := rc.handshake(ctx, cmds); err != nil {
+		_ = conn.Close()
+		return nil, err
 	}
 
 	return rc, nil
 }
 
+// handshake sends cmds in one pipeline and checks every one of them was answered as expected, see handshakeOK.
+func (rc *Connection) handshake(ctx context.Context, cmds [][]interface{}) error {
+	if len(cmds) == 0 {
+		return nil
+	}
+	replies, err := execPipeline(ctx, rc, cmds)
+	if err != nil {
+		return err
+	}
+	// The first failure is reported, when AUTH fails the following commands only fail with NOAUTH
+	for i, reply := range replies {
+		if err := reply.Err(); err != nil {
+			return err
+		}
+		if !handshakeOK(cmds[i][0], reply) {
+			return fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(cmds[i][0].(string)), reply.Value())
+		}
+	}
+	return nil
+}
+
+// handshakeOK reports whether reply is the answer expected for command: the server properties for HELLO,
+// OK for the others.
+func handshakeOK(command interface{}, reply *Reply) bool {
+	if command == "HELLO" {
+		_, err := reply.Array()
+		return err == nil
+	}
+	text, err := reply.Text()
+	return err == nil && text == "OK"
+}
+
+// authenticate sends AUTH <username> <password> for ACL users, AUTH <password> when there is no username
+// and nothing when there is no password either.
+func authenticate(ctx context.Context, conn IConnection, username string, password string) error {
+	if username != "" {
+		return conn.AuthUser(ctx, username, password)
+	}
+	if password != "" {
+		return conn.Auth(ctx, password)
+	}
+	return nil
+}
+
 func (rc *Connection) Auth(ctx context.Context, password string) error {
+	return rc.auth(ctx, "AUTH", password)
+}
+
+// AuthUser authenticates as an ACL user (Redis 6+).
+func (rc *Connection) AuthUser(ctx context.Context, username string, password string) error {
+	return rc.auth(ctx, "AUTH", username, password)
+}
+
+func (rc *Connection) auth(ctx context.Context, args ...interface{}) error {
 	// Check if the context has been canceled before attempting the read operation
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	if err := rc.Send(ctx, fmt.Sprintf("AUTH %s", password)); err != nil {
+	if err := rc.SendCommand(ctx, args...); err != nil {
 		return err
 	}
 	reply, err := rc.Receive(ctx)
@@ -66,6 +295,20 @@ func (rc *Connection) Auth(ctx context.Context, password string) error {
 	return nil
 }
 
+func selectDB(ctx context.Context, conn IConnection, db int) error {
+	if err := conn.SendCommand(ctx, "SELECT", db); err != nil {
+		return err
+	}
+	reply, err := conn.Receive(ctx)
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("select: unexpected response from server %v", reply)
+	}
+	return nil
+}
+
 func (rc *Connection) Ping(ctx context.Context) error {
 	// Check if the context has been canceled before attempting the operation
 	if err := ctx.Err(); err != nil {
@@ -73,7 +316,7 @@ func (rc *Connection) Ping(ctx context.Context) error {
 	}
 
 	// Send the PING command to the Redis server
-	if err := rc.Send(ctx, "PING"); err != nil {
+	if err := rc.SendCommand(ctx, "PING"); err != nil {
 		return err
 	}
 
@@ -91,66 +334,360 @@ func (rc *Connection) Ping(ctx context.Context) error {
 	return nil
 }
 
+// Send writes command as an inline command: arguments are split on spaces, so it can't carry values holding
+// spaces, CRLF or binary data. SendCommand sends every argument length-prefixed instead.
 func (rc *Connection) Send(ctx context.Context, command string) error {
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	deadline, ok := ctx.Deadline()
-	if !ok { // Default deadline if none is set
-		deadline = time.Now().Add(5 * time.Second)
-	}
-	if err := rc.conn.SetWriteDeadline(deadline); err != nil {
+	if err := rc.setWriteDeadline(ctx); err != nil {
 		return err
 	}
 
+	stop := rc.interruptOnCancel(ctx)
+	defer stop()
+
 	_, err := rc.rw.WriteString(command + "\r\n")
+	if err != nil {
+		return rc.ioError(ctx, err)
+	}
+
+	return rc.ioError(ctx, rc.rw.Flush())
+}
+
+// SendCommand encodes args as a RESP array (see encodeCommand) and writes it to the server.
+func (rc *Connection) SendCommand(ctx context.Context, args ...interface{}) error {
+	if err := rc.WriteCommand(ctx, args...); err != nil {
+		return err
+	}
+	return rc.Flush(ctx)
+}
+
+// SendCommandFrom is like SendCommand with one more argument, size bytes read from r, which is copied
+// to the connection in chunks instead of being held in memory. When ctx has no deadline the write timeout
+// applies to every chunk. The connection must be closed after an error, the command may be partly sent.
+func (rc *Connection) SendCommandFrom(ctx context.Context, r io.Reader, size int64, args ...interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// the array header counts the streamed argument, which is sent after the others
+	cmd, err := appendArgs(appendArrayHeader(nil, len(args)+1), args)
+	if err != nil {
+		return err
+	}
+	cmd = append(cmd, '$')
+	cmd = strconv.AppendInt(cmd, size, 10)
+	cmd = append(cmd, '\r', '\n')
+
+	stop := rc.interruptOnCancel(ctx)
+	defer stop()
+	body := &deadlineWriter{rc: rc, ctx: ctx}
+	if _, err := body.Write(cmd); err != nil {
+		return rc.ioError(ctx, err)
+	}
+	n, err := io.CopyN(body, r, size)
+	if err != nil {
+		if err == io.EOF {
+			err = fmt.Errorf("sendCommandFrom: reader ended after %d of %d bytes: %w", n, size, io.ErrUnexpectedEOF)
+		}
+		return rc.ioError(ctx, err)
+	}
+	if _, err := body.Write([]byte("\r\n")); err != nil {
+		return rc.ioError(ctx, err)
+	}
+	return rc.Flush(ctx)
+}
+
+// deadlineWriter writes to the connection, pushing the write deadline back before every write.
+type deadlineWriter struct {
+	rc  *Connection
+	ctx context.Context
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	if err := w.rc.setWriteDeadline(w.ctx); err != nil {
+		return 0, err
+	}
+	return w.rc.rw.Write(p)
+}
+
+// WriteCommand is like SendCommand but leaves the command in the write buffer until Flush,
+// so several commands can be sent in one write.
+func (rc *Connection) WriteCommand(ctx context.Context, args ...interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cmd, err := encodeCommand(args...)
 	if err != nil {
 		return err
 	}
+	if err := rc.setWriteDeadline(ctx); err != nil {
+		return err
+	}
 
-	return rc.rw.Flush()
+	stop := rc.interruptOnCancel(ctx)
+	defer stop()
+	_, err = rc.rw.Write(cmd)
+	return rc.ioError(ctx, err)
 }
 
-func (rc *Connection) Receive(ctx context.Context) (string, error) {
+func (rc *Connection) Flush(ctx context.Context) error {
 	if err := ctx.Err(); err != nil {
-		return "", err
+		return err
 	}
-	deadline, ok := ctx.Deadline()
-	if !ok { // Default deadline if none is set
-		deadline = time.Now().Add(5 * time.Second)
+	if err := rc.setWriteDeadline(ctx); err != nil {
+		return err
 	}
+	stop := rc.interruptOnCancel(ctx)
+	defer stop()
+	return rc.ioError(ctx, rc.rw.Flush())
+}
 
-	if err := rc.conn.SetReadDeadline(deadline); err != nil {
+func (rc *Connection) setWriteDeadline(ctx context.Context) error {
+	return rc.conn.SetWriteDeadline(deadline(ctx, rc.writeTimeout, DefaultWriteTimeout))
+}
+
+// bufferSize returns size, or DefaultBufferSize when it is not set.
+func bufferSize(size int) int {
+	if size <= 0 {
+		return DefaultBufferSize
+	}
+	return size
+}
+
+// deadline returns the deadline of ctx, or one timeout from now when ctx has none.
+func deadline(ctx context.Context, timeout time.Duration, defaultTimeout time.Duration) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	if timeout < 0 {
+		return time.Time{} // no deadline
+	}
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	return time.Now().Add(timeout)
+}
+
+// Receive reads a single reply as a string, integers are prefixed with ':' and nil replies return ErrNil
+// so they can't be mistaken for empty strings. Arrays are not supported, see ReceiveValue.
+func (rc *Connection) Receive(ctx context.Context) (string, error) {
+	value, err := rc.ReceiveValue(ctx)
+	if err != nil {
 		return "", err
 	}
+	return flattenReply(value)
+}
+
+// ReceiveValue reads a single reply in structured form: simple and bulk strings as string,
+// integers as int64, nil bulk strings and nil arrays as nil, and arrays as []interface{}
+// holding any of these (error replies nested in an array are kept as error values).
+func (rc *Connection) ReceiveValue(ctx context.Context) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := rc.conn.SetReadDeadline(deadline(ctx, rc.readTimeout, DefaultReadTimeout)); err != nil {
+		return nil, err
+	}
+	stop := rc.interruptOnCancel(ctx)
+	defer stop()
+
+	value, err := rc.readValue()
+	if err != nil {
+		return nil, rc.readError(ctx, err)
+	}
+	if replyErr, ok := value.(replyError); ok {
+		return nil, replyErr
+	}
+	return value, nil
+}
+
+// ReceiveTo reads a bulk string reply and copies it to w as it arrives, in chunks, instead of holding it
+// in memory, and returns the number of bytes copied. When ctx has no deadline the read timeout applies to
+// every chunk, so large values aren't cut short. A nil reply returns ErrNil and an error reply its error;
+// after any other error (including one from w) the reply is partly read and the connection must be closed.
+func (rc *Connection) ReceiveTo(ctx context.Context, w io.Writer) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := rc.conn.SetReadDeadline(deadline(ctx, rc.readTimeout, DefaultReadTimeout)); err != nil {
+		return 0, err
+	}
+	stop := rc.interruptOnCancel(ctx)
+	defer stop()
+	line, err := rc.readLine()
+	if err != nil {
+		return 0, rc.readError(ctx, err)
+	}
+	if line[0] != '$' {
+		// read the rest of the reply, the connection stays usable
+		value, err := rc.parseValue(line)
+		if err != nil {
+			return 0, rc.readError(ctx, err)
+		}
+		if replyErr, ok := value.(replyError); ok {
+			return 0, replyErr
+		}
+		if value == nil {
+			return 0, ErrNil
+		}
+		return 0, fmt.Errorf("receiveTo: unexpected %T reply", value)
+	}
+
+	length, err := strconv.ParseInt(line[1:len(line)-2], 10, 64)
+	if err != nil || length < -1 {
+		return 0, rc.ioError(ctx, fmt.Errorf("%w: invalid bulk string length %q", ErrProtocol, line[1:len(line)-2]))
+	}
+	if length == -1 {
+		return 0, ErrNil
+	}
+	body := &deadlineReader{rc: rc, ctx: ctx}
+	n, err := io.CopyN(w, body, length)
+	if err != nil {
+		return n, rc.readError(ctx, err)
+	}
+	var crlf [2]byte
+	if _, err := io.ReadFull(body, crlf[:]); err != nil {
+		return n, rc.readError(ctx, err)
+	}
+	if crlf != [2]byte{'\r', '\n'} {
+		return n, rc.ioError(ctx, fmt.Errorf("%w: bulk string of length %d not terminated by CRLF", ErrProtocol, length))
+	}
+	return n, nil
+}
+
+// deadlineReader reads from the connection, pushing the read deadline back before every read.
+type deadlineReader struct {
+	rc  *Connection
+	ctx context.Context
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	if err := r.rc.conn.SetReadDeadline(deadline(r.ctx, r.rc.readTimeout, DefaultReadTimeout)); err != nil {
+		return 0, err
+	}
+	return r.rc.rw.Read(p)
+}
+
+// bulkPreallocLimit is the largest bulk string read into a buffer allocated upfront, larger ones are read
+// into a growing buffer so a corrupt length can't make the client allocate gigabytes before any data arrives.
+const bulkPreallocLimit = 1 << 20
+
+func (rc *Connection) readValue() (interface{}, error) {
+	line, err := rc.readLine()
+	if err != nil {
+		return nil, err
+	}
+	return rc.parseValue(line)
+}
 
+// readLine reads the first line of a reply, a type byte and a payload terminated by CRLF. The line may
+// arrive in any number of reads, it is buffered until the LF.
+func (rc *Connection) readLine() (string, error) {
 	line, err := rc.rw.ReadString('\n')
 	if err != nil {
 		return "", err
 	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return "", fmt.Errorf("%w: invalid reply line %q, want a type and CRLF", ErrProtocol, line)
+	}
+	return line, nil
+}
 
+// readBulk reads a bulk string payload of length bytes and its CRLF, however many reads it takes.
+func (rc *Connection) readBulk(length int) ([]byte, error) {
+	var buf []byte
+	if length <= bulkPreallocLimit {
+		buf = make([]byte, length+2) // +2 for the CRLF (\r\n)
+		if _, err := io.ReadFull(rc.rw, buf); err != nil {
+			return nil, err
+		}
+	} else {
+		var b bytes.Buffer
+		b.Grow(bulkPreallocLimit)
+		if _, err := io.CopyN(&b, rc.rw, int64(length)+2); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		buf = b.Bytes()
+	}
+	if buf[length] != '\r' || buf[length+1] != '\n' {
+		return nil, fmt.Errorf("%w: bulk string of length %d not terminated by CRLF", ErrProtocol, length)
+	}
+	return buf[:length], nil
+}
+
+// parseValue parses the reply starting with line, reading the rest of it (bulk payload, array elements).
+func (rc *Connection) parseValue(line string) (interface{}, error) {
+	payload := line[1 : len(line)-2] // trim the type prefix and the CRLF, see readLine
 	switch line[0] {
-	case '-': // Handle simple error
-		return "", fmt.Errorf(strings.TrimSuffix(line[1:], "\r\n"))
-	case '$': //Assume the reply is a bulk string ,array serialization ain't supported in this client
-		length, _ := strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n")) //trim the CRLF from our response
+	case '-': // Handle simple error, returned as a value so errors nested in arrays don't abort the read
+		if redirect, ok := parseRedirect(payload); ok {
+			return redirect, nil
+		}
+		return RedisError(payload), nil
+	case '+': // Handle simple string, return the string without the '+' prefix
+		return payload, nil
+	case ':':
+		number, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid integer reply %q", ErrProtocol, payload)
+		}
+		return number, nil
+	case '$':
+		length, err := strconv.Atoi(payload)
+		if err != nil || length < -1 {
+			return nil, fmt.Errorf("%w: invalid bulk string length %q", ErrProtocol, payload)
+		}
 		if length == -1 {
 			// This is a nil reply
-			return "", nil
+			return nil, nil
 		}
-		buf := make([]byte, length+2) // +2 for the CRLF (\r\n)
-		_, err = rc.rw.Read(buf)
+		// The payload is read by its length, it may hold CRLF or any other byte
+		buf, err := rc.readBulk(length)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		return string(buf[:length]), nil
-	case '+': // Handle simple string, return the string without the '+' prefix
-		return strings.TrimSuffix(line[1:], "\r\n"), nil
+		return string(buf), nil
+	case '*':
+		length, err := strconv.Atoi(payload)
+		if err != nil || length < -1 {
+			return nil, fmt.Errorf("%w: invalid array length %q", ErrProtocol, payload)
+		}
+		if length == -1 {
+			// This is a nil array
+			return nil, nil
+		}
+		// the elements are appended as they are read, a corrupt length fails on the missing elements
+		values := make([]interface{}, 0, min(length, 1024))
+		for i := 0; i < length; i++ {
+			value, err := rc.readValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
 	default:
-		return strings.TrimSuffix(line, "\r\n"), nil
-
+		return nil, fmt.Errorf("%w: unknown reply type %q", ErrProtocol, line[0])
 	}
+}
 
+// flattenReply converts a scalar reply into the string form returned by Receive,
+// integer replies keep their ':' prefix.
+func flattenReply(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", ErrNil
+	case string:
+		return value, nil
+	case int64:
+		return ":" + strconv.FormatInt(value, 10), nil
+	default:
+		return "", fmt.Errorf("unexpected %T reply", value)
+	}
 }
 
 func (rc *Connection) Close() error {
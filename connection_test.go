@@ -6,9 +6,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -75,6 +80,22 @@ func TestConnection_Auth(t *testing.T) {
 	})
 }
 
+func TestConnection_AuthUser(t *testing.T) {
+	conn := newMockConnection("+OK\r\n", new(bytes.Buffer), time.Time{})
+	if err := conn.AuthUser(context.Background(), "app", "secret"); err != nil {
+		t.Errorf("AuthUser should succeed, got error: %v", err)
+	}
+	want := "*3\r\n$4\r\nAUTH\r\n$3\r\napp\r\n$6\r\nsecret\r\n"
+	if sent := conn.conn.(*MockNetConn).WriteBuffer.String(); sent != want {
+		t.Errorf("AuthUser sent %q, want %q", sent, want)
+	}
+
+	conn = newMockConnection("-WRONGPASS invalid username-password pair or user is disabled.\r\n", new(bytes.Buffer), time.Time{})
+	if err := conn.AuthUser(context.Background(), "app", "wrong"); err == nil {
+		t.Errorf("Expected an authentication error, got nil")
+	}
+}
+
 func TestConnection_Send(t *testing.T) {
 	t.Run("send data within deadline", func(t *testing.T) {
 		commandToSend := "SET key value"
@@ -123,6 +144,36 @@ func TestConnection_Send(t *testing.T) {
 		}
 	})
 
+	t.Run("send encoded command", func(t *testing.T) {
+		mockConn := newMockConnection("", new(bytes.Buffer), time.Time{})
+		err := mockConn.SendCommand(context.Background(), "SET", "key", "value")
+		if err != nil {
+			t.Fatalf("SendCommand() error = %v, wantErr %v", err, nil)
+		}
+
+		expectedData := "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nvalue\r\n"
+		if gotData := mockConn.conn.(*MockNetConn).WriteBuffer.String(); gotData != expectedData {
+			t.Errorf("SendCommand() got = %q, want %q", gotData, expectedData)
+		}
+	})
+
+	t.Run("write commands then flush", func(t *testing.T) {
+		mockConn := newMockConnection("", new(bytes.Buffer), time.Time{})
+		_ = mockConn.WriteCommand(context.Background(), "PING")
+		_ = mockConn.WriteCommand(context.Background(), "PING")
+		if gotData := mockConn.conn.(*MockNetConn).WriteBuffer.String(); gotData != "" {
+			t.Errorf("WriteCommand() wrote %q before Flush", gotData)
+		}
+		if err := mockConn.Flush(context.Background()); err != nil {
+			t.Fatalf("Flush() error = %v, wantErr %v", err, nil)
+		}
+
+		expectedData := "*1\r\n$4\r\nPING\r\n*1\r\n$4\r\nPING\r\n"
+		if gotData := mockConn.conn.(*MockNetConn).WriteBuffer.String(); gotData != expectedData {
+			t.Errorf("Flush() got = %q, want %q", gotData, expectedData)
+		}
+	})
+
 	//todo send data with write error
 	//todo send data after connection closed
 
@@ -162,11 +213,35 @@ func TestConnection_Receive(t *testing.T) {
 	t.Run("receive nil bulk string response", func(t *testing.T) {
 		conn := newMockConnection("$-1\r\n", new(bytes.Buffer), time.Time{})
 		data, err := conn.Receive(context.Background())
+		if !errors.Is(err, ErrNil) || data != "" {
+			t.Errorf("Receive() got = %q, %v, want %v", data, err, ErrNil)
+		}
+	})
+
+	t.Run("receive empty bulk string response", func(t *testing.T) {
+		conn := newMockConnection("$0\r\n\r\n", new(bytes.Buffer), time.Time{})
+		data, err := conn.Receive(context.Background())
+		if err != nil || data != "" {
+			t.Errorf("Receive() got = %q, %v, want an empty string", data, err)
+		}
+	})
+
+	t.Run("receive integer response", func(t *testing.T) {
+		conn := newMockConnection(":42\r\n", new(bytes.Buffer), time.Time{})
+		data, err := conn.Receive(context.Background())
 		if err != nil {
 			t.Fatalf("Receive() error = %v, wantErr %v", err, nil)
 		}
-		if data != "" {
-			t.Errorf("Receive() got = %v, want empty string", data)
+		if data != ":42" {
+			t.Errorf("Receive() got = %v, want %v", data, ":42")
+		}
+	})
+
+	t.Run("receive array response", func(t *testing.T) {
+		conn := newMockConnection("*2\r\n$3\r\nfoo\r\n$3\r\nbar\r\n", new(bytes.Buffer), time.Time{})
+		_, err := conn.Receive(context.Background())
+		if err == nil {
+			t.Error("Receive() expected error for an array reply, got none")
 		}
 	})
 
@@ -182,3 +257,287 @@ func TestConnection_Receive(t *testing.T) {
 	})
 	//todo receive data after connection closed
 }
+
+func TestConnection_ReceiveValue(t *testing.T) {
+	t.Run("receive flat array", func(t *testing.T) {
+		conn := newMockConnection("*3\r\n$3\r\nfoo\r\n$-1\r\n:7\r\n", new(bytes.Buffer), time.Time{})
+		value, err := conn.ReceiveValue(context.Background())
+		if err != nil {
+			t.Fatalf("ReceiveValue() error = %v, wantErr %v", err, nil)
+		}
+		values, ok := value.([]interface{})
+		if !ok || len(values) != 3 {
+			t.Fatalf("ReceiveValue() got = %#v, want 3 elements", value)
+		}
+		if values[0] != "foo" || values[1] != nil || values[2] != int64(7) {
+			t.Errorf("ReceiveValue() got = %#v", values)
+		}
+	})
+
+	t.Run("receive nested array", func(t *testing.T) {
+		conn := newMockConnection("*2\r\n*2\r\n+a\r\n+b\r\n*0\r\n", new(bytes.Buffer), time.Time{})
+		value, err := conn.ReceiveValue(context.Background())
+		if err != nil {
+			t.Fatalf("ReceiveValue() error = %v, wantErr %v", err, nil)
+		}
+		values := value.([]interface{})
+		inner, ok := values[0].([]interface{})
+		if !ok || len(inner) != 2 || inner[0] != "a" || inner[1] != "b" {
+			t.Errorf("ReceiveValue() got inner = %#v", values[0])
+		}
+		if empty, ok := values[1].([]interface{}); !ok || len(empty) != 0 {
+			t.Errorf("ReceiveValue() got = %#v, want empty array", values[1])
+		}
+	})
+
+	t.Run("receive nil array", func(t *testing.T) {
+		conn := newMockConnection("*-1\r\n", new(bytes.Buffer), time.Time{})
+		value, err := conn.ReceiveValue(context.Background())
+		if err != nil {
+			t.Fatalf("ReceiveValue() error = %v, wantErr %v", err, nil)
+		}
+		if value != nil {
+			t.Errorf("ReceiveValue() got = %#v, want nil", value)
+		}
+	})
+
+	t.Run("receive array with error element", func(t *testing.T) {
+		conn := newMockConnection("*2\r\n+OK\r\n-ERR wrong type\r\n+PONG\r\n", new(bytes.Buffer), time.Time{})
+		value, err := conn.ReceiveValue(context.Background())
+		if err != nil {
+			t.Fatalf("ReceiveValue() error = %v, wantErr %v", err, nil)
+		}
+		values := value.([]interface{})
+		if replyErr, ok := values[1].(error); !ok || replyErr.Error() != "ERR wrong type" {
+			t.Errorf("ReceiveValue() got = %#v, want error element", values[1])
+		}
+
+		// the stream must be left at the next reply
+		next, err := conn.Receive(context.Background())
+		if err != nil || next != "PONG" {
+			t.Errorf("Receive() after array got = %v, %v", next, err)
+		}
+	})
+	t.Run("receive binary bulk string", func(t *testing.T) {
+		conn := newMockConnection("$7\r\na\r\nb\x00c\xff\r\n", new(bytes.Buffer), time.Time{})
+		value, err := conn.ReceiveValue(context.Background())
+		if err != nil || value != "a\r\nb\x00c\xff" {
+			t.Errorf("ReceiveValue() got = %q, %v", value, err)
+		}
+	})
+
+	t.Run("receive bulk string over several reads", func(t *testing.T) {
+		clientConn, serverConn := net.Pipe()
+		defer serverConn.Close()
+		conn := &Connection{conn: clientConn, rw: bufio.NewReadWriter(bufio.NewReader(clientConn), bufio.NewWriter(clientConn))}
+		defer conn.Close()
+		go func() {
+			_, _ = serverConn.Write([]byte("$11\r\nhello"))
+			_, _ = serverConn.Write([]byte(" you\r\n\r\n"))
+		}()
+		value, err := conn.ReceiveValue(context.Background())
+		if err != nil || value != "hello you\r\n" {
+			t.Errorf("ReceiveValue() got = %q, %v", value, err)
+		}
+	})
+
+	t.Run("receive truncated bulk string", func(t *testing.T) {
+		conn := newMockConnection("$8\r\nabcdefghij\r\n", new(bytes.Buffer), time.Time{})
+		if _, err := conn.ReceiveValue(context.Background()); err == nil {
+			t.Error("ReceiveValue() expected an error for a bulk string longer than its length")
+		}
+	})
+
+	t.Run("receive error reply", func(t *testing.T) {
+		conn := newMockConnection("-WRONGTYPE Operation against a key holding the wrong kind of value\r\n", new(bytes.Buffer), time.Time{})
+		_, err := conn.ReceiveValue(context.Background())
+		var redisErr RedisError
+		if !errors.As(err, &redisErr) || redisErr.Code() != "WRONGTYPE" {
+			t.Errorf("ReceiveValue() error = %#v, want a WRONGTYPE RedisError", err)
+		}
+	})
+}
+
+func TestConnection_ReadTimeout(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	rc := &Connection{
+		conn:        clientConn,
+		rw:          bufio.NewReadWriter(bufio.NewReader(clientConn), bufio.NewWriter(clientConn)),
+		readTimeout: 20 * time.Millisecond,
+	}
+	defer rc.Close()
+
+	// the server never answers, the read gives up after the read timeout since ctx has no deadline
+	start := time.Now()
+	_, err := rc.ReceiveValue(context.Background())
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() || !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("ReceiveValue() error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ReceiveValue() took %v, the read timeout wasn't applied", elapsed)
+	}
+}
+
+func TestConnection_NoReadTimeout(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	rc := &Connection{
+		conn:        clientConn,
+		rw:          bufio.NewReadWriter(bufio.NewReader(clientConn), bufio.NewWriter(clientConn)),
+		readTimeout: -1,
+	}
+	defer rc.Close()
+
+	// a negative read timeout waits for the reply as long as it takes
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_, _ = serverConn.Write([]byte("+late\r\n"))
+	}()
+	if value, err := rc.ReceiveValue(context.Background()); err != nil || value != "late" {
+		t.Errorf("ReceiveValue() got = %v, %v", value, err)
+	}
+	if got := deadline(context.Background(), -1, DefaultReadTimeout); !got.IsZero() {
+		t.Errorf("deadline() = %v, want no deadline", got)
+	}
+}
+
+// chunkWriter records how many writes a value was copied in.
+type chunkWriter struct {
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func TestConnection_ReceiveTo(t *testing.T) {
+	t.Run("large value", func(t *testing.T) {
+		clientConn, serverConn := net.Pipe()
+		defer serverConn.Close()
+		conn := &Connection{conn: clientConn, rw: bufio.NewReadWriter(bufio.NewReader(clientConn), bufio.NewWriter(clientConn))}
+		defer conn.Close()
+		value := bytes.Repeat([]byte("0123456789abcdef"), 64*1024) // 1MiB
+		go func() {
+			_, _ = serverConn.Write([]byte("$" + strconv.Itoa(len(value)) + "\r\n"))
+			_, _ = serverConn.Write(value)
+			_, _ = serverConn.Write([]byte("\r\n"))
+		}()
+
+		w := &chunkWriter{}
+		n, err := conn.ReceiveTo(context.Background(), w)
+		if err != nil || n != int64(len(value)) || !bytes.Equal(w.buf.Bytes(), value) {
+			t.Fatalf("ReceiveTo() got %d bytes, %v", n, err)
+		}
+		if w.writes < 2 {
+			t.Errorf("ReceiveTo() copied the value in %d write, want chunks", w.writes)
+		}
+	})
+
+	t.Run("nil and other replies", func(t *testing.T) {
+		conn := newMockConnection("$-1\r\n-ERR wrong type\r\n:1\r\n+PONG\r\n", new(bytes.Buffer), time.Time{})
+		if _, err := conn.ReceiveTo(context.Background(), io.Discard); !errors.Is(err, ErrNil) {
+			t.Errorf("ReceiveTo() error = %v, want %v", err, ErrNil)
+		}
+		if _, err := conn.ReceiveTo(context.Background(), io.Discard); !isReplyError(err) {
+			t.Errorf("ReceiveTo() error = %v, want the error reply", err)
+		}
+		if _, err := conn.ReceiveTo(context.Background(), io.Discard); err == nil {
+			t.Error("ReceiveTo() expected an error for an integer reply")
+		}
+		// the replies were read whole
+		if got, err := conn.Receive(context.Background()); err != nil || got != "PONG" {
+			t.Errorf("Receive() got = %q, %v", got, err)
+		}
+	})
+}
+
+func TestConnection_SendCommandFrom(t *testing.T) {
+	t.Run("streamed value", func(t *testing.T) {
+		conn := newMockConnection("", new(bytes.Buffer), time.Time{})
+		if err := conn.SendCommandFrom(context.Background(), strings.NewReader("a\r\nvalue"), 8, "SET", "key"); err != nil {
+			t.Fatalf("SendCommandFrom() error = %v", err)
+		}
+		want := "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$8\r\na\r\nvalue\r\n"
+		if got := conn.conn.(*MockNetConn).WriteBuffer.String(); got != want {
+			t.Errorf("SendCommandFrom() wrote %q, want %q", got, want)
+		}
+	})
+
+	t.Run("short reader", func(t *testing.T) {
+		conn := newMockConnection("", new(bytes.Buffer), time.Time{})
+		err := conn.SendCommandFrom(context.Background(), strings.NewReader("short"), 8, "SET", "key")
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("SendCommandFrom() error = %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+}
+
+func TestConnection_Cancel(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer serverConn.Close()
+	conn := &Connection{conn: clientConn, rw: bufio.NewReadWriter(bufio.NewReader(clientConn), bufio.NewWriter(clientConn))}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := conn.ReceiveValue(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ReceiveValue() error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ReceiveValue() returned after %v, want right after the cancellation", elapsed)
+	}
+}
+
+func TestConnection_Framing(t *testing.T) {
+	// newConn reads data one byte at a time, as if every byte of the replies came in its own TCP segment
+	newConn := func(data string) *Connection {
+		r := bufio.NewReader(iotest.OneByteReader(strings.NewReader(data)))
+		return &Connection{conn: &MockNetConn{}, rw: bufio.NewReadWriter(r, bufio.NewWriter(io.Discard))}
+	}
+
+	t.Run("fragmented replies", func(t *testing.T) {
+		conn := newConn("+OK\r\n:42\r\n$7\r\na\r\nb\r\nc\r\n*2\r\n$1\r\nx\r\n*1\r\n-ERR nested\r\n$-1\r\n")
+		want := []interface{}{"OK", int64(42), "a\r\nb\r\nc", []interface{}{"x", []interface{}{RedisError("ERR nested")}}, nil}
+		for _, w := range want {
+			got, err := conn.ReceiveValue(context.Background())
+			if err != nil || !reflect.DeepEqual(got, w) {
+				t.Errorf("ReceiveValue() got = %#v, %v, want %#v", got, err, w)
+			}
+		}
+	})
+
+	t.Run("large value", func(t *testing.T) {
+		value := strings.Repeat("0123456789abcdef", bulkPreallocLimit/8) // twice the preallocation limit
+		conn := newMockConnection("$"+strconv.Itoa(len(value))+"\r\n"+value+"\r\n", new(bytes.Buffer), time.Time{})
+		if got, err := conn.ReceiveValue(context.Background()); err != nil || got != value {
+			t.Errorf("ReceiveValue() got %d bytes, %v", len(fmt.Sprint(got)), err)
+		}
+		conn = newMockConnection("$"+strconv.Itoa(len(value))+"\r\n"+value[:1000], new(bytes.Buffer), time.Time{})
+		if _, err := conn.ReceiveValue(context.Background()); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("ReceiveValue() error = %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		conn := newConn("%1\r\n+key\r\n+value\r\n")
+		if got, err := conn.ReceiveValue(context.Background()); !errors.Is(err, ErrProtocol) {
+			t.Errorf("ReceiveValue() got = %#v, %v, want %v", got, err, ErrProtocol)
+		}
+		if !conn.conn.(*MockNetConn).Closed {
+			t.Error("ReceiveValue() left the connection open after a protocol error")
+		}
+	})
+
+	for _, reply := range []string{"+OK\n", "\r\n", "*-5\r\n", "$-2\r\n", "$abc\r\n", "*2\r\n:1\r\n"} {
+		t.Run("invalid "+strconv.Quote(reply), func(t *testing.T) {
+			if got, err := newConn(reply).ReceiveValue(context.Background()); err == nil {
+				t.Errorf("ReceiveValue() got = %#v, want an error", got)
+			}
+		})
+	}
+}
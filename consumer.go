@@ -0,0 +1,285 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// StreamHandler processes an entry read by a Consumer from stream. The entry is acknowledged when it returns
+// nil and stays pending otherwise, to be delivered again.
+type StreamHandler func(ctx context.Context, stream string, entry StreamEntry) error
+
+// ConsumerOptions configure a Consumer.
+type ConsumerOptions struct {
+	Group   string        // the consumer group, see Client.XGroupCreate
+	Name    string        // the consumer name, unique among the consumers of the group
+	Streams []string      // the keys of the streams read
+	Count   int           // entries per read, 10 when zero
+	Block   time.Duration // how long a read waits for new entries, 5s when zero
+
+	// ClaimMinIdle enables the claimer: entries pending for at least ClaimMinIdle, whatever their consumer,
+	// are claimed with XAUTOCLAIM and handled again. It must be longer than the handler takes.
+	ClaimMinIdle time.Duration
+	// ClaimInterval is how often the claimer looks for idle entries, ClaimMinIdle when zero.
+	ClaimInterval time.Duration
+
+	// MaxDeliveries enables dead-lettering: an entry delivered more than MaxDeliveries times, which keeps
+	// failing, is moved to the dead-letter stream instead of being handled again. It requires ClaimMinIdle:
+	// the loop only goes through the pending entries of the consumer once, when it starts, so an entry
+	// failing afterwards is only delivered again by the claimer.
+	MaxDeliveries int
+	// DeadLetterStream is the key of the dead-letter stream, the key of the stream with a ":dead" suffix
+	// when empty. Entries are added with their fields and a new ID.
+	DeadLetterStream string
+	// OnDeadLetter, when set, is called with each entry moved to the dead-letter stream.
+	OnDeadLetter func(stream string, entry StreamEntry, deliveries int)
+}
+
+func (opts ConsumerOptions) withDefaults() ConsumerOptions {
+	if opts.Count <= 0 {
+		opts.Count = 10
+	}
+	if opts.Block <= 0 {
+		opts.Block = 5 * time.Second
+	}
+	if opts.ClaimInterval <= 0 {
+		opts.ClaimInterval = opts.ClaimMinIdle
+	}
+	return opts
+}
+
+// Consumer reads the streams of a consumer group in a loop with XREADGROUP, on a connection of its own, and
+// passes the entries one at a time to a StreamHandler, acknowledging the ones it handled. It first goes
+// through the entries delivered to it before and never acknowledged, which were left by a crash or by
+// handler errors, then reads new entries. Every entry is handled at least once as long as consumers restart
+// under the same name, or when a consumer with a claimer (see ConsumerOptions.ClaimMinIdle) takes over the
+// entries of those that never came back. The claimer runs in a goroutine of its own, the handler must be
+// safe to call concurrently then.
+//
+// The loop runs until Close is called or the connection fails, see Err.
+type Consumer struct {
+	client  *Client
+	opts    ConsumerOptions
+	handler StreamHandler
+	conn    IConnection
+	cancel  context.CancelFunc
+	done    chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	err     error
+}
+
+// NewConsumer starts a Consumer for opts passing entries to handler, the group must exist.
+func (client *Client) NewConsumer(ctx context.Context, opts ConsumerOptions, handler StreamHandler) (*Consumer, error) {
+	if opts.Group == "" || opts.Name == "" {
+		return nil, errors.New("consumer: no group or consumer name")
+	}
+	if len(opts.Streams) == 0 {
+		return nil, errors.New("consumer: no stream")
+	}
+	if handler == nil {
+		return nil, errors.New("consumer: no handler")
+	}
+	if opts.MaxDeliveries > 0 && opts.ClaimMinIdle <= 0 {
+		return nil, errors.New("consumer: MaxDeliveries requires ClaimMinIdle")
+	}
+	conn, err := client.dialBlocking(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	loopCtx, cancel := context.WithCancel(context.Background())
+	c := &Consumer{
+		client:  client,
+		opts:    opts.withDefaults(),
+		handler: handler,
+		conn:    conn,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.run(loopCtx)
+	if c.opts.ClaimMinIdle > 0 {
+		c.wg.Add(1)
+		go c.claim(loopCtx)
+	}
+	go func() {
+		c.wg.Wait()
+		close(c.done)
+	}()
+	return c, nil
+}
+
+// Done returns a channel closed once the loop stopped.
+func (c *Consumer) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error that stopped the loop, if any.
+func (c *Consumer) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// Close stops the loop and the claimer, cancelling the context of the handlers in flight, and waits for them
+// to return.
+func (c *Consumer) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+func (c *Consumer) run(ctx context.Context) {
+	defer c.wg.Done()
+	defer c.conn.Close()
+	defer c.cancel() // stops the claimer when the loop fails
+
+	// "0" reads the pending entries of this consumer, from the start
+	ids := make(map[string]string, len(c.opts.Streams))
+	for _, stream := range c.opts.Streams {
+		ids[stream] = "0"
+	}
+	for {
+		streams, err := c.read(ctx, ids)
+		if err != nil && !errors.Is(err, ErrNil) {
+			if ctx.Err() == nil {
+				c.mu.Lock()
+				c.err = err
+				c.mu.Unlock()
+			}
+			return
+		}
+		for _, stream := range c.opts.Streams {
+			entries := streams[stream]
+			redelivered := ids[stream] != StreamUndelivered
+			if redelivered {
+				if len(entries) == 0 {
+					ids[stream] = StreamUndelivered // no more pending entries
+					continue
+				}
+				ids[stream] = entries[len(entries)-1].ID
+			}
+			c.handleAll(ctx, stream, entries, redelivered)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// claim runs XAUTOCLAIM on every stream every ClaimInterval, and handles the entries claimed.
+func (c *Consumer) claim(ctx context.Context) {
+	defer c.wg.Done()
+	ticker := time.NewTicker(c.opts.ClaimInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, stream := range c.opts.Streams {
+			c.claimStream(ctx, stream)
+		}
+	}
+}
+
+// claimStream claims the idle entries of stream a page at a time, an error waits for the next round.
+func (c *Consumer) claimStream(ctx context.Context, stream string) {
+	start := "0-0"
+	for {
+		result, err := c.client.XAutoClaim(ctx, stream, c.opts.Group, c.opts.Name, c.opts.ClaimMinIdle, start, c.opts.Count)
+		if err != nil {
+			return
+		}
+		c.handleAll(ctx, stream, result.Entries, true)
+		if ctx.Err() != nil {
+			return
+		}
+		if result.Next == "0-0" || result.Next == "" {
+			return
+		}
+		start = result.Next
+	}
+}
+
+// read runs XREADGROUP on the connection of the consumer, it only blocks once every stream reads new entries.
+func (c *Consumer) read(ctx context.Context, ids map[string]string) (map[string][]StreamEntry, error) {
+	args := xreadGroupArgs(c.opts.Group, c.opts.Name, XReadGroupOptions{Count: c.opts.Count, Block: c.opts.Block}, 0, ids)
+	if err := c.conn.SendCommand(ctx, args...); err != nil {
+		return nil, err
+	}
+	value, err := c.conn.ReceiveValue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseXRead("xreadgroup", NewReply(value))
+}
+
+// handleAll handles entries of stream in order, redelivered tells they were delivered before and can be
+// dead letters.
+func (c *Consumer) handleAll(ctx context.Context, stream string, entries []StreamEntry, redelivered bool) {
+	var deliveries map[string]int
+	if redelivered && c.opts.MaxDeliveries > 0 && len(entries) > 0 {
+		deliveries = c.deliveries(ctx, stream, entries)
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		if n := deliveries[entry.ID]; n > c.opts.MaxDeliveries && entry.Fields != nil {
+			c.deadLetter(ctx, stream, entry, n)
+			continue
+		}
+		c.handle(ctx, stream, entry)
+	}
+}
+
+// deliveries returns the delivery counts of entries, pending for this consumer, from XPENDING. They are
+// handled as usual when it fails.
+func (c *Consumer) deliveries(ctx context.Context, stream string, entries []StreamEntry) map[string]int {
+	pending, err := c.client.XPendingExt(ctx, stream, c.opts.Group, XPendingOptions{
+		Start:    entries[0].ID,
+		End:      entries[len(entries)-1].ID,
+		Count:    len(entries),
+		Consumer: c.opts.Name,
+	})
+	if err != nil {
+		return nil
+	}
+	deliveries := make(map[string]int, len(pending))
+	for _, entry := range pending {
+		deliveries[entry.ID] = entry.Deliveries
+	}
+	return deliveries
+}
+
+// deadLetter moves entry to the dead-letter stream and acknowledges it, it stays pending when XADD fails.
+func (c *Consumer) deadLetter(ctx context.Context, stream string, entry StreamEntry, deliveries int) {
+	ctx = context.WithoutCancel(ctx)
+	key := c.opts.DeadLetterStream
+	if key == "" {
+		key = stream + ":dead"
+	}
+	if _, err := c.client.XAdd(ctx, key, AutoID, entry.Fields); err != nil {
+		return
+	}
+	_, _ = c.client.XAck(ctx, stream, c.opts.Group, entry.ID)
+	if c.opts.OnDeadLetter != nil {
+		c.opts.OnDeadLetter(stream, entry, deliveries)
+	}
+}
+
+func (c *Consumer) handle(ctx context.Context, stream string, entry StreamEntry) {
+	// an entry deleted from the stream while pending has no fields left to handle
+	if entry.Fields != nil {
+		if err := c.handler(ctx, stream, entry); err != nil {
+			return
+		}
+	}
+	// the entry was handled, acknowledge it even when the consumer is closing
+	_, _ = c.client.XAck(context.WithoutCancel(ctx), stream, c.opts.Group, entry.ID)
+}
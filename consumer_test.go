@@ -0,0 +1,248 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serveCommand reads a command on server, answers it with reply and returns its arguments.
+func serveCommand(t *testing.T, server *Connection, reply string) []string {
+	value, err := server.ReceiveValue(context.Background())
+	if err != nil {
+		t.Fatalf("server failed to read the command: %v", err)
+	}
+	_, _ = server.rw.WriteString(reply)
+	_ = server.rw.Flush()
+	args, _ := NewReply(value).StringSlice()
+	return args
+}
+
+func TestConsumer(t *testing.T) {
+	// acknowledgements go through the pool of the client
+	acks := make(chan []string, 3)
+	SendFunc = func(command string) error {
+		args := decodeCommand(command)
+		acks <- args
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) { return int64(1), nil }
+	client, servers := newPipeClient("")
+	handled := make(chan StreamEntry, 3)
+	consumer, err := client.NewConsumer(context.Background(), ConsumerOptions{Group: "workers", Name: "w1", Streams: []string{"jobs"}},
+		func(ctx context.Context, stream string, entry StreamEntry) error {
+			handled <- entry
+			if entry.Fields["task"] == "fail" {
+				return errors.New("failed")
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+	reader := <-servers
+
+	// the entry left pending by a previous run is handled and acknowledged first
+	args := serveCommand(t, reader, "*1\r\n*2\r\n$4\r\njobs\r\n*1\r\n*2\r\n$3\r\n1-0\r\n*2\r\n$4\r\ntask\r\n$6\r\nresize\r\n")
+	if want := []string{"XREADGROUP", "GROUP", "workers", "w1", "COUNT", "10", "BLOCK", "5000", "STREAMS", "jobs", "0"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Consumer sent %q, want %q", args, want)
+	}
+	if entry := <-handled; entry.ID != "1-0" {
+		t.Errorf("Consumer handled %+v, want 1-0", entry)
+	}
+	if args := <-acks; !reflect.DeepEqual(args, []string{"XACK", "jobs", "workers", "1-0"}) {
+		t.Errorf("Consumer sent %q, want XACK of 1-0", args)
+	}
+
+	// no more pending entries, read new ones
+	if args := serveCommand(t, reader, "*1\r\n*2\r\n$4\r\njobs\r\n*0\r\n"); args[len(args)-1] != "1-0" {
+		t.Errorf("Consumer sent %q, want to read after 1-0", args)
+	}
+	args = serveCommand(t, reader, "*1\r\n*2\r\n$4\r\njobs\r\n*1\r\n*2\r\n$3\r\n2-0\r\n*2\r\n$4\r\ntask\r\n$4\r\nfail\r\n")
+	if args[len(args)-1] != StreamUndelivered {
+		t.Errorf("Consumer sent %q, want to read new entries", args)
+	}
+	if entry := <-handled; entry.ID != "2-0" {
+		t.Errorf("Consumer handled %+v, want 2-0", entry)
+	}
+
+	// the failed entry isn't acknowledged, the next command is the next read
+	args = serveCommand(t, reader, "-NOGROUP No such key 'jobs' or consumer group 'workers'\r\n")
+	if args[0] != "XREADGROUP" {
+		t.Errorf("Consumer sent %q, want XREADGROUP", args)
+	}
+	select {
+	case <-consumer.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Consumer didn't stop on the error")
+	}
+	if err := consumer.Err(); err == nil || !strings.HasPrefix(err.Error(), "NOGROUP") {
+		t.Errorf("Err() = %v, want the server error", err)
+	}
+	_ = consumer.Close()
+	if len(acks) != 0 {
+		t.Errorf("Consumer sent %q, want the failed entry left pending", <-acks)
+	}
+}
+
+func TestConsumer_Close(t *testing.T) {
+	client, servers := newPipeClient("")
+	consumer, err := client.NewConsumer(context.Background(), ConsumerOptions{Group: "workers", Name: "w1", Streams: []string{"jobs"}},
+		func(ctx context.Context, stream string, entry StreamEntry) error { return nil })
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+	reader := <-servers
+	_, _ = reader.ReceiveValue(context.Background()) // the read is never answered
+
+	done := make(chan error)
+	go func() { done <- consumer.Close() }()
+	select {
+	case err := <-done:
+		if err != nil || consumer.Err() != nil {
+			t.Errorf("Close() error = %v, Err() = %v", err, consumer.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close() didn't interrupt the read")
+	}
+
+	if _, err := client.NewConsumer(context.Background(), ConsumerOptions{Group: "workers", Name: "w1"}, nil); err == nil {
+		t.Error("NewConsumer() expected an error without streams")
+	}
+	noClaimer := ConsumerOptions{Group: "workers", Name: "w1", Streams: []string{"jobs"}, MaxDeliveries: 3}
+	if _, err := client.NewConsumer(context.Background(), noClaimer, func(ctx context.Context, stream string, entry StreamEntry) error { return nil }); err == nil {
+		t.Error("NewConsumer() expected an error for MaxDeliveries without a claimer")
+	}
+}
+
+func TestConsumer_Claim(t *testing.T) {
+	var mu sync.Mutex
+	var last string
+	sent := make(chan []string, 10)
+	SendFunc = func(command string) error {
+		args := decodeCommand(command)
+		mu.Lock()
+		last = args[0]
+		mu.Unlock()
+		select {
+		case sent <- args:
+		default: // later rounds find nothing to claim
+		}
+		return nil
+	}
+	claimed := false
+	ReceiveFunc = func() (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if last == "XACK" {
+			return int64(1), nil
+		}
+		if claimed {
+			return []interface{}{"0-0", []interface{}{}, []interface{}{}}, nil
+		}
+		claimed = true
+		return []interface{}{"0-0", []interface{}{[]interface{}{"4-0", []interface{}{"task", "resize"}}}, []interface{}{}}, nil
+	}
+	client, servers := newPipeClient("")
+	handled := make(chan StreamEntry, 1)
+	consumer, err := client.NewConsumer(context.Background(), ConsumerOptions{Group: "workers", Name: "w2", Streams: []string{"jobs"}, ClaimMinIdle: 20 * time.Millisecond},
+		func(ctx context.Context, stream string, entry StreamEntry) error {
+			handled <- entry
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+	reader := <-servers
+	go func() { _, _ = reader.ReceiveValue(context.Background()) }() // the read is never answered
+
+	if args := <-sent; !reflect.DeepEqual(args, []string{"XAUTOCLAIM", "jobs", "workers", "w2", "20", "0-0", "COUNT", "10"}) {
+		t.Errorf("Consumer sent %q, want XAUTOCLAIM", args)
+	}
+	select {
+	case entry := <-handled:
+		if entry.ID != "4-0" {
+			t.Errorf("Consumer handled %+v, want 4-0", entry)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Consumer didn't handle the claimed entry")
+	}
+	if args := <-sent; !reflect.DeepEqual(args, []string{"XACK", "jobs", "workers", "4-0"}) {
+		t.Errorf("Consumer sent %q, want XACK of 4-0", args)
+	}
+	_ = consumer.Close()
+	if err := consumer.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestConsumer_DeadLetter(t *testing.T) {
+	sent := make(chan []string, 3)
+	SendFunc = func(command string) error {
+		args := decodeCommand(command)
+		sent <- args
+		return nil
+	}
+	ReceiveFunc = replySequence(
+		[]interface{}{
+			[]interface{}{"1-0", "w1", int64(60000), int64(4)},
+			[]interface{}{"2-0", "w1", int64(60000), int64(2)},
+		},
+		"9-0",
+		int64(1),
+		int64(1),
+	)
+	client, servers := newPipeClient("")
+	handled := make(chan StreamEntry, 2)
+	dead := make(chan StreamEntry, 1)
+	consumer, err := client.NewConsumer(context.Background(), ConsumerOptions{
+		// the claimer doesn't run before the end of the test
+		Group: "workers", Name: "w1", Streams: []string{"jobs"}, MaxDeliveries: 3, ClaimMinIdle: time.Hour,
+		OnDeadLetter: func(stream string, entry StreamEntry, deliveries int) {
+			if stream != "jobs" || deliveries != 4 {
+				t.Errorf("OnDeadLetter() got %q, %d", stream, deliveries)
+			}
+			dead <- entry
+		},
+	}, func(ctx context.Context, stream string, entry StreamEntry) error {
+		handled <- entry
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+	reader := <-servers
+	serveCommand(t, reader, "*1\r\n*2\r\n$4\r\njobs\r\n*2\r\n"+
+		"*2\r\n$3\r\n1-0\r\n*2\r\n$4\r\ntask\r\n$6\r\nresize\r\n"+
+		"*2\r\n$3\r\n2-0\r\n*2\r\n$4\r\ntask\r\n$4\r\ncrop\r\n")
+
+	want := [][]string{
+		{"XPENDING", "jobs", "workers", "1-0", "2-0", "2", "w1"},
+		{"XADD", "jobs:dead", "*", "task", "resize"},
+		{"XACK", "jobs", "workers", "1-0"},
+	}
+	for _, w := range want {
+		if args := <-sent; !reflect.DeepEqual(args, w) {
+			t.Errorf("Consumer sent %q, want %q", args, w)
+		}
+	}
+	if entry := <-dead; entry.ID != "1-0" {
+		t.Errorf("OnDeadLetter() got %+v, want 1-0", entry)
+	}
+	// the entry delivered twice is handled as usual
+	if entry := <-handled; entry.ID != "2-0" {
+		t.Errorf("Consumer handled %+v, want 2-0", entry)
+	}
+	if args := <-sent; !reflect.DeepEqual(args, []string{"XACK", "jobs", "workers", "2-0"}) {
+		t.Errorf("Consumer sent %q, want XACK of 2-0", args)
+	}
+	if len(handled) != 0 {
+		t.Errorf("Consumer handled %+v, want the dead letter skipped", <-handled)
+	}
+	go func() { _, _ = reader.ReceiveValue(context.Background()) }()
+	_ = consumer.Close()
+}
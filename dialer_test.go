@@ -2,16 +2,17 @@ package resp
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"testing"
 )
 
 type MockDialer struct {
-	DialFunc func(ctx context.Context, address string) (net.Conn, error)
+	DialFunc func(ctx context.Context, network string, address string) (net.Conn, error)
 }
 
-func (m *MockDialer) Dial(ctx context.Context, address string) (net.Conn, error) {
-	return m.DialFunc(ctx, address)
+func (m *MockDialer) Dial(ctx context.Context, network string, address string) (net.Conn, error) {
+	return m.DialFunc(ctx, network, address)
 }
 
 func TestDialer_Dial(t *testing.T) {
@@ -19,7 +20,7 @@ func TestDialer_Dial(t *testing.T) {
 
 	// Test dialing a valid address
 	address := "localhost:6379" //assuming a Redis server is running on localhost:6379
-	conn, err := dialer.Dial(context.Background(), address)
+	conn, err := dialer.Dial(context.Background(), "tcp", address)
 	if err != nil {
 		t.Errorf("Failed to dial a valid address: %s", err)
 	}
@@ -33,8 +34,51 @@ func TestDialer_Dial(t *testing.T) {
 
 	// Test dialing an invalid address
 	invalidAddress := "invalid:6379"
-	conn, err = dialer.Dial(context.Background(), invalidAddress)
+	conn, err = dialer.Dial(context.Background(), "tcp", invalidAddress)
 	if err == nil {
 		t.Errorf("Expected an error when dialing an invalid address")
 	}
 }
+
+func TestDialer_DialTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir())
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+
+	t.Run("trusted CA", func(t *testing.T) {
+		config, err := NewTLSConfig(TLSOptions{CAFile: certFile})
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn, err := NewTLSDialer(config).Dial(context.Background(), "tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial() error = %v", err)
+		}
+		_ = conn.Close()
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		conn, err := NewTLSDialer(&tls.Config{}).Dial(context.Background(), "tcp", listener.Addr().String())
+		if err == nil {
+			_ = conn.Close()
+			t.Error("Dial() expected a certificate verification error")
+		}
+	})
+}
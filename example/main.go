@@ -20,7 +20,7 @@ func main() {
 	}
 
 	// Initialize a new Redis client.
-	client, err := resp.NewRedisClient(redisAddress, "")
+	client, err := resp.NewClient(redisAddress)
 	if err != nil {
 		log.Fatalf("Error connecting to redis: %s", err)
 	}
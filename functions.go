@@ -0,0 +1,167 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FunctionLibrary is a library of Redis functions (Redis 7+) as listed by FUNCTION LIST.
+// Code is only set when the list was requested with the library code.
+type FunctionLibrary struct {
+	Name      string
+	Engine    string
+	Functions []FunctionInfo
+	Code      string
+}
+
+type FunctionInfo struct {
+	Name        string
+	Description string
+	Flags       []string
+}
+
+// FunctionLoad loads a library, replace allows overwriting an existing library with the same name.
+// It returns the name of the loaded library.
+func (client *Client) FunctionLoad(ctx context.Context, code string, replace bool) (string, error) {
+	args := []interface{}{"FUNCTION", "LOAD"}
+	if replace {
+		args = append(args, "REPLACE")
+	}
+	reply, err := client.Do(ctx, append(args, code)...)
+	if err != nil {
+		return "", err
+	}
+	name, err := reply.Text()
+	if err != nil {
+		return "", fmt.Errorf("functionLoad: unexpected response from server %v", reply.Value())
+	}
+	return name, nil
+}
+
+// FunctionDump returns a serialized payload of every loaded library, to be restored with FUNCTION RESTORE.
+func (client *Client) FunctionDump(ctx context.Context) ([]byte, error) {
+	reply, err := client.Do(ctx, "FUNCTION", "DUMP")
+	if err != nil {
+		return nil, err
+	}
+	payload, err := reply.Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("functionDump: unexpected response from server %v", reply.Value())
+	}
+	return payload, nil
+}
+
+// FunctionList lists the libraries whose name matches libraryPattern (every library when empty).
+func (client *Client) FunctionList(ctx context.Context, libraryPattern string, withCode bool) ([]FunctionLibrary, error) {
+	args := []interface{}{"FUNCTION", "LIST"}
+	if libraryPattern != "" {
+		args = append(args, "LIBRARYNAME", libraryPattern)
+	}
+	if withCode {
+		args = append(args, "WITHCODE")
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	libraries, err := parseFunctionLibraries(reply)
+	if err != nil {
+		return nil, fmt.Errorf("functionList: unexpected response from server %v", reply.Value())
+	}
+	return libraries, nil
+}
+
+// FCall calls a function, keys are available to it as its keys argument and args as its args argument.
+func (client *Client) FCall(ctx context.Context, function string, keys []string, args ...interface{}) (*Reply, error) {
+	return client.Do(ctx, evalArgs("FCALL", function, keys, args)...)
+}
+
+// FCallRO calls a function flagged no-writes, so it may run on read-only replicas.
+func (client *Client) FCallRO(ctx context.Context, function string, keys []string, args ...interface{}) (*Reply, error) {
+	return client.Do(ctx, evalArgs("FCALL_RO", function, keys, args)...)
+}
+
+func parseFunctionLibraries(reply *Reply) ([]FunctionLibrary, error) {
+	entries, err := reply.Array()
+	if err != nil {
+		return nil, err
+	}
+
+	libraries := make([]FunctionLibrary, 0, len(entries))
+	for _, entry := range entries {
+		var library FunctionLibrary
+		err := forEachField(entry, func(field string, value *Reply) error {
+			var err error
+			switch field {
+			case "library_name":
+				library.Name, err = value.Text()
+			case "engine":
+				library.Engine, err = value.Text()
+			case "library_code":
+				library.Code, err = value.Text()
+			case "functions":
+				library.Functions, err = parseFunctionInfos(value)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		libraries = append(libraries, library)
+	}
+	return libraries, nil
+}
+
+func parseFunctionInfos(reply *Reply) ([]FunctionInfo, error) {
+	entries, err := reply.Array()
+	if err != nil {
+		return nil, err
+	}
+
+	functions := make([]FunctionInfo, 0, len(entries))
+	for _, entry := range entries {
+		var function FunctionInfo
+		err := forEachField(entry, func(field string, value *Reply) error {
+			var err error
+			switch field {
+			case "name":
+				function.Name, err = value.Text()
+			case "description":
+				if !value.IsNil() {
+					function.Description, err = value.Text()
+				}
+			case "flags":
+				function.Flags, err = value.StringSlice()
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		functions = append(functions, function)
+	}
+	return functions, nil
+}
+
+// forEachField walks a reply made of field/value pairs, the RESP2 encoding of a map whose values may be nested replies.
+func forEachField(reply *Reply, fn func(field string, value *Reply) error) error {
+	elements, err := reply.Array()
+	if err != nil {
+		return err
+	}
+	if len(elements)%2 != 0 {
+		return errors.New("reply: map reply has an odd number of elements")
+	}
+	for i := 0; i < len(elements); i += 2 {
+		field, err := elements[i].Text()
+		if err != nil {
+			return err
+		}
+		if err := fn(field, elements[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package resp
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestClient_FunctionLoad(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return "mylib", nil
+	}
+	client := newMockClient(2, "password")
+	name, err := client.FunctionLoad(context.Background(), "#!lua name=mylib\nredis.register_function('f', function() return 1 end)", true)
+	if err != nil {
+		t.Fatalf("FunctionLoad returned error: %s", err)
+	}
+	if name != "mylib" || !strings.Contains(sent, "$7\r\nREPLACE\r\n") {
+		t.Errorf("FunctionLoad got = %s, sent %q", name, sent)
+	}
+}
+
+func TestClient_FunctionDump(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return "\xf5\xc3@X", nil
+	}
+	client := newMockClient(2, "password")
+	payload, err := client.FunctionDump(context.Background())
+	if err != nil {
+		t.Fatalf("FunctionDump returned error: %s", err)
+	}
+	if string(payload) != "\xf5\xc3@X" {
+		t.Errorf("FunctionDump got = %q", payload)
+	}
+}
+
+func TestClient_FunctionList(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return []interface{}{
+			[]interface{}{
+				"library_name", "mylib",
+				"engine", "LUA",
+				"functions", []interface{}{
+					[]interface{}{"name", "myfunc", "description", nil, "flags", []interface{}{"no-writes"}},
+				},
+				"library_code", "#!lua name=mylib",
+			},
+		}, nil
+	}
+	client := newMockClient(2, "password")
+	libraries, err := client.FunctionList(context.Background(), "my*", true)
+	if err != nil {
+		t.Fatalf("FunctionList returned error: %s", err)
+	}
+	if len(libraries) != 1 {
+		t.Fatalf("FunctionList got %d libraries, want 1", len(libraries))
+	}
+	library := libraries[0]
+	if library.Name != "mylib" || library.Engine != "LUA" || library.Code != "#!lua name=mylib" {
+		t.Errorf("FunctionList got = %+v", library)
+	}
+	if len(library.Functions) != 1 || library.Functions[0].Name != "myfunc" || library.Functions[0].Flags[0] != "no-writes" {
+		t.Errorf("FunctionList got functions = %+v", library.Functions)
+	}
+}
+
+func TestClient_FCall(t *testing.T) {
+	var sent []string
+	SendFunc = func(command string) error {
+		sent = append(sent, command)
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return int64(1), nil
+	}
+	client := newMockClient(2, "password")
+	if _, err := client.FCall(context.Background(), "myfunc", []string{"key"}, "arg"); err != nil {
+		t.Fatalf("FCall returned error: %s", err)
+	}
+	if _, err := client.FCallRO(context.Background(), "myfunc", []string{"key"}); err != nil {
+		t.Fatalf("FCallRO returned error: %s", err)
+	}
+	if !strings.HasPrefix(sent[0], "*5\r\n$5\r\nFCALL\r\n") || !strings.HasPrefix(sent[1], "*4\r\n$8\r\nFCALL_RO\r\n") {
+		t.Errorf("FCall sent %q", sent)
+	}
+}
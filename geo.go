@@ -0,0 +1,248 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// GeoUnit is the unit of distances, see GeoDist.
+type GeoUnit string
+
+const (
+	GeoMeters     GeoUnit = "m"
+	GeoKilometers GeoUnit = "km"
+	GeoMiles      GeoUnit = "mi"
+	GeoFeet       GeoUnit = "ft"
+)
+
+// GeoCoord is a position on Earth, in degrees.
+type GeoCoord struct {
+	Longitude float64
+	Latitude  float64
+}
+
+// GeoLocation is a member of a geospatial index with its position.
+type GeoLocation struct {
+	Member string
+	GeoCoord
+}
+
+// GeoAdd adds locations to the geospatial index at key, a sorted set, updating the position of the members
+// already in it, and returns how many were added.
+func (client *Client) GeoAdd(ctx context.Context, key string, locations ...GeoLocation) (int, error) {
+	if len(locations) == 0 {
+		return 0, errors.New("geoadd: no location to add")
+	}
+	args := make([]interface{}, 0, 3*len(locations)+2)
+	args = append(args, "GEOADD", key)
+	for _, location := range locations {
+		args = append(args, location.Longitude, location.Latitude, location.Member)
+	}
+	return client.doInt(ctx, args...)
+}
+
+// GeoPos returns the positions of members in the geospatial index at key, nil for those not in it.
+func (client *Client) GeoPos(ctx context.Context, key string, members ...string) ([]*GeoCoord, error) {
+	if len(members) == 0 {
+		return nil, errors.New("geopos: no member")
+	}
+	reply, err := client.Do(ctx, keyArgs("GEOPOS", append([]string{key}, members...))...)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := reply.Array()
+	if err != nil || len(replies) != len(members) {
+		return nil, fmt.Errorf("geopos: unexpected response from server %v", reply.Value())
+	}
+	positions := make([]*GeoCoord, len(replies))
+	for i, r := range replies {
+		if r.IsNil() {
+			continue
+		}
+		coord, err := parseGeoCoord(r)
+		if err != nil {
+			return nil, fmt.Errorf("geopos: unexpected response from server %v", reply.Value())
+		}
+		positions[i] = &coord
+	}
+	return positions, nil
+}
+
+// GeoDist returns the distance between two members of the geospatial index at key in unit, meters when
+// empty, or returns ErrNil when one of them isn't in it.
+func (client *Client) GeoDist(ctx context.Context, key string, member1 string, member2 string, unit GeoUnit) (float64, error) {
+	args := []interface{}{"GEODIST", key, member1, member2}
+	if unit != "" {
+		args = append(args, string(unit))
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	if reply.IsNil() {
+		return 0, ErrNil
+	}
+	dist, err := reply.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("geodist: unexpected response from server %v", reply.Value())
+	}
+	return dist, nil
+}
+
+// GeoSort orders the results of GeoSearch by distance.
+type GeoSort string
+
+const (
+	GeoAsc  GeoSort = "ASC"
+	GeoDesc GeoSort = "DESC"
+)
+
+// GeoSearchOptions are the area of GeoSearch: a center, FromMember or FromCoord, and a shape, a circle of
+// Radius or a box of Width by Height.
+type GeoSearchOptions struct {
+	FromMember string    // centers the area on a member of the index
+	FromCoord  *GeoCoord // centers the area on a position
+	Radius     float64
+	Width      float64
+	Height     float64
+	Unit       GeoUnit // of the shape and the distances returned, meters when empty
+	Sort       GeoSort // unsorted when empty
+	Count      int     // members at most, all of them when zero
+	Any        bool    // with Count, stop at the first Count members found instead of the nearest ones
+}
+
+func (opts GeoSearchOptions) args() ([]interface{}, error) {
+	if (opts.FromMember != "") == (opts.FromCoord != nil) {
+		return nil, errors.New("one of FromMember and FromCoord is required")
+	}
+	box := opts.Width > 0 || opts.Height > 0
+	if (opts.Radius > 0) == box || (box && (opts.Width <= 0 || opts.Height <= 0)) {
+		return nil, errors.New("one of Radius and Width with Height is required")
+	}
+	if opts.Any && opts.Count <= 0 {
+		return nil, errors.New("ANY requires a count")
+	}
+	unit := opts.Unit
+	if unit == "" {
+		unit = GeoMeters
+	}
+	var args []interface{}
+	if opts.FromMember != "" {
+		args = append(args, "FROMMEMBER", opts.FromMember)
+	} else {
+		args = append(args, "FROMLONLAT", opts.FromCoord.Longitude, opts.FromCoord.Latitude)
+	}
+	if box {
+		args = append(args, "BYBOX", opts.Width, opts.Height, string(unit))
+	} else {
+		args = append(args, "BYRADIUS", opts.Radius, string(unit))
+	}
+	if opts.Sort != "" {
+		args = append(args, string(opts.Sort))
+	}
+	if opts.Count > 0 {
+		args = append(args, "COUNT", opts.Count)
+		if opts.Any {
+			args = append(args, "ANY")
+		}
+	}
+	return args, nil
+}
+
+// GeoSearch returns the members of the geospatial index at key in the area of opts (Redis 6.2+).
+func (client *Client) GeoSearch(ctx context.Context, key string, opts GeoSearchOptions) ([]string, error) {
+	args, err := opts.args()
+	if err != nil {
+		return nil, fmt.Errorf("geosearch: %w", err)
+	}
+	reply, err := client.Do(ctx, append([]interface{}{"GEOSEARCH", key}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	members, err := reply.StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("geosearch: unexpected response from server %v", reply.Value())
+	}
+	return members, nil
+}
+
+// GeoSearchResult is a member found by GeoSearchLocations.
+type GeoSearchResult struct {
+	GeoLocation
+	Dist float64 // from the center of the area, in the unit of the search
+}
+
+// GeoSearchLocations is like GeoSearch, returning the positions of the members and their distances to the
+// center as well (WITHCOORD and WITHDIST).
+func (client *Client) GeoSearchLocations(ctx context.Context, key string, opts GeoSearchOptions) ([]GeoSearchResult, error) {
+	args, err := opts.args()
+	if err != nil {
+		return nil, fmt.Errorf("geosearch: %w", err)
+	}
+	reply, err := client.Do(ctx, append(append([]interface{}{"GEOSEARCH", key}, args...), "WITHCOORD", "WITHDIST")...)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := reply.Array()
+	if err != nil {
+		return nil, fmt.Errorf("geosearch: unexpected response from server %v", reply.Value())
+	}
+	results := make([]GeoSearchResult, len(replies))
+	for i, r := range replies {
+		if results[i], err = parseGeoSearchResult(r); err != nil {
+			return nil, fmt.Errorf("geosearch: unexpected response from server %v", reply.Value())
+		}
+	}
+	return results, nil
+}
+
+// GeoSearchStore stores the members of the geospatial index at source in the area of opts in destination,
+// replacing it, and returns how many. With storeDist, destination is a plain sorted set scored by the
+// distances to the center instead of a geospatial index.
+func (client *Client) GeoSearchStore(ctx context.Context, destination string, source string, opts GeoSearchOptions, storeDist bool) (int, error) {
+	args, err := opts.args()
+	if err != nil {
+		return 0, fmt.Errorf("geosearchstore: %w", err)
+	}
+	args = append([]interface{}{"GEOSEARCHSTORE", destination, source}, args...)
+	if storeDist {
+		args = append(args, "STOREDIST")
+	}
+	return client.doInt(ctx, args...)
+}
+
+// parseGeoCoord converts a longitude and latitude pair.
+func parseGeoCoord(reply *Reply) (GeoCoord, error) {
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 2 {
+		return GeoCoord{}, errors.New("malformed position")
+	}
+	var coord GeoCoord
+	if coord.Longitude, err = parts[0].Float64(); err != nil {
+		return GeoCoord{}, err
+	}
+	if coord.Latitude, err = parts[1].Float64(); err != nil {
+		return GeoCoord{}, err
+	}
+	return coord, nil
+}
+
+// parseGeoSearchResult converts a member of GEOSEARCH WITHCOORD WITHDIST: its name, distance and position.
+func parseGeoSearchResult(reply *Reply) (GeoSearchResult, error) {
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 3 {
+		return GeoSearchResult{}, errors.New("malformed result")
+	}
+	var result GeoSearchResult
+	if result.Member, err = parts[0].Text(); err != nil {
+		return GeoSearchResult{}, err
+	}
+	if result.Dist, err = parts[1].Float64(); err != nil {
+		return GeoSearchResult{}, err
+	}
+	if result.GeoCoord, err = parseGeoCoord(parts[2]); err != nil {
+		return GeoSearchResult{}, err
+	}
+	return result, nil
+}
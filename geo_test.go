@@ -0,0 +1,122 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGeoSearchOptions_Args(t *testing.T) {
+	tests := []struct {
+		opts    GeoSearchOptions
+		want    []interface{}
+		wantErr bool
+	}{
+		{
+			opts: GeoSearchOptions{FromMember: "paris", Radius: 500, Unit: GeoKilometers},
+			want: []interface{}{"FROMMEMBER", "paris", "BYRADIUS", 500.0, "km"},
+		},
+		{
+			opts: GeoSearchOptions{FromCoord: &GeoCoord{Longitude: 2.35, Latitude: 48.85}, Width: 10, Height: 20, Sort: GeoAsc, Count: 5, Any: true},
+			want: []interface{}{"FROMLONLAT", 2.35, 48.85, "BYBOX", 10.0, 20.0, "m", "ASC", "COUNT", 5, "ANY"},
+		},
+		{opts: GeoSearchOptions{Radius: 1}, wantErr: true},
+		{opts: GeoSearchOptions{FromMember: "paris", FromCoord: &GeoCoord{}, Radius: 1}, wantErr: true},
+		{opts: GeoSearchOptions{FromMember: "paris"}, wantErr: true},
+		{opts: GeoSearchOptions{FromMember: "paris", Radius: 1, Width: 1, Height: 1}, wantErr: true},
+		{opts: GeoSearchOptions{FromMember: "paris", Width: 1}, wantErr: true},
+		{opts: GeoSearchOptions{FromMember: "paris", Radius: 1, Any: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.args()
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.args() = %v, %v, want %v", tt.opts, got, err, tt.want)
+		}
+	}
+}
+
+func TestClient_Geo(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(
+		int64(2),
+		[]interface{}{[]interface{}{"2.35000056028366089", "48.85000070699127"}, nil},
+		"343.5563",
+		nil,
+	)
+	added, err := client.GeoAdd(context.Background(), "cities",
+		GeoLocation{Member: "paris", GeoCoord: GeoCoord{Longitude: 2.35, Latitude: 48.85}},
+		GeoLocation{Member: "london", GeoCoord: GeoCoord{Longitude: -0.12, Latitude: 51.5}})
+	if err != nil || added != 2 {
+		t.Errorf("GeoAdd() got = %d, %v", added, err)
+	}
+	if want := []string{"GEOADD", "cities", "2.35", "48.85", "paris", "-0.12", "51.5", "london"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("GeoAdd() sent %q, want %q", sent, want)
+	}
+	positions, err := client.GeoPos(context.Background(), "cities", "paris", "rome")
+	if err != nil || len(positions) != 2 || positions[0] == nil || positions[1] != nil {
+		t.Fatalf("GeoPos() got = %v, %v", positions, err)
+	}
+	if p := *positions[0]; p.Longitude < 2.3499 || p.Longitude > 2.3501 || p.Latitude < 48.8499 || p.Latitude > 48.8501 {
+		t.Errorf("GeoPos() got = %+v", p)
+	}
+	if dist, err := client.GeoDist(context.Background(), "cities", "paris", "london", GeoKilometers); err != nil || dist != 343.5563 {
+		t.Errorf("GeoDist() got = %v, %v", dist, err)
+	}
+	if want := []string{"GEODIST", "cities", "paris", "london", "km"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("GeoDist() sent %q, want %q", sent, want)
+	}
+	if _, err := client.GeoDist(context.Background(), "cities", "paris", "rome", ""); !errors.Is(err, ErrNil) {
+		t.Errorf("GeoDist() error = %v, want %v", err, ErrNil)
+	}
+	if want := []string{"GEODIST", "cities", "paris", "rome"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("GeoDist() sent %q, want %q", sent, want)
+	}
+}
+
+func TestClient_GeoSearch(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+	opts := GeoSearchOptions{FromMember: "paris", Radius: 500, Unit: GeoKilometers, Sort: GeoAsc}
+
+	ReceiveFunc = replySequence(
+		[]interface{}{"paris", "london"},
+		[]interface{}{
+			[]interface{}{"paris", "0.0000", []interface{}{"2.35", "48.85"}},
+			[]interface{}{"london", "343.5563", []interface{}{"-0.12", "51.5"}},
+		},
+		int64(2),
+	)
+	if got, err := client.GeoSearch(context.Background(), "cities", opts); err != nil || !reflect.DeepEqual(got, []string{"paris", "london"}) {
+		t.Errorf("GeoSearch() got = %q, %v", got, err)
+	}
+	if want := []string{"GEOSEARCH", "cities", "FROMMEMBER", "paris", "BYRADIUS", "500", "km", "ASC"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("GeoSearch() sent %q, want %q", sent, want)
+	}
+	got, err := client.GeoSearchLocations(context.Background(), "cities", opts)
+	want := []GeoSearchResult{
+		{GeoLocation: GeoLocation{Member: "paris", GeoCoord: GeoCoord{Longitude: 2.35, Latitude: 48.85}}},
+		{GeoLocation: GeoLocation{Member: "london", GeoCoord: GeoCoord{Longitude: -0.12, Latitude: 51.5}}, Dist: 343.5563},
+	}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("GeoSearchLocations() got = %+v, %v, want %+v", got, err, want)
+	}
+	if len(sent) < 2 || sent[len(sent)-2] != "WITHCOORD" || sent[len(sent)-1] != "WITHDIST" {
+		t.Errorf("GeoSearchLocations() sent %q, want WITHCOORD WITHDIST", sent)
+	}
+	if n, err := client.GeoSearchStore(context.Background(), "near", "cities", opts, true); err != nil || n != 2 {
+		t.Errorf("GeoSearchStore() got = %d, %v", n, err)
+	}
+	if want := []string{"GEOSEARCHSTORE", "near", "cities", "FROMMEMBER", "paris", "BYRADIUS", "500", "km", "ASC", "STOREDIST"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("GeoSearchStore() sent %q, want %q", sent, want)
+	}
+
+	sent = nil
+	if _, err := client.GeoSearch(context.Background(), "cities", GeoSearchOptions{FromMember: "paris"}); err == nil || sent != nil {
+		t.Errorf("GeoSearch() error = %v, sent %q, want an error before sending", err, sent)
+	}
+}
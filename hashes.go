@@ -0,0 +1,40 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+)
+
+// FieldValue is a field of a hash with its value.
+type FieldValue struct {
+	Field string
+	Value string
+}
+
+// HRandField returns a random field of the hash at key, or returns ErrNil when it doesn't exist (Redis 6.2+).
+func (client *Client) HRandField(ctx context.Context, key string) (string, error) {
+	return client.randomMember(ctx, "HRANDFIELD", key)
+}
+
+// HRandFields returns up to count distinct random fields of the hash at key when count is positive, and
+// exactly -count fields, possibly repeated, when it is negative. A missing key returns an empty slice.
+func (client *Client) HRandFields(ctx context.Context, key string, count int) ([]string, error) {
+	return client.randomMembers(ctx, "HRANDFIELD", key, count)
+}
+
+// HRandFieldsWithValues is like HRandFields, returning the values of the fields as well.
+func (client *Client) HRandFieldsWithValues(ctx context.Context, key string, count int) ([]FieldValue, error) {
+	reply, err := client.Do(ctx, "HRANDFIELD", key, count, "WITHVALUES")
+	if err != nil {
+		return nil, err
+	}
+	values, err := reply.StringSlice()
+	if err != nil || len(values)%2 != 0 {
+		return nil, fmt.Errorf("hrandfield: unexpected response from server %v", reply.Value())
+	}
+	fields := make([]FieldValue, len(values)/2)
+	for i := range fields {
+		fields[i] = FieldValue{Field: values[2*i], Value: values[2*i+1]}
+	}
+	return fields, nil
+}
@@ -0,0 +1,39 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClient_HRandField(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("name", nil, []interface{}{"age", "name"}, []interface{}{"name", "ada", "name", "ada"}, []interface{}{"name"})
+	if got, err := client.HRandField(context.Background(), "user:1"); err != nil || got != "name" {
+		t.Errorf("HRandField() got = %q, %v", got, err)
+	}
+	if _, err := client.HRandField(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("HRandField() error = %v, want %v", err, ErrNil)
+	}
+	if got, err := client.HRandFields(context.Background(), "user:1", 2); err != nil || !reflect.DeepEqual(got, []string{"age", "name"}) {
+		t.Errorf("HRandFields() got = %q, %v", got, err)
+	}
+	if want := []string{"HRANDFIELD", "user:1", "2"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("HRandFields() sent %q, want %q", sent, want)
+	}
+	// a negative count can repeat fields
+	got, err := client.HRandFieldsWithValues(context.Background(), "user:1", -2)
+	if want := []FieldValue{{Field: "name", Value: "ada"}, {Field: "name", Value: "ada"}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("HRandFieldsWithValues() got = %+v, %v, want %+v", got, err, want)
+	}
+	if want := []string{"HRANDFIELD", "user:1", "-2", "WITHVALUES"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("HRandFieldsWithValues() sent %q, want %q", sent, want)
+	}
+	if _, err := client.HRandFieldsWithValues(context.Background(), "user:1", 1); err == nil {
+		t.Error("HRandFieldsWithValues() expected an error for a field without a value")
+	}
+}
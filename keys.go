@@ -0,0 +1,296 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Exists returns how many of keys exist, a key given twice is counted twice.
+func (client *Client) Exists(ctx context.Context, keys ...string) (int, error) {
+	return client.countKeys(ctx, "EXISTS", keys)
+}
+
+// Touch updates the last access time of keys and returns how many of them exist.
+func (client *Client) Touch(ctx context.Context, keys ...string) (int, error) {
+	return client.countKeys(ctx, "TOUCH", keys)
+}
+
+// Unlink deletes keys like DEL, but frees their memory in the background so removing large keys doesn't
+// block the server. It returns how many keys were deleted.
+func (client *Client) Unlink(ctx context.Context, keys ...string) (int, error) {
+	return client.countKeys(ctx, "UNLINK", keys)
+}
+
+// countKeys runs a command taking keys and replying with how many of them it applied to.
+// Nothing is sent without keys, the server would reject the command.
+func (client *Client) countKeys(ctx context.Context, command string, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	reply, err := client.Do(ctx, keyArgs(command, keys)...)
+	if err != nil {
+		return 0, err
+	}
+	count, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return count, nil
+}
+
+// pairArgs returns the arguments of command applied to the key value pairs.
+func pairArgs(command string, pairs map[string]string) []interface{} {
+	args := make([]interface{}, 0, 2*len(pairs)+1)
+	args = append(args, command)
+	for key, value := range pairs {
+		args = append(args, key, value)
+	}
+	return args
+}
+
+// keyArgs returns the arguments of command applied to keys.
+func keyArgs(command string, keys []string) []interface{} {
+	args := make([]interface{}, 0, len(keys)+1)
+	args = append(args, command)
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	return args
+}
+
+// NoExpiry is the TTL of a key without an expiration, see Client.TTL.
+const NoExpiry time.Duration = -1
+
+// TTL returns how long key has left to live, to the second, NoExpiry when it has no expiration
+// and ErrNil when it doesn't exist.
+func (client *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return client.ttl(ctx, "TTL", key, time.Second)
+}
+
+// PTTL is like TTL to the millisecond.
+func (client *Client) PTTL(ctx context.Context, key string) (time.Duration, error) {
+	return client.ttl(ctx, "PTTL", key, time.Millisecond)
+}
+
+func (client *Client) ttl(ctx context.Context, command string, key string, unit time.Duration) (time.Duration, error) {
+	reply, err := client.Do(ctx, command, key)
+	if err != nil {
+		return 0, err
+	}
+	ttl, err := reply.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	switch {
+	case ttl == -2:
+		return 0, ErrNil
+	case ttl == -1:
+		return NoExpiry, nil
+	case ttl < 0:
+		return 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return time.Duration(ttl) * unit, nil
+}
+
+// ExpireTime returns when key expires, to the second, the zero time when it has no expiration and ErrNil
+// when it doesn't exist (Redis 7+).
+func (client *Client) ExpireTime(ctx context.Context, key string) (time.Time, error) {
+	return client.expireTime(ctx, "EXPIRETIME", key, func(sec int64) time.Time {
+		return time.Unix(sec, 0)
+	})
+}
+
+// PExpireTime is like ExpireTime to the millisecond.
+func (client *Client) PExpireTime(ctx context.Context, key string) (time.Time, error) {
+	return client.expireTime(ctx, "PEXPIRETIME", key, time.UnixMilli)
+}
+
+func (client *Client) expireTime(ctx context.Context, command string, key string, toTime func(int64) time.Time) (time.Time, error) {
+	reply, err := client.Do(ctx, command, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	timestamp, err := reply.Int64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	switch {
+	case timestamp == -2:
+		return time.Time{}, ErrNil
+	case timestamp == -1:
+		return time.Time{}, nil
+	case timestamp < 0:
+		return time.Time{}, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return toTime(timestamp), nil
+}
+
+// Persist removes the expiration of key, it returns false when the key doesn't exist or has none.
+func (client *Client) Persist(ctx context.Context, key string) (bool, error) {
+	reply, err := client.Do(ctx, "PERSIST", key)
+	if err != nil {
+		return false, err
+	}
+	removed, err := reply.Bool()
+	if err != nil {
+		return false, fmt.Errorf("persist: unexpected response from server %v", reply.Value())
+	}
+	return removed, nil
+}
+
+// Rename renames key to newKey, replacing newKey if it exists. It returns ErrNil when key doesn't exist.
+func (client *Client) Rename(ctx context.Context, key string, newKey string) error {
+	reply, err := client.Do(ctx, "RENAME", key, newKey)
+	if err != nil {
+		if isNoSuchKey(err) {
+			return ErrNil
+		}
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("rename: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// RenameNX renames key to newKey only when newKey doesn't exist, and reports whether it was renamed.
+// It returns ErrNil when key doesn't exist.
+func (client *Client) RenameNX(ctx context.Context, key string, newKey string) (bool, error) {
+	reply, err := client.Do(ctx, "RENAMENX", key, newKey)
+	if err != nil {
+		if isNoSuchKey(err) {
+			return false, ErrNil
+		}
+		return false, err
+	}
+	renamed, err := reply.Bool()
+	if err != nil {
+		return false, fmt.Errorf("renameNX: unexpected response from server %v", reply.Value())
+	}
+	return renamed, nil
+}
+
+// isNoSuchKey reports whether err is the error reply of RENAME for a missing key.
+func isNoSuchKey(err error) bool {
+	var redisErr RedisError
+	return errors.As(err, &redisErr) && strings.HasSuffix(string(redisErr), "no such key")
+}
+
+// Copy copies the value of source to destination in the same database and reports whether it was copied:
+// false when source doesn't exist, or destination does and replace is false (Redis 6.2+).
+func (client *Client) Copy(ctx context.Context, source string, destination string, replace bool) (bool, error) {
+	return client.copy(ctx, []interface{}{"COPY", source, destination}, replace)
+}
+
+// CopyToDB is like Copy with destination in database db.
+func (client *Client) CopyToDB(ctx context.Context, source string, destination string, db int, replace bool) (bool, error) {
+	return client.copy(ctx, []interface{}{"COPY", source, destination, "DB", db}, replace)
+}
+
+func (client *Client) copy(ctx context.Context, args []interface{}, replace bool) (bool, error) {
+	if replace {
+		args = append(args, "REPLACE")
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return false, err
+	}
+	copied, err := reply.Bool()
+	if err != nil {
+		return false, fmt.Errorf("copy: unexpected response from server %v", reply.Value())
+	}
+	return copied, nil
+}
+
+// Move moves key to database db and reports whether it was moved: false when key doesn't exist
+// or already exists in db.
+func (client *Client) Move(ctx context.Context, key string, db int) (bool, error) {
+	reply, err := client.Do(ctx, "MOVE", key, db)
+	if err != nil {
+		return false, err
+	}
+	moved, err := reply.Bool()
+	if err != nil {
+		return false, fmt.Errorf("move: unexpected response from server %v", reply.Value())
+	}
+	return moved, nil
+}
+
+// Type returns the type of the value stored at key: string, list, set, zset, hash or stream.
+// It returns ErrNil when the key doesn't exist.
+func (client *Client) Type(ctx context.Context, key string) (string, error) {
+	reply, err := client.Do(ctx, "TYPE", key)
+	if err != nil {
+		return "", err
+	}
+	valueType, err := reply.Text()
+	if err != nil {
+		return "", fmt.Errorf("type: unexpected response from server %v", reply.Value())
+	}
+	if valueType == "none" {
+		return "", ErrNil
+	}
+	return valueType, nil
+}
+
+// ObjectEncoding returns the internal encoding of the value stored at key, such as listpack, hashtable,
+// intset or embstr. It returns ErrNil when the key doesn't exist.
+func (client *Client) ObjectEncoding(ctx context.Context, key string) (string, error) {
+	reply, err := client.Do(ctx, "OBJECT", "ENCODING", key)
+	if err != nil {
+		return "", err
+	}
+	return reply.Text()
+}
+
+// ObjectFreq returns the logarithmic access frequency counter of key. It needs an LFU maxmemory-policy,
+// the server returns an error otherwise, and it returns ErrNil when the key doesn't exist.
+func (client *Client) ObjectFreq(ctx context.Context, key string) (int, error) {
+	reply, err := client.Do(ctx, "OBJECT", "FREQ", key)
+	if err != nil {
+		return 0, err
+	}
+	if reply.IsNil() {
+		return 0, ErrNil
+	}
+	freq, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("object freq: unexpected response from server %v", reply.Value())
+	}
+	return freq, nil
+}
+
+// ObjectIdleTime returns how long key hasn't been read or written, with a precision of seconds. It needs
+// an LRU or noeviction maxmemory-policy, the server returns an error otherwise, and it returns ErrNil when
+// the key doesn't exist.
+func (client *Client) ObjectIdleTime(ctx context.Context, key string) (time.Duration, error) {
+	reply, err := client.Do(ctx, "OBJECT", "IDLETIME", key)
+	if err != nil {
+		return 0, err
+	}
+	if reply.IsNil() {
+		return 0, ErrNil
+	}
+	seconds, err := reply.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("object idletime: unexpected response from server %v", reply.Value())
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// Keys returns the keys matching the glob-style pattern. It walks the whole keyspace in one O(N) command
+// that blocks the server meanwhile, so it is meant for admin tooling and tests, use SCAN in production.
+func (client *Client) Keys(ctx context.Context, pattern string) ([]string, error) {
+	reply, err := client.Do(ctx, "KEYS", pattern)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := reply.StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("keys: unexpected response from server %v", reply.Value())
+	}
+	return keys, nil
+}
@@ -0,0 +1,212 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClient_CountKeys(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context, keys ...string) (int, error)
+		want string
+	}{
+		{name: "EXISTS", call: client.Exists, want: "*3\r\n$6\r\nEXISTS\r\n$1\r\na\r\n$1\r\nb\r\n"},
+		{name: "TOUCH", call: client.Touch, want: "*3\r\n$5\r\nTOUCH\r\n$1\r\na\r\n$1\r\nb\r\n"},
+		{name: "UNLINK", call: client.Unlink, want: "*3\r\n$6\r\nUNLINK\r\n$1\r\na\r\n$1\r\nb\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ReceiveFunc = replySequence(int64(2), "OK")
+			if got, err := tt.call(context.Background(), "a", "b"); err != nil || got != 2 {
+				t.Errorf("%s got = %d, %v", tt.name, got, err)
+			}
+			if sent != tt.want {
+				t.Errorf("%s sent %q, want %q", tt.name, sent, tt.want)
+			}
+			if _, err := tt.call(context.Background(), "a"); err == nil {
+				t.Errorf("%s expected an error for a non-integer reply", tt.name)
+			}
+			sent = ""
+			if got, err := tt.call(context.Background()); err != nil || got != 0 || sent != "" {
+				t.Errorf("%s() got = %d, %v, sent %q", tt.name, got, err, sent)
+			}
+		})
+	}
+}
+
+func TestClient_TTL(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(90), int64(-1), int64(-2), "OK")
+	if got, err := client.TTL(context.Background(), "key"); err != nil || got != 90*time.Second {
+		t.Errorf("TTL got = %v, %v", got, err)
+	}
+	if got, err := client.TTL(context.Background(), "persistent"); err != nil || got != NoExpiry {
+		t.Errorf("TTL got = %v, %v, want %v", got, err, NoExpiry)
+	}
+	if _, err := client.TTL(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("TTL error = %v, want %v", err, ErrNil)
+	}
+	if _, err := client.TTL(context.Background(), "key"); err == nil {
+		t.Error("TTL expected an error for a non-integer reply")
+	}
+
+	ReceiveFunc = replySequence(int64(1500))
+	if got, err := client.PTTL(context.Background(), "key"); err != nil || got != 1500*time.Millisecond {
+		t.Errorf("PTTL got = %v, %v", got, err)
+	}
+
+	ReceiveFunc = replySequence(int64(1), int64(0))
+	if removed, err := client.Persist(context.Background(), "key"); err != nil || !removed {
+		t.Errorf("Persist got = %v, %v", removed, err)
+	}
+	if removed, err := client.Persist(context.Background(), "persistent"); err != nil || removed {
+		t.Errorf("Persist got = %v, %v", removed, err)
+	}
+}
+
+func TestClient_ExpireTime(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(1700000000), int64(-1), int64(-2))
+	if got, err := client.ExpireTime(context.Background(), "key"); err != nil || !got.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("ExpireTime got = %v, %v", got, err)
+	}
+	if got, err := client.ExpireTime(context.Background(), "persistent"); err != nil || !got.IsZero() {
+		t.Errorf("ExpireTime got = %v, %v, want the zero time", got, err)
+	}
+	if _, err := client.ExpireTime(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("ExpireTime error = %v, want %v", err, ErrNil)
+	}
+
+	ReceiveFunc = replySequence(int64(1700000000250))
+	if got, err := client.PExpireTime(context.Background(), "key"); err != nil || !got.Equal(time.UnixMilli(1700000000250)) {
+		t.Errorf("PExpireTime got = %v, %v", got, err)
+	}
+}
+
+func TestClient_Rename(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("OK", RedisError("ERR no such key"))
+	if err := client.Rename(context.Background(), "key", "new"); err != nil {
+		t.Errorf("Rename error = %v", err)
+	}
+	if err := client.Rename(context.Background(), "missing", "new"); !errors.Is(err, ErrNil) {
+		t.Errorf("Rename error = %v, want %v", err, ErrNil)
+	}
+
+	ReceiveFunc = replySequence(int64(1), int64(0), RedisError("ERR no such key"))
+	if renamed, err := client.RenameNX(context.Background(), "key", "new"); err != nil || !renamed {
+		t.Errorf("RenameNX got = %v, %v", renamed, err)
+	}
+	if renamed, err := client.RenameNX(context.Background(), "key", "taken"); err != nil || renamed {
+		t.Errorf("RenameNX got = %v, %v", renamed, err)
+	}
+	if _, err := client.RenameNX(context.Background(), "missing", "new"); !errors.Is(err, ErrNil) {
+		t.Errorf("RenameNX error = %v, want %v", err, ErrNil)
+	}
+}
+
+func TestClient_CopyMove(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(1), int64(0), int64(1))
+	if copied, err := client.Copy(context.Background(), "src", "dst", false); err != nil || !copied {
+		t.Errorf("Copy got = %v, %v", copied, err)
+	}
+	if want := "*3\r\n$4\r\nCOPY\r\n$3\r\nsrc\r\n$3\r\ndst\r\n"; sent != want {
+		t.Errorf("Copy sent %q, want %q", sent, want)
+	}
+	if copied, err := client.CopyToDB(context.Background(), "src", "dst", 2, true); err != nil || copied {
+		t.Errorf("CopyToDB got = %v, %v", copied, err)
+	}
+	if want := "*6\r\n$4\r\nCOPY\r\n$3\r\nsrc\r\n$3\r\ndst\r\n$2\r\nDB\r\n$1\r\n2\r\n$7\r\nREPLACE\r\n"; sent != want {
+		t.Errorf("CopyToDB sent %q, want %q", sent, want)
+	}
+	if moved, err := client.Move(context.Background(), "key", 3); err != nil || !moved {
+		t.Errorf("Move got = %v, %v", moved, err)
+	}
+	if want := "*3\r\n$4\r\nMOVE\r\n$3\r\nkey\r\n$1\r\n3\r\n"; sent != want {
+		t.Errorf("Move sent %q, want %q", sent, want)
+	}
+}
+
+func TestClient_TypeObjectEncoding(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("hash", "none")
+	if got, err := client.Type(context.Background(), "key"); err != nil || got != "hash" {
+		t.Errorf("Type got = %q, %v", got, err)
+	}
+	if _, err := client.Type(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("Type error = %v, want %v", err, ErrNil)
+	}
+
+	ReceiveFunc = replySequence("listpack", nil)
+	if got, err := client.ObjectEncoding(context.Background(), "key"); err != nil || got != "listpack" {
+		t.Errorf("ObjectEncoding got = %q, %v", got, err)
+	}
+	if _, err := client.ObjectEncoding(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("ObjectEncoding error = %v, want %v", err, ErrNil)
+	}
+}
+
+func TestClient_ObjectFreqIdleTime(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(7), nil, RedisError("ERR An LFU maxmemory policy is not selected, access frequency not tracked."))
+	if got, err := client.ObjectFreq(context.Background(), "key"); err != nil || got != 7 {
+		t.Errorf("ObjectFreq got = %d, %v", got, err)
+	}
+	if _, err := client.ObjectFreq(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("ObjectFreq error = %v, want %v", err, ErrNil)
+	}
+	if _, err := client.ObjectFreq(context.Background(), "key"); err == nil {
+		t.Error("ObjectFreq expected the server error")
+	}
+
+	ReceiveFunc = replySequence(int64(120), nil)
+	if got, err := client.ObjectIdleTime(context.Background(), "key"); err != nil || got != 2*time.Minute {
+		t.Errorf("ObjectIdleTime got = %v, %v", got, err)
+	}
+	if _, err := client.ObjectIdleTime(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("ObjectIdleTime error = %v, want %v", err, ErrNil)
+	}
+}
+
+func TestClient_Keys(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence([]interface{}{"user:1", "user:2"}, []interface{}{}, "OK")
+	if got, err := client.Keys(context.Background(), "user:*"); err != nil || len(got) != 2 || got[0] != "user:1" || got[1] != "user:2" {
+		t.Errorf("Keys got = %q, %v", got, err)
+	}
+	if got, err := client.Keys(context.Background(), "none:*"); err != nil || len(got) != 0 {
+		t.Errorf("Keys got = %q, %v", got, err)
+	}
+	if _, err := client.Keys(context.Background(), "*"); err == nil {
+		t.Error("Keys expected an error for a non-array reply")
+	}
+}
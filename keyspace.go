@@ -0,0 +1,205 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type KeyEventType string
+
+const (
+	EventSet     KeyEventType = "set"
+	EventDel     KeyEventType = "del"
+	EventExpired KeyEventType = "expired"
+	EventEvicted KeyEventType = "evicted"
+)
+
+// keyEventFlags maps events to the notify-keyspace-events class that publishes them.
+var keyEventFlags = map[KeyEventType]string{
+	EventSet:     "$",
+	EventDel:     "g",
+	EventExpired: "x",
+	EventEvicted: "e",
+}
+
+// KeyEvent is a keyevent notification: Event happened to Key in database DB.
+type KeyEvent struct {
+	DB    int
+	Key   string
+	Event KeyEventType
+}
+
+// KeyEventSubscriber delivers the keyevent notifications matching a key pattern, see Client.SubscribeKeyEvents.
+type KeyEventSubscriber struct {
+	ps     *PubSub
+	events chan *KeyEvent
+}
+
+// EnableKeyEvents makes sure the server publishes keyevent notifications for events (every event when none is given),
+// adding the missing flags to notify-keyspace-events with CONFIG SET. Nothing is changed if the flags are already set.
+func (client *Client) EnableKeyEvents(ctx context.Context, events ...KeyEventType) error {
+	reply, err := client.Do(ctx, "CONFIG", "GET", "notify-keyspace-events")
+	if err != nil {
+		return err
+	}
+	config, err := reply.Map()
+	if err != nil {
+		return fmt.Errorf("enableKeyEvents: unexpected response from server %v", reply.Value())
+	}
+	current := config["notify-keyspace-events"]
+
+	required := []string{"E"}
+	if len(events) == 0 {
+		required = append(required, "A")
+	}
+	for _, event := range events {
+		flag, ok := keyEventFlags[event]
+		if !ok {
+			return fmt.Errorf("enableKeyEvents: unsupported event %s", event)
+		}
+		required = append(required, flag)
+	}
+
+	missing := ""
+	for _, flag := range required {
+		// "A" is an alias for every class flag
+		covered := strings.Contains(current, flag) || (flag != "E" && flag != "A" && strings.Contains(current, "A"))
+		if !covered && !strings.Contains(missing, flag) {
+			missing += flag
+		}
+	}
+	if missing == "" {
+		return nil
+	}
+
+	reply, err = client.Do(ctx, "CONFIG", "SET", "notify-keyspace-events", current+missing)
+	if err != nil {
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("enableKeyEvents: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// SubscribeKeyEvents enables keyevent notifications (see EnableKeyEvents) and subscribes to the __keyevent@<db>__
+// channels of events, delivering the ones for keys matching pattern (glob-style, as in KEYS) on Events.
+// Every event is delivered when none is given.
+func (client *Client) SubscribeKeyEvents(ctx context.Context, db int, pattern string, events ...KeyEventType) (*KeyEventSubscriber, error) {
+	if err := client.EnableKeyEvents(ctx, events...); err != nil {
+		return nil, err
+	}
+
+	channels := []string{fmt.Sprintf("__keyevent@%d__:*", db)}
+	if len(events) > 0 {
+		channels = channels[:0]
+		for _, event := range events {
+			channels = append(channels, fmt.Sprintf("__keyevent@%d__:%s", db, event))
+		}
+	}
+	ps, err := client.PSubscribe(ctx, channels...)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &KeyEventSubscriber{
+		ps:     ps,
+		events: make(chan *KeyEvent, 100),
+	}
+	go sub.receive(db, pattern)
+	return sub, nil
+}
+
+// Events returns the channel notifications are delivered on, it is closed once the subscriber stops.
+func (sub *KeyEventSubscriber) Events() <-chan *KeyEvent {
+	return sub.events
+}
+
+func (sub *KeyEventSubscriber) Close() error {
+	return sub.ps.Close()
+}
+
+func (sub *KeyEventSubscriber) receive(db int, pattern string) {
+	defer close(sub.events)
+	for msg := range sub.ps.Channel() {
+		// the channel is __keyevent@<db>__:<event> and the payload holds the key
+		_, event, ok := strings.Cut(msg.Channel, "__:")
+		if !ok || !matchPattern(pattern, msg.Payload) {
+			continue
+		}
+		select {
+		case sub.events <- &KeyEvent{DB: db, Key: msg.Payload, Event: KeyEventType(event)}:
+		case <-sub.ps.done:
+			return
+		}
+	}
+}
+
+// matchPattern reports whether s matches the glob-style pattern the way redis matches KEYS patterns:
+// * matches any sequence, ? a single character, [abc], [^abc] and [a-z] character classes, \ escapes.
+func matchPattern(pattern string, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchPattern(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := strings.IndexByte(pattern[1:], ']')
+			if end < 0 {
+				return false
+			}
+			class := pattern[1 : end+1]
+			negate := strings.HasPrefix(class, "^")
+			if negate {
+				class = class[1:]
+			}
+			matched := false
+			for i := 0; i < len(class); i++ {
+				if i+2 < len(class) && class[i+1] == '-' {
+					if class[i] <= s[0] && s[0] <= class[i+2] {
+						matched = true
+					}
+					i += 2
+				} else if class[i] == s[0] {
+					matched = true
+				}
+			}
+			if matched == negate {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[end+1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return len(s) == 0
+}
@@ -0,0 +1,102 @@
+package resp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"*", "anything", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"user:*:name", "user:a/b:name", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestClient_EnableKeyEvents(t *testing.T) {
+	t.Run("adds missing flags", func(t *testing.T) {
+		var sent []string
+		SendFunc = func(command string) error {
+			sent = append(sent, command)
+			return nil
+		}
+		ReceiveFunc = replySequence([]interface{}{"notify-keyspace-events", "g"}, "OK")
+		client := newMockClient(2, "password")
+		if err := client.EnableKeyEvents(context.Background(), EventExpired, EventDel); err != nil {
+			t.Fatalf("EnableKeyEvents returned error: %s", err)
+		}
+		if len(sent) != 2 || !strings.HasSuffix(sent[1], "$3\r\ngEx\r\n") {
+			t.Errorf("EnableKeyEvents sent %q, want CONFIG SET with gEx", sent)
+		}
+	})
+
+	t.Run("already enabled", func(t *testing.T) {
+		var sent []string
+		SendFunc = func(command string) error {
+			sent = append(sent, command)
+			return nil
+		}
+		ReceiveFunc = replySequence([]interface{}{"notify-keyspace-events", "AE"})
+		client := newMockClient(2, "password")
+		if err := client.EnableKeyEvents(context.Background(), EventExpired); err != nil {
+			t.Fatalf("EnableKeyEvents returned error: %s", err)
+		}
+		if len(sent) != 1 {
+			t.Errorf("EnableKeyEvents sent %q, want only CONFIG GET", sent)
+		}
+	})
+}
+
+func TestClient_SubscribeKeyEvents(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = replySequence([]interface{}{"notify-keyspace-events", "Ex"})
+	client, servers := newPipeClient("")
+	go func() {
+		server := <-servers
+		expectCommand(t, server, "PSUBSCRIBE",
+			"*3\r\n$10\r\npsubscribe\r\n$22\r\n__keyevent@0__:expired\r\n:1\r\n"+
+				"*4\r\n$8\r\npmessage\r\n$22\r\n__keyevent@0__:expired\r\n$22\r\n__keyevent@0__:expired\r\n$7\r\norder:1\r\n"+
+				"*4\r\n$8\r\npmessage\r\n$22\r\n__keyevent@0__:expired\r\n$22\r\n__keyevent@0__:expired\r\n$6\r\nuser:1\r\n")
+	}()
+
+	sub, err := client.SubscribeKeyEvents(context.Background(), 0, "user:*", EventExpired)
+	if err != nil {
+		t.Fatalf("SubscribeKeyEvents returned error: %s", err)
+	}
+	defer sub.Close()
+
+	select {
+	case event := <-sub.Events():
+		if event.Key != "user:1" || event.Event != EventExpired || event.DB != 0 {
+			t.Errorf("got event %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+}
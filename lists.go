@@ -0,0 +1,325 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ListSide is an end of a list, see Client.LMove.
+type ListSide string
+
+const (
+	ListLeft  ListSide = "LEFT"
+	ListRight ListSide = "RIGHT"
+)
+
+// InsertPosition places an element relative to a pivot, see Client.LInsert.
+type InsertPosition string
+
+const (
+	InsertBefore InsertPosition = "BEFORE"
+	InsertAfter  InsertPosition = "AFTER"
+)
+
+// LPush inserts values at the head of the list at key, the last value ends up first, and returns the new length.
+func (client *Client) LPush(ctx context.Context, key string, values ...string) (int, error) {
+	return client.push(ctx, "LPUSH", key, values)
+}
+
+// RPush appends values to the tail of the list at key and returns the new length.
+func (client *Client) RPush(ctx context.Context, key string, values ...string) (int, error) {
+	return client.push(ctx, "RPUSH", key, values)
+}
+
+func (client *Client) push(ctx context.Context, command string, key string, values []string) (int, error) {
+	if len(values) == 0 {
+		return 0, fmt.Errorf("%s: no value to push", strings.ToLower(command))
+	}
+	return client.doInt(ctx, keyArgs(command, append([]string{key}, values...))...)
+}
+
+// cappedPushScript pushes ARGV[2], ARGV[3]... to the head of the list KEYS[1], then trims it to the
+// ARGV[1] first elements, in one atomic step.
+var cappedPushScript = NewScript(`redis.call('LPUSH', KEYS[1], unpack(ARGV, 2))
+redis.call('LTRIM', KEYS[1], 0, tonumber(ARGV[1]) - 1)
+return redis.call('LLEN', KEYS[1])`)
+
+// LPushCapped pushes values to the head of the list at key like LPush and drops the oldest elements past
+// maxLen, atomically, and returns the new length. It keeps lists of recent items, such as activity feeds,
+// from growing without bound.
+func (client *Client) LPushCapped(ctx context.Context, key string, maxLen int, values ...string) (int, error) {
+	if maxLen < 1 {
+		return 0, fmt.Errorf("lpushCapped: invalid maximum length %d", maxLen)
+	}
+	if len(values) == 0 {
+		return 0, errors.New("lpushCapped: no value to push")
+	}
+	args := make([]interface{}, 0, len(values)+1)
+	args = append(args, maxLen)
+	for _, value := range values {
+		args = append(args, value)
+	}
+	reply, err := cappedPushScript.Run(ctx, client, []string{key}, args...)
+	if err != nil {
+		return 0, err
+	}
+	length, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("lpushCapped: unexpected response from server %v", reply.Value())
+	}
+	return length, nil
+}
+
+// LPop removes and returns the first element of the list at key, or returns ErrNil when the list is empty.
+func (client *Client) LPop(ctx context.Context, key string) (string, error) {
+	return client.pop(ctx, "LPOP", key)
+}
+
+// RPop removes and returns the last element of the list at key, or returns ErrNil when the list is empty.
+func (client *Client) RPop(ctx context.Context, key string) (string, error) {
+	return client.pop(ctx, "RPOP", key)
+}
+
+func (client *Client) pop(ctx context.Context, command string, key string) (string, error) {
+	reply, err := client.Do(ctx, command, key)
+	if err != nil {
+		return "", err
+	}
+	return reply.Text()
+}
+
+// LPopCount removes and returns up to count elements from the head of the list at key, or returns ErrNil
+// when the list is empty (Redis 6.2+).
+func (client *Client) LPopCount(ctx context.Context, key string, count int) ([]string, error) {
+	return client.popCount(ctx, "LPOP", key, count)
+}
+
+// RPopCount removes and returns up to count elements from the tail of the list at key, last first, or returns
+// ErrNil when the list is empty (Redis 6.2+).
+func (client *Client) RPopCount(ctx context.Context, key string, count int) ([]string, error) {
+	return client.popCount(ctx, "RPOP", key, count)
+}
+
+func (client *Client) popCount(ctx context.Context, command string, key string, count int) ([]string, error) {
+	reply, err := client.Do(ctx, command, key, count)
+	if err != nil {
+		return nil, err
+	}
+	if reply.IsNil() {
+		return nil, ErrNil
+	}
+	elements, err := reply.StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return elements, nil
+}
+
+// LRange returns the elements of the list at key from start to stop included, negative indexes count from
+// the tail (-1 is the last element). A missing key is an empty list.
+func (client *Client) LRange(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	reply, err := client.Do(ctx, "LRANGE", key, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	elements, err := reply.StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("lrange: unexpected response from server %v", reply.Value())
+	}
+	return elements, nil
+}
+
+// LLen returns the length of the list at key, 0 when it doesn't exist.
+func (client *Client) LLen(ctx context.Context, key string) (int, error) {
+	return client.doInt(ctx, "LLEN", key)
+}
+
+// LRem removes the elements equal to element and returns how many were removed: the first count from the
+// head when count > 0, the last -count from the tail when count < 0, all of them when count is 0.
+func (client *Client) LRem(ctx context.Context, key string, count int, element string) (int, error) {
+	return client.doInt(ctx, "LREM", key, count, element)
+}
+
+// LInsert inserts element before or after the first pivot in the list at key and returns the new length,
+// 0 when the key doesn't exist. It returns ErrNil when pivot isn't in the list.
+func (client *Client) LInsert(ctx context.Context, key string, position InsertPosition, pivot string, element string) (int, error) {
+	length, err := client.doInt(ctx, "LINSERT", key, string(position), pivot, element)
+	if err != nil {
+		return 0, err
+	}
+	if length == -1 {
+		return 0, ErrNil
+	}
+	return length, nil
+}
+
+// LSet sets the element at index of the list at key, it returns ErrNil when the key doesn't exist and the
+// server error when index is out of range.
+func (client *Client) LSet(ctx context.Context, key string, index int, element string) error {
+	reply, err := client.Do(ctx, "LSET", key, index, element)
+	if err != nil {
+		if isNoSuchKey(err) {
+			return ErrNil
+		}
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("lset: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// LTrim keeps only the elements of the list at key from start to stop included, see LRange for the indexes.
+func (client *Client) LTrim(ctx context.Context, key string, start int, stop int) error {
+	reply, err := client.Do(ctx, "LTRIM", key, start, stop)
+	if err != nil {
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("ltrim: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// LMove atomically pops an element from the from side of source, pushes it to the to side of destination
+// and returns it, or returns ErrNil when source is empty (Redis 6.2+). With the same list on both ends it
+// rotates the list, which suits reliable queues.
+func (client *Client) LMove(ctx context.Context, source string, destination string, from ListSide, to ListSide) (string, error) {
+	reply, err := client.Do(ctx, "LMOVE", source, destination, string(from), string(to))
+	if err != nil {
+		return "", err
+	}
+	return reply.Text()
+}
+
+// LPosOptions narrow the search of LPos.
+type LPosOptions struct {
+	Rank   int // skip to the Rank-th match, counting from the tail when negative, the first match when zero
+	MaxLen int // compare at most MaxLen elements, the whole list when zero
+}
+
+func (opts LPosOptions) args() []interface{} {
+	var args []interface{}
+	if opts.Rank != 0 {
+		args = append(args, "RANK", opts.Rank)
+	}
+	if opts.MaxLen > 0 {
+		args = append(args, "MAXLEN", opts.MaxLen)
+	}
+	return args
+}
+
+// LPos returns the index of the first element equal to element in the list at key, see LPosOptions, or
+// returns ErrNil when there is none (Redis 6.0.6+).
+func (client *Client) LPos(ctx context.Context, key string, element string, opts LPosOptions) (int, error) {
+	reply, err := client.Do(ctx, append([]interface{}{"LPOS", key, element}, opts.args()...)...)
+	if err != nil {
+		return 0, err
+	}
+	if reply.IsNil() {
+		return 0, ErrNil
+	}
+	index, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("lpos: unexpected response from server %v", reply.Value())
+	}
+	return index, nil
+}
+
+// LPosCount is like LPos for up to count matches, all of them when count is 0. It returns an empty slice
+// when there is none.
+func (client *Client) LPosCount(ctx context.Context, key string, element string, count int, opts LPosOptions) ([]int, error) {
+	reply, err := client.Do(ctx, append([]interface{}{"LPOS", key, element, "COUNT", count}, opts.args()...)...)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := reply.Array()
+	if err != nil {
+		return nil, fmt.Errorf("lpos: unexpected response from server %v", reply.Value())
+	}
+	indexes := make([]int, len(replies))
+	for i, r := range replies {
+		if indexes[i], err = r.Int(); err != nil {
+			return nil, fmt.Errorf("lpos: unexpected response from server %v", reply.Value())
+		}
+	}
+	return indexes, nil
+}
+
+// ListPopResult holds the elements popped by LMPop and the list they were popped from.
+type ListPopResult struct {
+	Key      string
+	Elements []string
+}
+
+// LMPop pops up to count elements, 1 when count isn't positive, from the from side of the first non-empty
+// list of keys. It returns ErrNil when they are all empty (Redis 7.0+).
+func (client *Client) LMPop(ctx context.Context, from ListSide, count int, keys ...string) (ListPopResult, error) {
+	if len(keys) == 0 {
+		return ListPopResult{}, errors.New("lmpop: no key to pop from")
+	}
+	reply, err := client.Do(ctx, append([]interface{}{"LMPOP"}, mpopArgs(keys, string(from), count)...)...)
+	if err != nil {
+		return ListPopResult{}, err
+	}
+	if reply.IsNil() {
+		return ListPopResult{}, ErrNil
+	}
+	key, elements, err := parseMPop(reply)
+	if err != nil {
+		return ListPopResult{}, fmt.Errorf("lmpop: %w", err)
+	}
+	result := ListPopResult{Key: key, Elements: make([]string, len(elements))}
+	for i, element := range elements {
+		if result.Elements[i], err = element.Text(); err != nil {
+			return ListPopResult{}, fmt.Errorf("lmpop: unexpected response from server %v", reply.Value())
+		}
+	}
+	return result, nil
+}
+
+// mpopArgs returns the arguments of LMPOP and ZMPOP after the name: numkeys key... side [COUNT count].
+func mpopArgs(keys []string, side string, count int) []interface{} {
+	args := make([]interface{}, 0, len(keys)+4)
+	args = append(args, len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	args = append(args, side)
+	if count > 0 {
+		args = append(args, "COUNT", count)
+	}
+	return args
+}
+
+// parseMPop splits a reply of LMPOP or ZMPOP into the key and the popped elements.
+func parseMPop(reply *Reply) (string, []*Reply, error) {
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 2 {
+		return "", nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	key, err := parts[0].Text()
+	if err != nil {
+		return "", nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	elements, err := parts[1].Array()
+	if err != nil {
+		return "", nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	return key, elements, nil
+}
+
+// doInt runs args and converts the integer reply.
+func (client *Client) doInt(ctx context.Context, args ...interface{}) (int, error) {
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(fmt.Sprint(args[0])), reply.Value())
+	}
+	return n, nil
+}
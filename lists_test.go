@@ -0,0 +1,189 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClient_Lists(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	tests := []struct {
+		name  string
+		reply interface{}
+		call  func(ctx context.Context) (interface{}, error)
+		want  interface{}
+		args  []string
+	}{
+		{
+			name: "LPush", reply: int64(3), want: 3, args: []string{"LPUSH", "list", "a", "b"},
+			call: func(ctx context.Context) (interface{}, error) { return client.LPush(ctx, "list", "a", "b") },
+		},
+		{
+			name: "RPush", reply: int64(1), want: 1, args: []string{"RPUSH", "list", "a"},
+			call: func(ctx context.Context) (interface{}, error) { return client.RPush(ctx, "list", "a") },
+		},
+		{
+			name: "LPop", reply: "a", want: "a", args: []string{"LPOP", "list"},
+			call: func(ctx context.Context) (interface{}, error) { return client.LPop(ctx, "list") },
+		},
+		{
+			name: "RPopCount", reply: []interface{}{"c", "b"}, want: []string{"c", "b"}, args: []string{"RPOP", "list", "2"},
+			call: func(ctx context.Context) (interface{}, error) { return client.RPopCount(ctx, "list", 2) },
+		},
+		{
+			name: "LRange", reply: []interface{}{"a", "b"}, want: []string{"a", "b"}, args: []string{"LRANGE", "list", "0", "-1"},
+			call: func(ctx context.Context) (interface{}, error) { return client.LRange(ctx, "list", 0, -1) },
+		},
+		{
+			name: "LLen", reply: int64(2), want: 2, args: []string{"LLEN", "list"},
+			call: func(ctx context.Context) (interface{}, error) { return client.LLen(ctx, "list") },
+		},
+		{
+			name: "LRem", reply: int64(1), want: 1, args: []string{"LREM", "list", "-1", "a"},
+			call: func(ctx context.Context) (interface{}, error) { return client.LRem(ctx, "list", -1, "a") },
+		},
+		{
+			name: "LInsert", reply: int64(4), want: 4, args: []string{"LINSERT", "list", "AFTER", "a", "z"},
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.LInsert(ctx, "list", InsertAfter, "a", "z")
+			},
+		},
+		{
+			name: "LSet", reply: "OK", want: nil, args: []string{"LSET", "list", "0", "z"},
+			call: func(ctx context.Context) (interface{}, error) { return nil, client.LSet(ctx, "list", 0, "z") },
+		},
+		{
+			name: "LTrim", reply: "OK", want: nil, args: []string{"LTRIM", "list", "0", "99"},
+			call: func(ctx context.Context) (interface{}, error) { return nil, client.LTrim(ctx, "list", 0, 99) },
+		},
+		{
+			name: "LMove", reply: "a", want: "a", args: []string{"LMOVE", "queue", "processing", "LEFT", "RIGHT"},
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.LMove(ctx, "queue", "processing", ListLeft, ListRight)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ReceiveFunc = replySequence(tt.reply)
+			got, err := tt.call(context.Background())
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, %v, want %v", got, err, tt.want)
+			}
+			if !reflect.DeepEqual(sent, tt.args) {
+				t.Errorf("sent %q, want %q", sent, tt.args)
+			}
+		})
+	}
+}
+
+func TestClient_ListsNil(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(nil, nil, nil, int64(-1), RedisError("ERR no such key"), RedisError("ERR index out of range"))
+	if _, err := client.RPop(context.Background(), "empty"); !errors.Is(err, ErrNil) {
+		t.Errorf("RPop error = %v, want %v", err, ErrNil)
+	}
+	if _, err := client.LPopCount(context.Background(), "empty", 3); !errors.Is(err, ErrNil) {
+		t.Errorf("LPopCount error = %v, want %v", err, ErrNil)
+	}
+	if _, err := client.LMove(context.Background(), "empty", "other", ListRight, ListLeft); !errors.Is(err, ErrNil) {
+		t.Errorf("LMove error = %v, want %v", err, ErrNil)
+	}
+	if _, err := client.LInsert(context.Background(), "list", InsertBefore, "missing", "z"); !errors.Is(err, ErrNil) {
+		t.Errorf("LInsert error = %v, want %v", err, ErrNil)
+	}
+	if err := client.LSet(context.Background(), "missing", 0, "z"); !errors.Is(err, ErrNil) {
+		t.Errorf("LSet error = %v, want %v", err, ErrNil)
+	}
+	if err := client.LSet(context.Background(), "list", 99, "z"); err == nil || errors.Is(err, ErrNil) {
+		t.Errorf("LSet error = %v, want the server error", err)
+	}
+	if _, err := client.LPush(context.Background(), "list"); err == nil {
+		t.Error("LPush expected an error without values")
+	}
+}
+
+func TestClient_LPushCapped(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(100))
+	if got, err := client.LPushCapped(context.Background(), "feed", 100, "a", "b"); err != nil || got != 100 {
+		t.Errorf("LPushCapped() got = %d, %v", got, err)
+	}
+	if want := []string{"EVALSHA", cappedPushScript.Hash(), "1", "feed", "100", "a", "b"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("LPushCapped() sent %q, want %q", sent, want)
+	}
+
+	// the script is sent when the server doesn't have it cached
+	ReceiveFunc = replySequence(RedisError("NOSCRIPT No matching script."), int64(1))
+	if got, err := client.LPushCapped(context.Background(), "feed", 100, "a"); err != nil || got != 1 {
+		t.Errorf("LPushCapped() got = %d, %v", got, err)
+	}
+	if len(sent) == 0 || sent[0] != "EVAL" {
+		t.Errorf("LPushCapped() sent %q, want EVAL", sent)
+	}
+
+	sent = nil
+	if _, err := client.LPushCapped(context.Background(), "feed", 0, "a"); err == nil || sent != nil {
+		t.Errorf("LPushCapped() error = %v, sent %q, want an error before sending", err, sent)
+	}
+}
+
+func TestClient_LPos(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(3), nil, []interface{}{int64(1), int64(4)}, []interface{}{})
+	if got, err := client.LPos(context.Background(), "list", "a", LPosOptions{Rank: -1, MaxLen: 100}); err != nil || got != 3 {
+		t.Errorf("LPos() got = %d, %v", got, err)
+	}
+	if want := []string{"LPOS", "list", "a", "RANK", "-1", "MAXLEN", "100"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("LPos() sent %q, want %q", sent, want)
+	}
+	if _, err := client.LPos(context.Background(), "list", "z", LPosOptions{}); !errors.Is(err, ErrNil) {
+		t.Errorf("LPos() error = %v, want %v", err, ErrNil)
+	}
+	if got, err := client.LPosCount(context.Background(), "list", "a", 0, LPosOptions{}); err != nil || !reflect.DeepEqual(got, []int{1, 4}) {
+		t.Errorf("LPosCount() got = %v, %v", got, err)
+	}
+	if want := []string{"LPOS", "list", "a", "COUNT", "0"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("LPosCount() sent %q, want %q", sent, want)
+	}
+	if got, err := client.LPosCount(context.Background(), "list", "z", 2, LPosOptions{}); err != nil || len(got) != 0 {
+		t.Errorf("LPosCount() got = %v, %v", got, err)
+	}
+}
+
+func TestClient_LMPop(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence([]interface{}{"q2", []interface{}{"a", "b"}}, nil, []interface{}{"q1"})
+	got, err := client.LMPop(context.Background(), ListLeft, 2, "q1", "q2")
+	if want := (ListPopResult{Key: "q2", Elements: []string{"a", "b"}}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("LMPop() got = %+v, %v, want %+v", got, err, want)
+	}
+	if want := []string{"LMPOP", "2", "q1", "q2", "LEFT", "COUNT", "2"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("LMPop() sent %q, want %q", sent, want)
+	}
+	if _, err := client.LMPop(context.Background(), ListRight, 0, "q1"); !errors.Is(err, ErrNil) {
+		t.Errorf("LMPop() error = %v, want %v", err, ErrNil)
+	}
+	if want := []string{"LMPOP", "1", "q1", "RIGHT"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("LMPop() sent %q, want %q", sent, want)
+	}
+	if _, err := client.LMPop(context.Background(), ListRight, 0, "q1"); err == nil || errors.Is(err, ErrNil) {
+		t.Errorf("LMPop() error = %v, want an error for a malformed reply", err)
+	}
+}
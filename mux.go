@@ -0,0 +1,283 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// muxMaxInflight caps the commands written to a multiplexed connection and waiting for their reply,
+// the writer waits for replies to be read past it.
+const muxMaxInflight = 1024
+
+// errMuxRetired is returned by muxConn.send once the connection was replaced.
+var errMuxRetired = errors.New("multiplexed connection retired")
+
+// unmultiplexedCommands block the connection (BLPOP, WAIT...) or change its state (SELECT, MULTI, SUBSCRIBE...),
+// so they are never sent on the shared connection, see Options.Multiplex.
+var unmultiplexedCommands = map[string]bool{
+	"BLPOP": true, "BRPOP": true, "BRPOPLPUSH": true, "BLMOVE": true, "BLMPOP": true,
+	"BZPOPMIN": true, "BZPOPMAX": true, "BZMPOP": true, "XREAD": true, "XREADGROUP": true,
+	"WAIT": true, "WAITAOF": true,
+	"MULTI": true, "EXEC": true, "DISCARD": true, "WATCH": true, "UNWATCH": true,
+	"SELECT": true, "AUTH": true, "HELLO": true, "RESET": true, "CLIENT": true, "QUIT": true,
+	"READONLY": true, "READWRITE": true,
+	"SUBSCRIBE": true, "PSUBSCRIBE": true, "SSUBSCRIBE": true, "MONITOR": true,
+	"UNSUBSCRIBE": true, "PUNSUBSCRIBE": true, "SUNSUBSCRIBE": true,
+}
+
+// multiplexed reports whether args can be sent on the shared connection.
+func multiplexed(args []interface{}) bool {
+	if len(args) == 0 {
+		return false
+	}
+	name, ok := args[0].(string)
+	return ok && !unmultiplexedCommands[strings.ToUpper(name)]
+}
+
+// mux shares one connection between all the callers of Do, see Options.Multiplex. The connection is
+// opened on first use and replaced when it breaks or the pool is retired (Select, ReAuth).
+type mux struct {
+	pool *pool
+	mu   sync.Mutex
+	conn *muxConn
+}
+
+func newMux(pool *pool) *mux {
+	return &mux{pool: pool}
+}
+
+// do runs args on the shared connection and returns the reply value, error replies as errors.
+func (m *mux) do(ctx context.Context, args []interface{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	req := &muxRequest{args: args, done: make(chan struct{})}
+	for {
+		mc, err := m.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = mc.send(req)
+		if err == nil {
+			break
+		}
+		if err != errMuxRetired {
+			return nil, err
+		}
+		// replaced since get returned it, queue on the new one
+	}
+	select {
+	case <-req.done:
+		return req.value, req.err
+	case <-ctx.Done():
+		// the reply is still read and dropped, the connection stays in sync
+		return nil, ctx.Err()
+	}
+}
+
+// get returns the shared connection, dialing a new one when there is none or it is unusable.
+func (m *mux) get(ctx context.Context) (*muxConn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.pool.closed.Load() {
+		return nil, ErrClosed
+	}
+	gen := m.pool.gen.Load()
+	if m.conn != nil && m.conn.gen == gen && m.conn.usable() {
+		return m.conn, nil
+	}
+	if m.conn != nil {
+		m.conn.retire()
+		m.conn = nil
+	}
+	conn, err := m.pool.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.conn = newMuxConn(conn, gen)
+	return m.conn, nil
+}
+
+// close retires the shared connection, the commands already sent still get their reply.
+func (m *mux) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn != nil {
+		m.conn.retire()
+		m.conn = nil
+	}
+}
+
+type muxRequest struct {
+	args  []interface{}
+	value interface{}
+	err   error
+	done  chan struct{}
+}
+
+func (req *muxRequest) finish(value interface{}, err error) {
+	req.value, req.err = value, err
+	close(req.done)
+}
+
+// muxConn is a connection shared by concurrent callers. The commands they send are queued and written by
+// one goroutine in batches, with one flush per batch, and another goroutine reads the replies, which come
+// back in the order the commands were written.
+type muxConn struct {
+	conn IConnection
+	gen  uint64
+
+	mu      sync.Mutex
+	queued  []*muxRequest
+	retired bool
+	err     error // why the connection broke, nil while it works
+
+	wake    chan struct{}    // signals the writer that commands were queued or the connection retired
+	pending chan *muxRequest // written commands waiting for their reply, closed when the writer exits
+	broken  chan struct{}    // closed once err is set
+}
+
+func newMuxConn(conn IConnection, gen uint64) *muxConn {
+	mc := &muxConn{
+		conn:    conn,
+		gen:     gen,
+		wake:    make(chan struct{}, 1),
+		pending: make(chan *muxRequest, muxMaxInflight),
+		broken:  make(chan struct{}),
+	}
+	go mc.writeLoop()
+	go mc.readLoop()
+	return mc
+}
+
+func (mc *muxConn) usable() bool {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return mc.err == nil && !mc.retired
+}
+
+// send queues req for the writer, it fails when the connection broke or was retired.
+func (mc *muxConn) send(req *muxRequest) error {
+	mc.mu.Lock()
+	if mc.err != nil || mc.retired {
+		err := mc.err
+		if err == nil {
+			err = errMuxRetired
+		}
+		mc.mu.Unlock()
+		return err
+	}
+	mc.queued = append(mc.queued, req)
+	mc.mu.Unlock()
+	mc.signal()
+	return nil
+}
+
+func (mc *muxConn) signal() {
+	select {
+	case mc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// retire stops the writer once the queued commands are written, the connection is closed after their replies.
+func (mc *muxConn) retire() {
+	mc.mu.Lock()
+	mc.retired = true
+	mc.mu.Unlock()
+	mc.signal()
+}
+
+// fail marks the connection broken with err, closes it and fails the queued commands. The first error is kept.
+func (mc *muxConn) fail(err error) error {
+	mc.mu.Lock()
+	if mc.err != nil {
+		err = mc.err
+		mc.mu.Unlock()
+		return err
+	}
+	mc.err = err
+	queued := mc.queued
+	mc.queued = nil
+	mc.mu.Unlock()
+
+	close(mc.broken)
+	_ = mc.conn.Close()
+	for _, req := range queued {
+		req.finish(nil, err)
+	}
+	return err
+}
+
+func (mc *muxConn) writeLoop() {
+	defer close(mc.pending)
+	ctx := context.Background() // the write timeout applies, the callers' contexts can't interrupt a shared connection
+	for {
+		select {
+		case <-mc.wake:
+		case <-mc.broken:
+			return
+		}
+		mc.mu.Lock()
+		batch := mc.queued
+		mc.queued = nil
+		retired := mc.retired
+		mc.mu.Unlock()
+
+		// commands are taken off batch as they are written, the rest were not sent when writing fails
+		written := make([]*muxRequest, 0, len(batch))
+		var err error
+		for len(batch) > 0 {
+			if err = mc.conn.WriteCommand(ctx, batch[0].args...); err != nil && !errors.Is(err, errUnsupportedArg) {
+				break
+			}
+			if err != nil {
+				batch[0].finish(nil, err) // nothing was written
+				err = nil
+			} else {
+				written = append(written, batch[0])
+			}
+			batch = batch[1:]
+		}
+		if err == nil && len(written) > 0 {
+			err = mc.conn.Flush(ctx)
+		}
+		if err != nil {
+			err = mc.fail(err)
+			for _, req := range append(written, batch...) {
+				req.finish(nil, err)
+			}
+			return
+		}
+		// the reader fails them if the connection broke meanwhile
+		for _, req := range written {
+			mc.pending <- req
+		}
+		if retired {
+			// nothing can be queued anymore, see send
+			return
+		}
+	}
+}
+
+func (mc *muxConn) readLoop() {
+	ctx := context.Background() // the read timeout applies, see writeLoop
+	var err error
+	for req := range mc.pending {
+		if err != nil {
+			req.finish(nil, err)
+			continue
+		}
+		value, readErr := mc.conn.ReceiveValue(ctx)
+		if readErr != nil && !isReplyError(readErr) {
+			err = mc.fail(readErr)
+			req.finish(nil, err)
+			continue
+		}
+		req.finish(value, readErr)
+	}
+	// the writer exited: the connection was retired and every reply read, or it broke
+	_ = mc.fail(ErrClosed)
+}
@@ -0,0 +1,155 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newMuxClient returns a multiplexing client whose connections are answered by serve, it returns the number of dials.
+func newMuxClient(serve func(server *Connection)) (*Client, *atomic.Int32) {
+	client, servers := newPipeClient("")
+	client.pool = newPool(client.newConn, &Options{MaxActive: 2, MaxIdle: 2, PoolTimeout: DefaultPoolTimeout})
+	client.mux = newMux(client.pool)
+	dials := &atomic.Int32{}
+	go func() {
+		for server := range servers {
+			dials.Add(1)
+			go serve(server)
+		}
+	}()
+	return client, dials
+}
+
+// serveEcho answers every command with its last argument, until the connection is closed.
+func serveEcho(server *Connection) {
+	for {
+		value, err := server.ReceiveValue(context.Background())
+		if err != nil {
+			return
+		}
+		args, _ := NewReply(value).StringSlice()
+		last := args[len(args)-1]
+		_, _ = server.rw.WriteString("$" + strconv.Itoa(len(last)) + "\r\n" + last + "\r\n")
+		_ = server.rw.Flush()
+	}
+}
+
+func TestMux_Concurrent(t *testing.T) {
+	client, dials := newMuxClient(serveEcho)
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				want := strconv.Itoa(i) + "-" + strconv.Itoa(j)
+				if got, err := client.Get(context.Background(), want); err != nil || got != want {
+					t.Errorf("Get(%s) got = %q, %v", want, got, err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if got := dials.Load(); got != 1 {
+		t.Errorf("dialed %d connections, want the one shared connection", got)
+	}
+	if stats := client.PoolStats(); stats.Misses != 0 {
+		t.Errorf("PoolStats() got %+v, want no pooled connection", stats)
+	}
+}
+
+func TestMux_Unmultiplexed(t *testing.T) {
+	client, dials := newMuxClient(serveEcho)
+	defer client.Close()
+
+	if _, err := client.Do(context.Background(), "GET", "key"); err != nil {
+		t.Fatalf("Do(GET) error = %v", err)
+	}
+	if _, err := client.Do(context.Background(), "BLPOP", "queue", "0"); err != nil {
+		t.Fatalf("Do(BLPOP) error = %v", err)
+	}
+	if got := dials.Load(); got != 2 {
+		t.Errorf("dialed %d connections, want BLPOP on a pooled connection", got)
+	}
+	if _, err := client.Do(context.Background(), "GET", struct{}{}); !errors.Is(err, errUnsupportedArg) {
+		t.Errorf("Do() error = %v, want %v", err, errUnsupportedArg)
+	}
+	if got, err := client.Get(context.Background(), "after"); err != nil || got != "after" {
+		t.Errorf("Get() got = %q, %v", got, err)
+	}
+}
+
+func TestMultiplexed(t *testing.T) {
+	for _, name := range []string{"GET", "set", "HGETALL"} {
+		if !multiplexed([]interface{}{name, "key"}) {
+			t.Errorf("multiplexed(%s) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"READONLY", "readwrite", "UNSUBSCRIBE", "PUNSUBSCRIBE", "SUNSUBSCRIBE", "blpop"} {
+		if multiplexed([]interface{}{name}) {
+			t.Errorf("multiplexed(%s) = true, want the command on a pooled connection", name)
+		}
+	}
+}
+
+func TestMux_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	client, _ := newMuxClient(func(server *Connection) {
+		<-release // the first command is answered late
+		serveEcho(server)
+	})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.Get(ctx, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+	// the late reply is dropped, not handed to the next caller
+	if got, err := client.Get(context.Background(), "fast"); err != nil || got != "fast" {
+		t.Errorf("Get() got = %q, %v", got, err)
+	}
+}
+
+func TestMux_Broken(t *testing.T) {
+	var served atomic.Int32
+	client, dials := newMuxClient(func(server *Connection) {
+		if served.Add(1) == 1 {
+			_, _ = server.ReceiveValue(context.Background())
+			_ = server.Close() // the server goes away with a command in flight
+			return
+		}
+		serveEcho(server)
+	})
+	defer client.Close()
+
+	if _, err := client.Get(context.Background(), "lost"); err == nil {
+		t.Fatal("Get() expected an error from the broken connection")
+	}
+	if got, err := client.Get(context.Background(), "key"); err != nil || got != "key" {
+		t.Errorf("Get() got = %q, %v", got, err)
+	}
+	if got := dials.Load(); got != 2 {
+		t.Errorf("dialed %d connections, want the broken one replaced", got)
+	}
+}
+
+func TestMux_Close(t *testing.T) {
+	client, _ := newMuxClient(serveEcho)
+	if _, err := client.Get(context.Background(), "key"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = client.Close()
+	if _, err := client.Get(context.Background(), "key"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get() error = %v, want %v", err, ErrClosed)
+	}
+}
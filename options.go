@@ -0,0 +1,596 @@
+package resp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options configures a client created with NewClientWithOptions, see NewClient for the functional form.
+type Options struct {
+	// Network is "tcp" (the default) or "unix" when Address is a unix socket path
+	Network string
+	Address string
+	// Username is the ACL user (Redis 6+) to authenticate as, the password alone authenticates the default user
+	Username string
+	// Password is sent with AUTH on every new connection when set
+	Password string
+	// CredentialsProvider, when set, is asked for the username and password instead of Username and Password
+	CredentialsProvider CredentialsProvider
+	// DB is the database selected on every new connection
+	DB int
+	// ClientName is registered with CLIENT SETNAME on every new connection, identifying it in CLIENT LIST
+	ClientName string
+	// TLSConfig enables TLS when set, see NewTLSConfig
+	TLSConfig *tls.Config
+	// Proxy routes connections through a SOCKS5 (socks5://) or HTTP CONNECT (http://) proxy
+	Proxy *url.URL
+	// Dialer overrides how connections are opened, TLSConfig and Proxy are ignored when it is set
+	Dialer IDialer
+	// DialTimeout bounds dialing and the connection handshake, DefaultDialTimeout is used when zero
+	DialTimeout time.Duration
+	// ReadTimeout and WriteTimeout bound reads and writes when the context has no deadline,
+	// DefaultReadTimeout and DefaultWriteTimeout are used when zero, negative values disable them
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// ReadBufferSize and WriteBufferSize are the sizes of the buffers of every connection, DefaultBufferSize
+	// when zero. Larger buffers take large values in fewer reads and writes, smaller ones save memory per connection
+	ReadBufferSize  int
+	WriteBufferSize int
+	// MaxActive caps the connections in use at once, callers wait for one to be released past it.
+	// DefaultPoolSize is used when zero
+	MaxActive int
+	// MaxIdle is how many released connections are kept open for reuse, MaxActive when zero
+	MaxIdle int
+	// MinIdleConns is how many idle connections are kept open in the background, replacing the ones
+	// handed out or closed, so borrowers rarely wait for a dial. It is capped by MaxIdle, none are kept when zero
+	MinIdleConns int
+	// PoolTimeout is how long to wait for a connection while MaxActive are in use before failing
+	// with ErrPoolExhausted, DefaultPoolTimeout when zero
+	PoolTimeout time.Duration
+	// IdleTimeout closes connections left idle for longer, before the server or a load balancer drops them.
+	// DefaultIdleTimeout is used when zero, a negative value keeps idle connections open
+	IdleTimeout time.Duration
+	// MaxConnLifetime closes connections open for longer once released, they are kept forever when zero
+	MaxConnLifetime time.Duration
+	// TestOnBorrow checks an idle connection before it is handed out, lastUsed is when it was released.
+	// The connection is closed and another one is used when an error is returned, see PingIfIdle
+	TestOnBorrow func(ctx context.Context, conn IConnection, lastUsed time.Time) error
+	// PoolHooks are called as pooled connections are opened, handed out, released and closed
+	PoolHooks PoolHooks
+	// PoolFIFO reuses the least recently used idle connection first, spreading the load over the idle
+	// connections and keeping them all warm. By default (LIFO) the most recently used one is, which keeps
+	// fewer connections busy and lets the extra ones hit the idle timeout
+	PoolFIFO bool
+	// Multiplex sends the commands run with Do from every goroutine on one shared connection: they are written
+	// in batches, one flush per batch, and their replies handed back in order, so many concurrent callers don't
+	// need as many pooled connections. Blocking and connection state commands (BLPOP, WAIT, SELECT, MULTI,
+	// SUBSCRIBE...) still use the pool, as do pipelines, transactions and streamed values. The read and write
+	// timeouts bound the shared connection, a context only stops its caller from waiting for the reply
+	Multiplex bool
+	// MaxRetries is how many times Do runs a command again after a transient failure: the connection it
+	// was sent on turned out to be closed (io.EOF, broken pipe, connection reset, retried on a new connection),
+	// the dial failed, or the server replied LOADING, READONLY, CLUSTERDOWN, TRYAGAIN or MASTERDOWN.
+	// The command may have run already when the connection broke while reading its reply, so only enable it
+	// when running commands twice is harmless. Commands are not retried when zero
+	MaxRetries int
+	// MinRetryBackoff and MaxRetryBackoff bound the exponential backoff between retries, a random duration up
+	// to MinRetryBackoff doubled on every retry, capped at MaxRetryBackoff. DefaultMinRetryBackoff and
+	// DefaultMaxRetryBackoff are used when zero
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+	// CircuitBreakerThreshold opens the circuit breaker of the client after as many consecutive commands
+	// failed to reach the server (I/O errors, timeouts, failed dials): Do then returns ErrCircuitOpen right away
+	// for CircuitBreakerCooldown, after which a PING probes the server. There is no circuit breaker when zero
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is how long the circuit breaker stays open, DefaultCircuitBreakerCooldown when zero
+	CircuitBreakerCooldown time.Duration
+	// ErrorArgs includes the arguments of a failed command in its CommandError, besides the command name.
+	// Leave it off when commands may carry secrets or personal data that shouldn't end up in logs
+	ErrorArgs bool
+	// Warmup is how many connections are opened concurrently when the client is created, 1 when zero
+	Warmup int
+	// LazyConnect makes creating the client return right away, the warmup connections are opened in the background
+	LazyConnect bool
+	// readOnly sends READONLY on every new connection, see Client.AddReplica
+	readOnly bool
+}
+
+// PoolHooks lets applications set up every pooled connection (CLIENT TRACKING, CLIENT NO-EVICT...) and
+// follow their lifecycle, e.g. for metrics. Unset hooks are skipped, the others must be safe for concurrent use.
+type PoolHooks struct {
+	// OnConnect runs on every new connection once authenticated, before it is used. The connection is
+	// closed and the dial fails when an error is returned
+	OnConnect func(ctx context.Context, conn IConnection) error
+	// OnBorrow is called when a connection is handed out
+	OnBorrow func(conn IConnection)
+	// OnReturn is called when a connection is released, err is the error it was last used with
+	OnReturn func(conn IConnection, err error)
+	// OnClose is called once the pool closed a connection
+	OnClose func(conn IConnection)
+}
+
+// CredentialsProvider supplies the credentials to authenticate with; it is consulted for every new connection
+// and by Client.ReAuth, so rotated passwords or short-lived tokens (IAM, Vault) are picked up without a restart.
+// An empty username authenticates the default user.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (username string, password string, err error)
+}
+
+// CredentialsProviderFunc adapts a function to a CredentialsProvider.
+type CredentialsProviderFunc func(ctx context.Context) (username string, password string, err error)
+
+func (f CredentialsProviderFunc) Credentials(ctx context.Context) (string, string, error) {
+	return f(ctx)
+}
+
+// Option configures a client created with NewClient.
+type Option func(opts *Options)
+
+// WithAuth sets the password sent with AUTH on every new connection.
+func WithAuth(password string) Option {
+	return func(opts *Options) {
+		opts.Password = password
+	}
+}
+
+// WithUser authenticates every new connection as the ACL user username (Redis 6+).
+func WithUser(username string, password string) Option {
+	return func(opts *Options) {
+		opts.Username = username
+		opts.Password = password
+	}
+}
+
+// WithCredentialsProvider fetches the credentials from provider for every new connection, see CredentialsProvider.
+func WithCredentialsProvider(provider CredentialsProvider) Option {
+	return func(opts *Options) {
+		opts.CredentialsProvider = provider
+	}
+}
+
+// WithDB sets the database selected on every new connection.
+func WithDB(db int) Option {
+	return func(opts *Options) {
+		opts.DB = db
+	}
+}
+
+// WithTLS enables TLS, see NewTLSConfig.
+func WithTLS(config *tls.Config) Option {
+	return func(opts *Options) {
+		opts.TLSConfig = config
+	}
+}
+
+// WithProxy routes connections through a SOCKS5 (socks5://) or HTTP CONNECT (http://) proxy.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(opts *Options) {
+		opts.Proxy = proxyURL
+	}
+}
+
+// WithDialer overrides how connections are opened.
+func WithDialer(dialer IDialer) Option {
+	return func(opts *Options) {
+		opts.Dialer = dialer
+	}
+}
+
+// WithUnixSocket connects to the unix socket at the client address instead of over TCP.
+func WithUnixSocket() Option {
+	return func(opts *Options) {
+		opts.Network = "unix"
+	}
+}
+
+// WithPoolSize sets MaxActive, the number of connections in use at once.
+func WithPoolSize(size int) Option {
+	return func(opts *Options) {
+		opts.MaxActive = size
+	}
+}
+
+// WithMaxIdle sets how many released connections are kept open for reuse.
+func WithMaxIdle(maxIdle int) Option {
+	return func(opts *Options) {
+		opts.MaxIdle = maxIdle
+	}
+}
+
+// WithMinIdleConns keeps n idle connections open in the background, see Options.MinIdleConns.
+func WithMinIdleConns(n int) Option {
+	return func(opts *Options) {
+		opts.MinIdleConns = n
+	}
+}
+
+// WithPoolFIFO makes the pool reuse idle connections in FIFO order, see Options.PoolFIFO.
+func WithPoolFIFO() Option {
+	return func(opts *Options) {
+		opts.PoolFIFO = true
+	}
+}
+
+// WithMultiplexing shares one connection between the concurrent callers of Do, see Options.Multiplex.
+func WithMultiplexing() Option {
+	return func(opts *Options) {
+		opts.Multiplex = true
+	}
+}
+
+// WithMaxRetries retries commands up to n times after a transient failure, see Options.MaxRetries.
+func WithMaxRetries(n int) Option {
+	return func(opts *Options) {
+		opts.MaxRetries = n
+	}
+}
+
+// WithBufferSizes sets the sizes of the read and write buffers of every connection, see Options.ReadBufferSize.
+func WithBufferSizes(readSize int, writeSize int) Option {
+	return func(opts *Options) {
+		opts.ReadBufferSize = readSize
+		opts.WriteBufferSize = writeSize
+	}
+}
+
+// WithRetryBackoff sets the bounds of the backoff between retries, see Options.MinRetryBackoff.
+func WithRetryBackoff(minBackoff time.Duration, maxBackoff time.Duration) Option {
+	return func(opts *Options) {
+		opts.MinRetryBackoff = minBackoff
+		opts.MaxRetryBackoff = maxBackoff
+	}
+}
+
+// WithCircuitBreaker fails fast for cooldown after threshold consecutive failures to reach the server,
+// see Options.CircuitBreakerThreshold.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(opts *Options) {
+		opts.CircuitBreakerThreshold = threshold
+		opts.CircuitBreakerCooldown = cooldown
+	}
+}
+
+// WithErrorArgs includes the arguments of failed commands in their errors, see Options.ErrorArgs.
+func WithErrorArgs() Option {
+	return func(opts *Options) {
+		opts.ErrorArgs = true
+	}
+}
+
+// WithWarmup opens n connections when the client is created, see Options.Warmup.
+func WithWarmup(n int) Option {
+	return func(opts *Options) {
+		opts.Warmup = n
+	}
+}
+
+// WithLazyConnect warms up the pool in the background instead of while creating the client.
+func WithLazyConnect() Option {
+	return func(opts *Options) {
+		opts.LazyConnect = true
+	}
+}
+
+// WithPoolTimeout sets how long to wait for a connection when the pool is exhausted.
+func WithPoolTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.PoolTimeout = timeout
+	}
+}
+
+// WithIdleTimeout sets how long a connection can stay idle in the pool, a negative timeout keeps them open.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.IdleTimeout = timeout
+	}
+}
+
+// WithMaxConnLifetime closes connections once they have been open for longer than lifetime.
+func WithMaxConnLifetime(lifetime time.Duration) Option {
+	return func(opts *Options) {
+		opts.MaxConnLifetime = lifetime
+	}
+}
+
+// WithTestOnBorrow sets the check run on idle connections before they are handed out, see PingIfIdle.
+func WithTestOnBorrow(test func(ctx context.Context, conn IConnection, lastUsed time.Time) error) Option {
+	return func(opts *Options) {
+		opts.TestOnBorrow = test
+	}
+}
+
+// PingIfIdle returns a TestOnBorrow check sending PING on connections idle for longer than idle,
+// so connections dropped by the server or the network while idle are replaced before a command fails on them.
+func PingIfIdle(idle time.Duration) func(ctx context.Context, conn IConnection, lastUsed time.Time) error {
+	return func(ctx context.Context, conn IConnection, lastUsed time.Time) error {
+		if time.Since(lastUsed) < idle {
+			return nil
+		}
+		return conn.Ping(ctx)
+	}
+}
+
+// WithPoolHooks sets the callbacks run on the lifecycle of pooled connections, see PoolHooks.
+func WithPoolHooks(hooks PoolHooks) Option {
+	return func(opts *Options) {
+		opts.PoolHooks = hooks
+	}
+}
+
+// WithClientName registers name with CLIENT SETNAME on every new connection, it must not contain spaces.
+func WithClientName(name string) Option {
+	return func(opts *Options) {
+		opts.ClientName = name
+	}
+}
+
+// WithDialTimeout bounds dialing a connection and its handshake (AUTH, SELECT...), DefaultDialTimeout is used
+// when zero or negative: connections can't be dialed without a timeout. A shorter context deadline wins.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.DialTimeout = timeout
+	}
+}
+
+// WithReadTimeout bounds waiting for a reply when the context has no deadline, the context deadline is used
+// otherwise. DefaultReadTimeout is used when zero, a negative timeout waits for replies as long as it takes.
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.ReadTimeout = timeout
+	}
+}
+
+// WithWriteTimeout bounds writing a command when the context has no deadline, the context deadline is used
+// otherwise. DefaultWriteTimeout is used when zero, a negative timeout waits for writes as long as it takes.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(opts *Options) {
+		opts.WriteTimeout = timeout
+	}
+}
+
+// TLSOptions describes a TLS setup loaded from PEM files, see NewTLSConfig.
+type TLSOptions struct {
+	// CAFile is a CA bundle to verify the server certificate with, the system roots are used when empty
+	CAFile string
+	// CertFile and KeyFile hold the client certificate presented for mutual TLS
+	CertFile string
+	KeyFile  string
+	// ServerName is used for SNI and to verify the server certificate, it defaults to the dialed host
+	ServerName string
+	// InsecureSkipVerify disables server certificate verification, only use it for testing
+	InsecureSkipVerify bool
+}
+
+// NewTLSConfig builds the tls.Config described by opts, as used by managed redis providers requiring TLS.
+func NewTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: can't read CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls: no certificate found in CA file %s", opts.CAFile)
+		}
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, errors.New("tls: both a certificate and a key file are required for client authentication")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: can't load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// ParseURL parses a connection URL into Options:
+//
+//	redis://[[username]:password@]host[:port][/db][?client_name=app&pool_size=10&max_idle=10&min_idle_conns=0&pool_fifo=false&multiplex=false&max_retries=0&min_retry_backoff=8ms&max_retry_backoff=512ms&circuit_breaker_threshold=0&circuit_breaker_cooldown=5s&warmup=1&lazy_connect=false&pool_timeout=5s&idle_timeout=5m&max_conn_lifetime=1h&dial_timeout=5s&read_timeout=5s&write_timeout=5s&read_buffer_size=4096&write_buffer_size=4096]
+//	rediss://[[username]:password@]host[:port][/db][?...]  (TLS)
+//	unix://[[username]:password@]/path/to/redis.sock[?db=0&...]
+//
+// The port defaults to 6379. Unknown query parameters are rejected rather than silently ignored.
+func ParseURL(rawURL string) (*Options, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parseURL: %w", err)
+	}
+
+	opts := &Options{}
+	switch u.Scheme {
+	case "redis", "rediss":
+		host, port := u.Hostname(), u.Port()
+		if host == "" {
+			host = "localhost"
+		}
+		if port == "" {
+			port = "6379"
+		}
+		opts.Address = net.JoinHostPort(host, port)
+		if u.Scheme == "rediss" {
+			opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+			if opts.DB, err = strconv.Atoi(path); err != nil {
+				return nil, fmt.Errorf("parseURL: invalid database %q", path)
+			}
+		}
+	case "unix":
+		if u.Path == "" {
+			return nil, errors.New("parseURL: missing unix socket path")
+		}
+		opts.Network = "unix"
+		opts.Address = u.Path
+	default:
+		return nil, fmt.Errorf("parseURL: unsupported scheme %q", u.Scheme)
+	}
+
+	if u.User != nil {
+		// AUTH default <password> is the same as AUTH <password>, which servers older than 6 accept as well
+		if username := u.User.Username(); username != "default" {
+			opts.Username = username
+		}
+		opts.Password, _ = u.User.Password()
+	}
+
+	for name, values := range u.Query() {
+		value := values[len(values)-1]
+		switch name {
+		case "db":
+			if opts.DB, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("parseURL: invalid database %q", value)
+			}
+		case "client_name":
+			opts.ClientName = value
+		case "pool_size", "max_idle", "min_idle_conns", "warmup", "max_retries", "circuit_breaker_threshold",
+			"read_buffer_size", "write_buffer_size":
+			size, err := strconv.Atoi(value)
+			if err != nil || size < 0 {
+				return nil, fmt.Errorf("parseURL: invalid %s %q", name, value)
+			}
+			switch name {
+			case "pool_size":
+				opts.MaxActive = size
+			case "max_idle":
+				opts.MaxIdle = size
+			case "min_idle_conns":
+				opts.MinIdleConns = size
+			case "max_retries":
+				opts.MaxRetries = size
+			case "circuit_breaker_threshold":
+				opts.CircuitBreakerThreshold = size
+			case "read_buffer_size":
+				opts.ReadBufferSize = size
+			case "write_buffer_size":
+				opts.WriteBufferSize = size
+			default:
+				opts.Warmup = size
+			}
+		case "lazy_connect", "pool_fifo", "multiplex":
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("parseURL: invalid %s %q", name, value)
+			}
+			switch name {
+			case "lazy_connect":
+				opts.LazyConnect = enabled
+			case "pool_fifo":
+				opts.PoolFIFO = enabled
+			default:
+				opts.Multiplex = enabled
+			}
+		case "dial_timeout", "read_timeout", "write_timeout", "pool_timeout", "idle_timeout", "max_conn_lifetime",
+			"min_retry_backoff", "max_retry_backoff", "circuit_breaker_cooldown":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("parseURL: invalid %s %q", name, value)
+			}
+			switch name {
+			case "dial_timeout":
+				opts.DialTimeout = timeout
+			case "read_timeout":
+				opts.ReadTimeout = timeout
+			case "pool_timeout":
+				opts.PoolTimeout = timeout
+			case "idle_timeout":
+				opts.IdleTimeout = timeout
+			case "max_conn_lifetime":
+				opts.MaxConnLifetime = timeout
+			case "min_retry_backoff":
+				opts.MinRetryBackoff = timeout
+			case "max_retry_backoff":
+				opts.MaxRetryBackoff = timeout
+			case "circuit_breaker_cooldown":
+				opts.CircuitBreakerCooldown = timeout
+			default:
+				opts.WriteTimeout = timeout
+			}
+		default:
+			return nil, fmt.Errorf("parseURL: unsupported parameter %q", name)
+		}
+	}
+
+	return opts, nil
+}
+
+// credentials returns the credentials to authenticate a new connection with.
+func (opts *Options) credentials(ctx context.Context) (string, string, error) {
+	if opts.CredentialsProvider == nil {
+		return opts.Username, opts.Password, nil
+	}
+	username, password, err := opts.CredentialsProvider.Credentials(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("credentials: %w", err)
+	}
+	return username, password, nil
+}
+
+// withDefaults returns a copy of opts with the unset settings filled in and the dialer resolved.
+func (opts *Options) withDefaults() *Options {
+	resolved := *opts
+	if resolved.Network == "" {
+		resolved.Network = "tcp"
+	}
+	if resolved.DialTimeout <= 0 {
+		resolved.DialTimeout = DefaultDialTimeout
+	}
+	if resolved.MaxActive <= 0 {
+		resolved.MaxActive = DefaultPoolSize
+	}
+	if resolved.MaxIdle <= 0 || resolved.MaxIdle > resolved.MaxActive {
+		resolved.MaxIdle = resolved.MaxActive
+	}
+	if resolved.MinIdleConns > resolved.MaxIdle {
+		resolved.MinIdleConns = resolved.MaxIdle
+	}
+	if resolved.Warmup <= 0 {
+		resolved.Warmup = 1
+	}
+	if resolved.PoolTimeout <= 0 {
+		resolved.PoolTimeout = DefaultPoolTimeout
+	}
+	if resolved.IdleTimeout == 0 {
+		resolved.IdleTimeout = DefaultIdleTimeout
+	}
+	if resolved.MinRetryBackoff <= 0 {
+		resolved.MinRetryBackoff = DefaultMinRetryBackoff
+	}
+	if resolved.MaxRetryBackoff <= 0 {
+		resolved.MaxRetryBackoff = DefaultMaxRetryBackoff
+	}
+	if resolved.MaxRetryBackoff < resolved.MinRetryBackoff {
+		resolved.MaxRetryBackoff = resolved.MinRetryBackoff
+	}
+	if resolved.CircuitBreakerCooldown <= 0 {
+		resolved.CircuitBreakerCooldown = DefaultCircuitBreakerCooldown
+	}
+	resolved.Dialer = opts.dialer()
+	return &resolved
+}
+
+func (opts *Options) dialer() IDialer {
+	if opts.Dialer != nil {
+		return opts.Dialer
+	}
+	return &Dialer{TLSConfig: opts.TLSConfig, Proxy: opts.Proxy}
+}
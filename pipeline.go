@@ -0,0 +1,79 @@
+package resp
+
+import (
+	"context"
+	"errors"
+)
+
+// Pipeline queues commands and sends them to the server in a single write on Exec,
+// saving a round trip per command. A Pipeline is not safe for concurrent use.
+type Pipeline struct {
+	client *Client
+	cmds   [][]interface{}
+}
+
+func (client *Client) Pipeline() *Pipeline {
+	return &Pipeline{client: client}
+}
+
+// Do queues a command, see Client.Do for the accepted arguments.
+func (p *Pipeline) Do(args ...interface{}) {
+	p.cmds = append(p.cmds, args)
+}
+
+// Len returns the number of queued commands.
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+// Exec sends the queued commands and reads their replies in order, then empties the queue.
+// A command failing on the server doesn't abort the pipeline: its reply holds the error (see Reply.Err)
+// and the first such error is also returned.
+func (p *Pipeline) Exec(ctx context.Context) ([]*Reply, error) {
+	cmds := p.cmds
+	p.cmds = nil
+	if len(cmds) == 0 {
+		return nil, nil
+	}
+
+	conn, err := p.client.getConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := execPipeline(ctx, conn, cmds)
+	p.client.releaseConn(conn, err)
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		if err := reply.Err(); err != nil {
+			return replies, err
+		}
+	}
+	return replies, nil
+}
+
+func execPipeline(ctx context.Context, conn IConnection, cmds [][]interface{}) ([]*Reply, error) {
+	for _, args := range cmds {
+		if err := conn.WriteCommand(ctx, args...); err != nil {
+			return nil, err
+		}
+	}
+	if err := conn.Flush(ctx); err != nil {
+		return nil, err
+	}
+
+	replies := make([]*Reply, len(cmds))
+	for i := range cmds {
+		value, err := conn.ReceiveValue(ctx)
+		var replyErr replyError
+		if errors.As(err, &replyErr) {
+			// keep reading, the remaining replies are still on the connection
+			value = replyErr
+		} else if err != nil {
+			return nil, err
+		}
+		replies[i] = NewReply(value)
+	}
+	return replies, nil
+}
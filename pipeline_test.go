@@ -0,0 +1,98 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPipeline_Exec(t *testing.T) {
+	t.Run("replies in order", func(t *testing.T) {
+		var sent []string
+		SendFunc = func(command string) error {
+			sent = append(sent, command)
+			return nil
+		}
+		ReceiveFunc = replySequence("OK", int64(2), nil)
+		client := newMockClient(2, "password")
+
+		pipe := client.Pipeline()
+		pipe.Do("SET", "key", "value")
+		pipe.Do("INCR", "counter")
+		pipe.Do("GET", "missing")
+		if pipe.Len() != 3 {
+			t.Fatalf("Len() got = %d, want 3", pipe.Len())
+		}
+
+		results, err := pipe.Exec(context.Background())
+		if err != nil {
+			t.Fatalf("Exec returned error: %s", err)
+		}
+		if len(sent) != 3 || len(results) != 3 {
+			t.Fatalf("Exec sent %d commands and got %d replies, want 3", len(sent), len(results))
+		}
+		if text, _ := results[0].Text(); text != "OK" {
+			t.Errorf("reply 0 got = %v", results[0].Value())
+		}
+		if n, _ := results[1].Int(); n != 2 {
+			t.Errorf("reply 1 got = %v", results[1].Value())
+		}
+		if !results[2].IsNil() {
+			t.Errorf("reply 2 got = %v, want nil", results[2].Value())
+		}
+		if pipe.Len() != 0 {
+			t.Errorf("Exec did not empty the queue")
+		}
+	})
+
+	t.Run("command error doesn't abort the pipeline", func(t *testing.T) {
+		SendFunc = func(command string) error {
+			return nil
+		}
+		ReceiveFunc = replySequence(RedisError("WRONGTYPE Operation against a key"), int64(1))
+		client := newMockClient(2, "password")
+
+		pipe := client.Pipeline()
+		pipe.Do("INCR", "list")
+		pipe.Do("INCR", "counter")
+		results, err := pipe.Exec(context.Background())
+		if err == nil {
+			t.Fatal("Exec expected the command error")
+		}
+		if len(results) != 2 || results[0].Err() == nil {
+			t.Fatalf("Exec got = %v, want error reply first", results)
+		}
+		if n, _ := results[1].Int(); n != 1 {
+			t.Errorf("reply 1 got = %v", results[1].Value())
+		}
+	})
+}
+
+func TestPipeline_ExecCancel(t *testing.T) {
+	client, servers := newPipeClient("")
+	client.pool = newPool(client.newConn, &Options{MaxActive: 1, MaxIdle: 1, PoolTimeout: DefaultPoolTimeout})
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		server := <-servers
+		// both commands are read, only the first one is answered before the caller gives up
+		for i := 0; i < 2; i++ {
+			if _, err := server.ReceiveValue(context.Background()); err != nil {
+				t.Errorf("server failed to read the pipeline: %s", err)
+			}
+		}
+		_, _ = server.rw.WriteString("+OK\r\n")
+		_ = server.rw.Flush()
+		cancel()
+	}()
+	pipe := client.Pipeline()
+	pipe.Do("SET", "key", "value")
+	pipe.Do("GET", "slow")
+	if _, err := pipe.Exec(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Exec() error = %v, want %v", err, context.Canceled)
+	}
+	if stats := client.PoolStats(); stats.IdleConns != 0 || stats.ActiveConns != 0 {
+		t.Errorf("PoolStats() got %+v, want the interrupted connection closed", stats)
+	}
+}
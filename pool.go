@@ -0,0 +1,573 @@
+package resp
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrClosed is returned when a command is run on a closed client.
+	ErrClosed = errors.New("client is closed")
+	// ErrPoolExhausted is returned when no connection was released within the pool timeout
+	// (or before the context deadline) while MaxActive connections were in use. It is wrapped in a timeout
+	// error (net.Error, context.DeadlineExceeded), check for it with errors.Is.
+	ErrPoolExhausted = errors.New("connection pool exhausted")
+)
+
+// WarmupError reports the connections that couldn't be opened while warming up the pool, see Client.Warmup.
+type WarmupError struct {
+	Requested int
+	Errors    []error
+}
+
+func (e *WarmupError) Error() string {
+	return fmt.Sprintf("warmup: %d of %d connections failed, first error: %v", len(e.Errors), e.Requested, e.Errors[0])
+}
+
+func (e *WarmupError) Unwrap() []error {
+	return e.Errors
+}
+
+const (
+	// DefaultPoolSize is the number of connections a client opens at most when no pool size is configured.
+	DefaultPoolSize = 10
+	// DefaultPoolTimeout is how long a caller waits for a connection when the pool is exhausted.
+	DefaultPoolTimeout = 5 * time.Second
+	// DefaultIdleTimeout is how long a connection stays idle before it is closed, below the idle
+	// timeout of most load balancers.
+	DefaultIdleTimeout = 5 * time.Minute
+)
+
+// maxReapInterval bounds how long stale idle connections stay open before the reaper closes them.
+const maxReapInterval = time.Minute
+
+// pool hands out connections for exclusive use, so commands and their replies from concurrent callers
+// (or a pipeline) don't interleave. At most maxActive connections are in use at once, callers wait up to
+// waitTimeout for one to be released past that, in arrival order. Released connections are kept for reuse, up to maxIdle of them.
+type pool struct {
+	dial        func(ctx context.Context) (IConnection, error)
+	turns       turnQueue // a turn for every connection in use
+	maxIdle     int
+	minIdle     int // idle connections the maintainer keeps open, dialing replacements as they are used or closed
+	waitTimeout time.Duration
+	idleTimeout time.Duration // 0 keeps idle connections forever
+	maxLifetime time.Duration // 0 keeps connections forever
+	fifo        bool          // reuse the least recently used idle connection instead of the most recent one
+	// testOnBorrow checks an idle connection before it is handed out, it is discarded when an error is returned
+	testOnBorrow func(ctx context.Context, conn IConnection, lastUsed time.Time) error
+	hooks        PoolHooks
+	done         chan struct{} // stops the reaper and the maintainer
+	refill       chan struct{} // wakes up the maintainer
+
+	stats poolCounters
+
+	// The counters are updated without holding mu, which only guards the idle connections,
+	// so dials and closes don't hold up the borrowers and releasers of idle connections.
+	open   atomic.Int32  // idle and in use
+	gen    atomic.Uint64 // connections from an older generation are closed instead of reused, see retire
+	closed atomic.Bool   // only set with mu held, so no connection is made idle after close
+
+	mu   sync.Mutex
+	idle []*poolConn // most recently used last
+}
+
+// PoolStats describes the connection pool of a client, see Client.PoolStats.
+// The counters are cumulative since the client was created.
+type PoolStats struct {
+	Hits         uint64        // connections handed out from the idle ones
+	Misses       uint64        // connections dialed because none was idle
+	Waits        uint64        // borrows that had to wait for a connection to be released
+	WaitDuration time.Duration // total time spent waiting
+	Timeouts     uint64        // waits that ended with ErrPoolExhausted
+	StaleConns   uint64        // idle connections closed for the idle timeout, max lifetime or a failed borrow test
+	DialErrors   uint64        // dials that failed
+	IdleConns    int           // connections currently idle
+	ActiveConns  int           // connections currently in use
+	CircuitOpen  bool          // the circuit breaker of the client is open, see Options.CircuitBreakerThreshold
+}
+
+type poolCounters struct {
+	hits, misses, waits, timeouts, stale, dialErrors atomic.Uint64
+	waitDuration                                     atomic.Int64
+}
+
+// poolConn is a connection owned by a pool.
+type poolConn struct {
+	IConnection
+	gen       uint64
+	createdAt time.Time
+	usedAt    time.Time // when it was last put back
+}
+
+// newPool returns a pool dialing connections with dial, sized by opts (with the defaults filled in).
+// A reaper closes the stale idle connections in the background when opts has an idle timeout or a max lifetime,
+// and a maintainer keeps MinIdleConns idle connections open when it is set.
+func newPool(dial func(ctx context.Context) (IConnection, error), opts *Options) *pool {
+	p := &pool{
+		dial:        dial,
+		turns:       turnQueue{size: opts.MaxActive},
+		maxIdle:     opts.MaxIdle,
+		minIdle:     opts.MinIdleConns,
+		waitTimeout: opts.PoolTimeout,
+		fifo:        opts.PoolFIFO,
+		done:        make(chan struct{}),
+		refill:      make(chan struct{}, 1),
+
+		testOnBorrow: opts.TestOnBorrow,
+		hooks:        opts.PoolHooks,
+	}
+	if opts.IdleTimeout > 0 {
+		p.idleTimeout = opts.IdleTimeout
+	}
+	if opts.MaxConnLifetime > 0 {
+		p.maxLifetime = opts.MaxConnLifetime
+	}
+
+	interval := maxReapInterval
+	for _, timeout := range []time.Duration{p.idleTimeout, p.maxLifetime} {
+		if timeout > 0 && timeout/2 < interval {
+			interval = timeout / 2
+		}
+	}
+	if p.idleTimeout > 0 || p.maxLifetime > 0 {
+		go p.reaper(interval)
+	}
+	if p.minIdle > 0 {
+		p.refillIdle()
+		go p.maintainer()
+	}
+	return p
+}
+
+// get returns an idle connection, or dials a new one when none is idle. It waits while maxActive
+// connections are in use, until one is released, ctx is done or the wait timeout passes (ErrPoolExhausted).
+// Idle connections that are stale or fail testOnBorrow are closed and skipped.
+// The connection must be given back with put.
+func (p *pool) get(ctx context.Context) (IConnection, error) {
+	return p.borrow(ctx, true)
+}
+
+// getNew is like get but always dials a new connection, for when the idle ones may be broken.
+func (p *pool) getNew(ctx context.Context) (IConnection, error) {
+	return p.borrow(ctx, false)
+}
+
+func (p *pool) borrow(ctx context.Context, reuseIdle bool) (IConnection, error) {
+	if err := p.waitTurn(ctx); err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.closed.Load() {
+			p.turns.release()
+			return nil, ErrClosed
+		}
+		if !reuseIdle {
+			break
+		}
+		p.mu.Lock()
+		conn := p.popIdle()
+		p.mu.Unlock()
+		if conn == nil {
+			break
+		}
+		if p.isStale(conn, time.Now()) {
+			// the reaper didn't get to it yet
+			p.stats.stale.Add(1)
+			p.discard(conn)
+			continue
+		}
+
+		if p.testOnBorrow != nil {
+			if err := p.testOnBorrow(ctx, conn.IConnection, conn.usedAt); err != nil {
+				p.stats.stale.Add(1)
+				p.discard(conn)
+				continue
+			}
+		}
+		p.stats.hits.Add(1)
+		p.refillIdle()
+		if p.hooks.OnBorrow != nil {
+			p.hooks.OnBorrow(conn.IConnection)
+		}
+		return conn, nil
+	}
+
+	p.open.Add(1)
+	gen := p.gen.Load()
+	p.refillIdle()
+
+	p.stats.misses.Add(1)
+	conn, err := p.connect(ctx)
+	if err != nil {
+		p.open.Add(-1)
+		p.turns.release()
+		return nil, err
+	}
+	if p.hooks.OnBorrow != nil {
+		p.hooks.OnBorrow(conn)
+	}
+	return &poolConn{IConnection: conn, gen: gen, createdAt: time.Now()}, nil
+}
+
+// connect dials a new connection and runs the OnConnect hook on it.
+func (p *pool) connect(ctx context.Context) (IConnection, error) {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		p.stats.dialErrors.Add(1)
+		return nil, err
+	}
+	if p.hooks.OnConnect != nil {
+		if err := p.hooks.OnConnect(ctx, conn); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("onConnect: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// popIdle takes the next idle connection to reuse out of the pool, or returns nil when none is idle.
+// p.mu must be held.
+func (p *pool) popIdle() *poolConn {
+	if len(p.idle) == 0 {
+		return nil
+	}
+	if p.fifo {
+		conn := p.idle[0]
+		p.idle[0] = nil
+		p.idle = p.idle[1:]
+		return conn
+	}
+	conn := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	return conn
+}
+
+// waitTurn takes a turn to use a connection, borrowers waiting for one are served in arrival order.
+func (p *pool) waitTurn(ctx context.Context) error {
+	if p.turns.tryAcquire() {
+		return nil
+	}
+
+	p.stats.waits.Add(1)
+	start := time.Now()
+	defer func() { p.stats.waitDuration.Add(int64(time.Since(start))) }()
+
+	waiter := p.turns.enqueue()
+	timer := time.NewTimer(p.waitTimeout)
+	defer timer.Stop()
+	var err error
+	select {
+	case <-waiter.Value.(chan struct{}):
+		return nil
+	case <-timer.C:
+		p.stats.timeouts.Add(1)
+		err = &timeoutError{err: ErrPoolExhausted}
+	case <-ctx.Done():
+		err = ctx.Err()
+		if errors.Is(err, context.DeadlineExceeded) {
+			p.stats.timeouts.Add(1)
+			err = fmt.Errorf("%w: %w", ErrPoolExhausted, err)
+		}
+	}
+	if !p.turns.cancel(waiter) {
+		// the turn was handed over in the meantime, pass it on
+		p.turns.release()
+	}
+	return err
+}
+
+// turnQueue is a semaphore handing out turns in FIFO order: a released turn goes to the borrower
+// waiting the longest, so no borrower is starved by newcomers under load.
+type turnQueue struct {
+	size int
+
+	mu      sync.Mutex
+	inUse   int
+	waiters list.List // a chan struct{} per waiting borrower, closed when the turn is handed over
+}
+
+// tryAcquire takes a turn when one is free and nobody is waiting for it.
+func (q *turnQueue) tryAcquire() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.inUse < q.size && q.waiters.Len() == 0 {
+		q.inUse++
+		return true
+	}
+	return false
+}
+
+// enqueue adds a waiter, its channel is closed once it was handed a turn.
+func (q *turnQueue) enqueue() *list.Element {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiters.PushBack(make(chan struct{}))
+}
+
+// cancel removes a waiter that gave up, it returns false when the waiter was handed a turn already.
+func (q *turnQueue) cancel(waiter *list.Element) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-waiter.Value.(chan struct{}):
+		return false
+	default:
+	}
+	q.waiters.Remove(waiter)
+	return true
+}
+
+// release gives back a turn, handing it over to the first waiter if any.
+func (q *turnQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if first := q.waiters.Front(); first != nil {
+		q.waiters.Remove(first)
+		close(first.Value.(chan struct{}))
+		return
+	}
+	q.inUse--
+}
+
+// warmup opens n connections concurrently and leaves them idle, n must not exceed maxIdle.
+// It returns the errors of the dials that failed.
+func (p *pool) warmup(ctx context.Context, n int) []error {
+	conns := make([]IConnection, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conns[i], errs[i] = p.get(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	// Put the connections back once they are all open, so the dials didn't reuse each other's
+	var failed []error
+	for i, conn := range conns {
+		if errs[i] != nil {
+			failed = append(failed, errs[i])
+			continue
+		}
+		p.put(conn, nil)
+	}
+	return failed
+}
+
+// put gives back a connection returned by get; err is the error the connection was last used with.
+// A connection that failed with anything but an error reply (I/O error, timeout...) may hold a partial
+// command or unread replies, so it is closed rather than reused.
+func (p *pool) put(conn IConnection, err error) {
+	pc := conn.(*poolConn)
+	if p.hooks.OnReturn != nil {
+		p.hooks.OnReturn(pc.IConnection, err)
+	}
+	broken := err != nil && !isReplyError(err)
+
+	pc.usedAt = time.Now()
+	stale := p.isStale(pc, pc.usedAt)
+	if stale {
+		p.stats.stale.Add(1)
+	}
+	reuse := !broken && !stale
+	if reuse {
+		p.mu.Lock()
+		// gen and closed are checked with mu held, retire and close change them with mu held too
+		reuse = !p.closed.Load() && pc.gen == p.gen.Load() && len(p.idle) < p.maxIdle
+		if reuse {
+			p.idle = append(p.idle, pc)
+		}
+		p.mu.Unlock()
+	}
+	if !reuse {
+		p.discard(pc)
+		p.refillIdle()
+	}
+	p.turns.release()
+}
+
+// discard closes a connection taken out of the pool.
+func (p *pool) discard(conn *poolConn) {
+	p.open.Add(-1)
+	p.closeConn(conn)
+}
+
+// retire closes the idle connections and makes the ones in use close when they are put back,
+// so every connection handed out afterwards is a new one.
+func (p *pool) retire() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.gen.Add(1)
+	p.mu.Unlock()
+	p.open.Add(-int32(len(idle)))
+
+	p.closeAll(idle)
+	p.refillIdle()
+}
+
+// isStale reports whether conn has been idle longer than the idle timeout or open longer than the max lifetime.
+func (p *pool) isStale(conn *poolConn, now time.Time) bool {
+	return (p.idleTimeout > 0 && now.Sub(conn.usedAt) >= p.idleTimeout) ||
+		(p.maxLifetime > 0 && now.Sub(conn.createdAt) >= p.maxLifetime)
+}
+
+func (p *pool) reaper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.reap()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// reap closes the stale idle connections.
+func (p *pool) reap() {
+	now := time.Now()
+	p.mu.Lock()
+	var stale []*poolConn
+	fresh := p.idle[:0]
+	for _, conn := range p.idle {
+		if p.isStale(conn, now) {
+			stale = append(stale, conn)
+		} else {
+			fresh = append(fresh, conn)
+		}
+	}
+	p.idle = fresh
+	p.mu.Unlock()
+	p.open.Add(-int32(len(stale)))
+	p.stats.stale.Add(uint64(len(stale)))
+
+	p.closeAll(stale)
+	if len(stale) > 0 {
+		p.refillIdle()
+	}
+}
+
+// refillIdle wakes up the maintainer, if any, to check for missing idle connections.
+func (p *pool) refillIdle() {
+	if p.minIdle <= 0 {
+		return
+	}
+	select {
+	case p.refill <- struct{}{}:
+	default:
+	}
+}
+
+// maintainer dials idle connections up to minIdle whenever refillIdle is called. Dials that failed are
+// retried on the next call, or after maxReapInterval.
+func (p *pool) maintainer() {
+	ticker := time.NewTicker(maxReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.refill:
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+		p.fillIdle()
+	}
+}
+
+// fillIdle dials connections and leaves them idle until there are minIdle idle ones,
+// without opening more than maxActive connections in total.
+func (p *pool) fillIdle() {
+	for {
+		p.mu.Lock()
+		missing := len(p.idle) < p.minIdle
+		p.mu.Unlock()
+		if p.closed.Load() || !missing {
+			return
+		}
+		if p.open.Add(1) > int32(p.turns.size) {
+			p.open.Add(-1)
+			return
+		}
+		gen := p.gen.Load()
+
+		conn, err := p.connect(context.Background())
+		if err != nil {
+			p.open.Add(-1)
+			return
+		}
+		now := time.Now()
+		pc := &poolConn{IConnection: conn, gen: gen, createdAt: now, usedAt: now}
+		p.mu.Lock()
+		reuse := !p.closed.Load() && gen == p.gen.Load()
+		if reuse {
+			p.idle = append(p.idle, pc)
+		}
+		p.mu.Unlock()
+		if !reuse {
+			p.discard(pc)
+		}
+	}
+}
+
+func (p *pool) statsSnapshot() PoolStats {
+	p.mu.Lock()
+	idle := len(p.idle)
+	p.mu.Unlock()
+	// open and idle aren't read atomically together, connections in use are only ever overestimated
+	// by the ones on their way in or out of the idle list
+	active := max(int(p.open.Load())-idle, 0)
+
+	return PoolStats{
+		Hits:         p.stats.hits.Load(),
+		Misses:       p.stats.misses.Load(),
+		Waits:        p.stats.waits.Load(),
+		WaitDuration: time.Duration(p.stats.waitDuration.Load()),
+		Timeouts:     p.stats.timeouts.Load(),
+		StaleConns:   p.stats.stale.Load(),
+		DialErrors:   p.stats.dialErrors.Load(),
+		IdleConns:    idle,
+		ActiveConns:  active,
+	}
+}
+
+func (p *pool) closeAll(conns []*poolConn) {
+	for _, conn := range conns {
+		p.closeConn(conn)
+	}
+}
+
+// closeConn closes a connection the pool is done with and runs the OnClose hook on it.
+func (p *pool) closeConn(conn IConnection) {
+	if pc, ok := conn.(*poolConn); ok {
+		conn = pc.IConnection
+	}
+	_ = conn.Close()
+	if p.hooks.OnClose != nil {
+		p.hooks.OnClose(conn)
+	}
+}
+
+// close closes the idle connections, the ones in use are closed when they are put back.
+func (p *pool) close() error {
+	p.mu.Lock()
+	if p.closed.Load() {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed.Store(true)
+	p.mu.Unlock()
+
+	close(p.done)
+	p.retire()
+	return nil
+}
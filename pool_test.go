@@ -0,0 +1,463 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCountingPool returns a pool of mock connections and the number of connections it dialed.
+func newCountingPool(maxActive int, maxIdle int) (*pool, *atomic.Int32) {
+	dialed := new(atomic.Int32)
+	opts := &Options{MaxActive: maxActive, MaxIdle: maxIdle}
+	p := newPool(func(ctx context.Context) (IConnection, error) {
+		dialed.Add(1)
+		return &mockConnection{}, nil
+	}, opts.withDefaults())
+	return p, dialed
+}
+
+func TestPool_Reuse(t *testing.T) {
+	CloseFunc = func() error { return nil }
+	p, dialed := newCountingPool(2, 2)
+
+	conn, err := p.get(context.Background())
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	p.put(conn, nil)
+	reused, _ := p.get(context.Background())
+	if reused != conn || dialed.Load() != 1 {
+		t.Errorf("get() dialed %d connections, want the idle one reused", dialed.Load())
+	}
+
+	// an error reply leaves the connection usable, an I/O error doesn't
+	p.put(reused, RedisError("ERR wrong number of arguments"))
+	reused, _ = p.get(context.Background())
+	if reused != conn {
+		t.Errorf("get() did not reuse a connection released with an error reply")
+	}
+	p.put(reused, io.ErrUnexpectedEOF)
+	if fresh, _ := p.get(context.Background()); fresh == conn || dialed.Load() != 2 {
+		t.Errorf("get() reused a broken connection")
+	}
+}
+
+func TestPool_MaxActive(t *testing.T) {
+	CloseFunc = func() error { return nil }
+	p, dialed := newCountingPool(2, 1)
+
+	first, _ := p.get(context.Background())
+	second, _ := p.get(context.Background())
+
+	// the pool is exhausted: get waits until a connection is released
+	got := make(chan IConnection)
+	go func() {
+		conn, err := p.get(context.Background())
+		if err != nil {
+			t.Errorf("get() error = %v", err)
+		}
+		got <- conn
+	}()
+	select {
+	case <-got:
+		t.Fatal("get() returned while maxActive connections are in use")
+	case <-time.After(20 * time.Millisecond):
+	}
+	p.put(first, nil)
+	if conn := <-got; conn != first {
+		t.Errorf("get() did not hand out the released connection")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.get(ctx); !errors.Is(err, ErrPoolExhausted) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("get() error = %v, want %v", err, ErrPoolExhausted)
+	}
+	p.waitTimeout = 20 * time.Millisecond
+	_, err := p.get(context.Background())
+	var netErr net.Error
+	if !errors.Is(err, ErrPoolExhausted) || !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("get() error = %v, want a %v timeout", err, ErrPoolExhausted)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.get(canceled); !errors.Is(err, context.Canceled) || errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("get() error = %v, want %v", err, context.Canceled)
+	}
+
+	// only maxIdle connections are kept
+	p.put(first, nil)
+	p.put(second, nil)
+	if len(p.idle) != 1 || p.open.Load() != 1 || dialed.Load() != 2 {
+		t.Errorf("pool has %d idle, %d open connections, want 1 and 1", len(p.idle), p.open.Load())
+	}
+}
+
+func TestPool_RetireAndClose(t *testing.T) {
+	var closed atomic.Int32
+	CloseFunc = func() error {
+		closed.Add(1)
+		return nil
+	}
+	p, _ := newCountingPool(2, 2)
+
+	idle, _ := p.get(context.Background())
+	inUse, _ := p.get(context.Background())
+	p.put(idle, nil)
+
+	p.retire()
+	if closed.Load() != 1 {
+		t.Errorf("retire() closed %d connections, want the idle one", closed.Load())
+	}
+	p.put(inUse, nil)
+	if closed.Load() != 2 || len(p.idle) != 0 {
+		t.Errorf("a connection from before retire() was kept")
+	}
+
+	conn, _ := p.get(context.Background())
+	p.put(conn, nil)
+	if err := p.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+	if closed.Load() != 3 || p.open.Load() != 0 {
+		t.Errorf("close() left %d connections open", p.open.Load())
+	}
+	if _, err := p.get(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("get() error = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestPool_Stale(t *testing.T) {
+	var closed atomic.Int32
+	CloseFunc = func() error {
+		closed.Add(1)
+		return nil
+	}
+
+	t.Run("idle timeout", func(t *testing.T) {
+		closed.Store(0)
+		p, dialed := newCountingPool(2, 2)
+		p.idleTimeout = 10 * time.Millisecond
+		conn, _ := p.get(context.Background())
+		p.put(conn, nil)
+
+		time.Sleep(20 * time.Millisecond)
+		fresh, _ := p.get(context.Background())
+		if fresh == conn || dialed.Load() != 2 || closed.Load() != 1 {
+			t.Errorf("get() handed out a connection idle for longer than the idle timeout")
+		}
+	})
+
+	t.Run("max lifetime", func(t *testing.T) {
+		closed.Store(0)
+		p, _ := newCountingPool(2, 2)
+		p.maxLifetime = 10 * time.Millisecond
+		conn, _ := p.get(context.Background())
+		time.Sleep(20 * time.Millisecond)
+		p.put(conn, nil)
+		if len(p.idle) != 0 || closed.Load() != 1 {
+			t.Errorf("put() kept a connection older than the max lifetime")
+		}
+	})
+
+	t.Run("reaper", func(t *testing.T) {
+		closed.Store(0)
+		opts := &Options{MaxActive: 2, IdleTimeout: 10 * time.Millisecond}
+		p := newPool(func(ctx context.Context) (IConnection, error) {
+			return &mockConnection{}, nil
+		}, opts.withDefaults())
+		defer p.close()
+		first, _ := p.get(context.Background())
+		second, _ := p.get(context.Background())
+		p.put(first, nil)
+		p.put(second, nil)
+
+		deadline := time.Now().Add(time.Second)
+		for closed.Load() != 2 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if len(p.idle) != 0 || p.open.Load() != 0 {
+			t.Errorf("the reaper left %d idle connections", len(p.idle))
+		}
+	})
+}
+
+func TestPool_TestOnBorrow(t *testing.T) {
+	CloseFunc = func() error { return nil }
+	p, dialed := newCountingPool(2, 2)
+	var tested []time.Time
+	p.testOnBorrow = func(ctx context.Context, conn IConnection, lastUsed time.Time) error {
+		tested = append(tested, lastUsed)
+		return io.EOF // the server dropped the connection
+	}
+
+	conn, _ := p.get(context.Background())
+	p.put(conn, nil)
+	fresh, err := p.get(context.Background())
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	if fresh == conn || dialed.Load() != 2 || len(tested) != 1 || !tested[0].Equal(conn.(*poolConn).usedAt) {
+		t.Errorf("get() handed out a connection failing the borrow test")
+	}
+	if p.open.Load() != 1 {
+		t.Errorf("pool has %d open connections, want 1", p.open.Load())
+	}
+}
+
+func TestPingIfIdle(t *testing.T) {
+	pinged := false
+	PingFunc = func(ctx context.Context) error {
+		pinged = true
+		return nil
+	}
+	test := PingIfIdle(time.Minute)
+
+	if err := test(context.Background(), &mockConnection{}, time.Now()); err != nil || pinged {
+		t.Errorf("PingIfIdle() pinged a connection used recently")
+	}
+	if err := test(context.Background(), &mockConnection{}, time.Now().Add(-2*time.Minute)); err != nil || !pinged {
+		t.Errorf("PingIfIdle() did not ping an idle connection")
+	}
+}
+
+func TestPool_Stats(t *testing.T) {
+	CloseFunc = func() error { return nil }
+	dialErr := errors.New("connection refused")
+	failDial := false
+	opts := &Options{MaxActive: 1, PoolTimeout: 10 * time.Millisecond}
+	p := newPool(func(ctx context.Context) (IConnection, error) {
+		if failDial {
+			return nil, dialErr
+		}
+		return &mockConnection{}, nil
+	}, opts.withDefaults())
+	defer p.close()
+
+	conn, _ := p.get(context.Background()) // miss
+	p.put(conn, nil)
+	conn, _ = p.get(context.Background()) // hit
+	if _, err := p.get(context.Background()); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("get() error = %v, want %v", err, ErrPoolExhausted)
+	}
+	stats := p.statsSnapshot()
+	if stats.ActiveConns != 1 || stats.IdleConns != 0 {
+		t.Errorf("stats got %d active, %d idle connections, want 1 and 0", stats.ActiveConns, stats.IdleConns)
+	}
+
+	p.maxLifetime = time.Nanosecond
+	p.put(conn, nil) // stale
+	failDial = true
+	if _, err := p.get(context.Background()); !errors.Is(err, dialErr) {
+		t.Fatalf("get() error = %v, want %v", err, dialErr)
+	}
+
+	stats = p.statsSnapshot()
+	want := PoolStats{Hits: 1, Misses: 2, Waits: 1, Timeouts: 1, StaleConns: 1, DialErrors: 1}
+	if stats.WaitDuration < 10*time.Millisecond {
+		t.Errorf("stats got WaitDuration = %v, want at least the pool timeout", stats.WaitDuration)
+	}
+	stats.WaitDuration = 0
+	if stats != want {
+		t.Errorf("stats got = %+v, want %+v", stats, want)
+	}
+}
+
+func TestPool_Order(t *testing.T) {
+	CloseFunc = func() error { return nil }
+	for _, fifo := range []bool{false, true} {
+		p, _ := newCountingPool(2, 2)
+		p.fifo = fifo
+		first, _ := p.get(context.Background())
+		second, _ := p.get(context.Background())
+		p.put(first, nil)
+		p.put(second, nil)
+
+		want := second // LIFO reuses the most recently released connection
+		if fifo {
+			want = first
+		}
+		if got, _ := p.get(context.Background()); got != want {
+			t.Errorf("get() with fifo = %v did not reuse the expected connection", fifo)
+		}
+	}
+}
+
+func TestPool_MinIdleConns(t *testing.T) {
+	CloseFunc = func() error { return nil }
+	dialed := new(atomic.Int32)
+	opts := &Options{MaxActive: 3, MinIdleConns: 2}
+	p := newPool(func(ctx context.Context) (IConnection, error) {
+		dialed.Add(1)
+		return &mockConnection{}, nil
+	}, opts.withDefaults())
+	defer p.close()
+
+	waitIdle := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for p.statsSnapshot().IdleConns != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("pool has %d idle connections, want %d", p.statsSnapshot().IdleConns, want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitIdle(2)
+
+	// a replacement is dialed for the borrowed connection
+	first, _ := p.get(context.Background())
+	waitIdle(2)
+	if got := p.statsSnapshot().Misses; got != 0 {
+		t.Errorf("get() dialed %d connections, want an idle one", got)
+	}
+
+	// but not past MaxActive
+	second, _ := p.get(context.Background())
+	waitIdle(1)
+	if got := dialed.Load(); got != 3 {
+		t.Errorf("pool dialed %d connections, want 3", got)
+	}
+
+	// a broken connection is replaced once there is room for it
+	p.put(first, io.ErrUnexpectedEOF)
+	waitIdle(2)
+	p.put(second, nil)
+	waitIdle(3)
+	if got := dialed.Load(); got != 4 {
+		t.Errorf("pool dialed %d connections, want 4", got)
+	}
+}
+
+func TestPool_Hooks(t *testing.T) {
+	CloseFunc = func() error { return nil }
+	var events []string
+	setupErr := errors.New("CLIENT TRACKING failed")
+	var failSetup bool
+	opts := &Options{MaxActive: 2, PoolHooks: PoolHooks{
+		OnConnect: func(ctx context.Context, conn IConnection) error {
+			events = append(events, "connect")
+			if failSetup {
+				return setupErr
+			}
+			return nil
+		},
+		OnBorrow: func(conn IConnection) { events = append(events, "borrow") },
+		OnReturn: func(conn IConnection, err error) { events = append(events, "return") },
+		OnClose:  func(conn IConnection) { events = append(events, "close") },
+	}}
+	p := newPool(func(ctx context.Context) (IConnection, error) {
+		return &mockConnection{}, nil
+	}, opts.withDefaults())
+
+	conn, _ := p.get(context.Background())
+	if _, ok := conn.(*poolConn).IConnection.(*mockConnection); !ok {
+		t.Fatalf("get() returned %T", conn)
+	}
+	p.put(conn, nil)
+	conn, _ = p.get(context.Background())
+	p.put(conn, io.ErrUnexpectedEOF)
+
+	failSetup = true
+	if _, err := p.get(context.Background()); !errors.Is(err, setupErr) {
+		t.Errorf("get() error = %v, want %v", err, setupErr)
+	}
+	failSetup = false
+	conn, _ = p.get(context.Background())
+	p.put(conn, nil)
+	_ = p.close()
+
+	want := "connect borrow return borrow return close connect connect borrow return close"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("hooks got %q, want %q", got, want)
+	}
+}
+
+func BenchmarkPool_GetPut(b *testing.B) {
+	CloseFunc = func() error { return nil }
+	for _, bm := range []struct {
+		name string
+		size int
+	}{
+		{name: "idle", size: 1000}, // every borrower finds an idle connection
+		{name: "exhausted", size: 4},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			p, _ := newCountingPool(bm.size, bm.size)
+			defer p.close()
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					conn, err := p.get(context.Background())
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					p.put(conn, nil)
+				}
+			})
+		})
+	}
+}
+
+func TestPool_FairWait(t *testing.T) {
+	CloseFunc = func() error { return nil }
+	p, _ := newCountingPool(1, 1)
+	defer p.close()
+	waiting := func() int {
+		p.turns.mu.Lock()
+		defer p.turns.mu.Unlock()
+		return p.turns.waiters.Len()
+	}
+	waitFor := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for waiting() != n {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d borrowers waiting, want %d", waiting(), n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	held, _ := p.get(context.Background())
+	served := make(chan int, 4)
+	canceled, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 4; i++ {
+		ctx := context.Background()
+		if i == 2 {
+			ctx = canceled
+		}
+		go func(i int, ctx context.Context) {
+			conn, err := p.get(ctx)
+			if err != nil {
+				served <- -i
+				return
+			}
+			served <- i
+			p.put(conn, nil)
+		}(i, ctx)
+		waitFor(i + 1)
+	}
+	cancel()
+	if got := <-served; got != -2 {
+		t.Fatalf("borrower %d was served, want the canceled one to give up", got)
+	}
+	waitFor(3)
+
+	p.put(held, nil)
+	for _, want := range []int{0, 1, 3} {
+		if got := <-served; got != want {
+			t.Errorf("borrower %d was served, want %d", got, want)
+		}
+	}
+}
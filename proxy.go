@@ -0,0 +1,216 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// dialProxy opens a tunnel to address through the proxy described by proxyURL:
+// socks5:// (or socks5h://) for a SOCKS5 proxy, http:// for an HTTP proxy supporting CONNECT.
+// Credentials in the URL user info are sent to the proxy.
+func dialProxy(ctx context.Context, proxyURL *url.URL, network string, address string) (net.Conn, error) {
+	if network != "tcp" {
+		return nil, fmt.Errorf("proxy: can't tunnel %s connections", network)
+	}
+
+	var handshake func(conn net.Conn, proxyURL *url.URL, address string) (net.Conn, error)
+	defaultPort := ""
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		handshake, defaultPort = socks5Handshake, "1080"
+	case "http":
+		handshake, defaultPort = httpConnectHandshake, "80"
+	default:
+		return nil, fmt.Errorf("proxy: unsupported scheme %q", proxyURL.Scheme)
+	}
+
+	proxyAddress := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddress = net.JoinHostPort(proxyURL.Hostname(), defaultPort)
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// The handshake is bounded by ctx like the dial itself
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+
+	tunnel, err := handshake(conn, proxyURL, address)
+	if !stop() && err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return tunnel, nil
+}
+
+// socks5Handshake asks a SOCKS5 proxy to connect to address (RFC 1928), authenticating with
+// username/password (RFC 1929) when the proxy URL has credentials. Host names are resolved by the proxy.
+func socks5Handshake(conn net.Conn, proxyURL *url.URL, address string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("proxy: invalid port %q", portStr)
+	}
+
+	methods := []byte{0x00} // no authentication
+	if proxyURL.User != nil {
+		methods = append(methods, 0x02) // username/password
+	}
+	if _, err := conn.Write(append([]byte{0x05, byte(len(methods))}, methods...)); err != nil {
+		return nil, err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, err
+	}
+	if reply[0] != 0x05 {
+		return nil, fmt.Errorf("proxy: unexpected SOCKS version %d", reply[0])
+	}
+	switch reply[1] {
+	case 0x00:
+	case 0x02:
+		if err := socks5Authenticate(conn, proxyURL.User); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("proxy: no acceptable SOCKS5 authentication method")
+	}
+
+	req := []byte{0x05, 0x01, 0x00} // CONNECT
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return nil, fmt.Errorf("proxy: host name too long %q", host)
+		}
+		req = append(req, 0x03, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, 0x01)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, 0x04)
+		req = append(req, ip...)
+	}
+	req = binary.BigEndian.AppendUint16(req, uint16(port))
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	// VER REP RSV ATYP, followed by the bound address and port we don't need
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, err
+	}
+	if header[1] != 0x00 {
+		return nil, fmt.Errorf("proxy: SOCKS5 connect to %s failed with code %d", address, header[1])
+	}
+	var skip int
+	switch header[3] {
+	case 0x01:
+		skip = net.IPv4len
+	case 0x04:
+		skip = net.IPv6len
+	case 0x03:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return nil, err
+		}
+		skip = int(length[0])
+	default:
+		return nil, fmt.Errorf("proxy: unexpected SOCKS5 address type %d", header[3])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, skip+2)); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func socks5Authenticate(conn net.Conn, user *url.Userinfo) error {
+	username := user.Username()
+	password, _ := user.Password()
+	if len(username) > 255 || len(password) > 255 {
+		return errors.New("proxy: SOCKS5 credentials too long")
+	}
+	req := []byte{0x01, byte(len(username))}
+	req = append(req, username...)
+	req = append(req, byte(len(password)))
+	req = append(req, password...)
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return err
+	}
+	if reply[1] != 0x00 {
+		return errors.New("proxy: SOCKS5 authentication failed")
+	}
+	return nil
+}
+
+// httpConnectHandshake opens a tunnel to address with an HTTP CONNECT request, sending
+// the proxy URL credentials as basic Proxy-Authorization.
+func httpConnectHandshake(conn net.Conn, proxyURL *url.URL, address string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("proxy: CONNECT to %s failed: %s", address, resp.Status)
+	}
+	if br.Buffered() > 0 {
+		// The server spoke first, keep what was read past the response
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn whose reads start with the data left in r.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,162 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// startProxy serves every connection accepted on a local listener with handle, and returns the listener address.
+func startProxy(t *testing.T, handle func(conn net.Conn)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// answerPing plays the server at the end of the tunnel, replying to a PING.
+func answerPing(r *bufio.Reader, w io.Writer) {
+	// *1\r\n$4\r\nPING\r\n
+	for i := 0; i < 3; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return
+		}
+	}
+	_, _ = io.WriteString(w, "+PONG\r\n")
+}
+
+func pingThrough(t *testing.T, proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	conn, err := NewRedisConnectionContext(ctx, NewProxyDialer(u, nil), "tcp", "redis.internal:6379", "")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Ping(ctx)
+}
+
+func TestDialer_DialSOCKS5(t *testing.T) {
+	requested := make(chan string, 1)
+	address := startProxy(t, func(conn net.Conn) {
+		buf := make([]byte, 512)
+		// greeting: only username/password is accepted
+		if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{0x05, 0x02})
+
+		// username/password
+		if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+			return
+		}
+		user := make([]byte, buf[1])
+		_, _ = io.ReadFull(conn, user)
+		_, _ = io.ReadFull(conn, buf[:1])
+		password := make([]byte, buf[0])
+		_, _ = io.ReadFull(conn, password)
+		if string(user) != "user" || string(password) != "secret" {
+			_, _ = conn.Write([]byte{0x01, 0x01})
+			return
+		}
+		_, _ = conn.Write([]byte{0x01, 0x00})
+
+		// CONNECT with a domain name
+		if _, err := io.ReadFull(conn, buf[:5]); err != nil {
+			return
+		}
+		host := make([]byte, buf[4])
+		_, _ = io.ReadFull(conn, host)
+		_, _ = io.ReadFull(conn, buf[:2])
+		requested <- net.JoinHostPort(string(host), strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+		_, _ = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0x18, 0xeb})
+
+		answerPing(bufio.NewReader(conn), conn)
+	})
+
+	if err := pingThrough(t, "socks5://user:secret@"+address); err != nil {
+		t.Fatalf("Ping() through SOCKS5 proxy error = %v", err)
+	}
+	if got := <-requested; got != "redis.internal:6379" {
+		t.Errorf("CONNECT address = %q, want %q", got, "redis.internal:6379")
+	}
+	if err := pingThrough(t, "socks5://user:wrong@"+address); err == nil {
+		t.Error("expected an authentication error")
+	}
+}
+
+func TestDialer_DialHTTPConnect(t *testing.T) {
+	address := startProxy(t, func(conn net.Conn) {
+		br := bufio.NewReader(conn)
+		req, err := http.ReadRequest(br)
+		if err != nil {
+			return
+		}
+		if req.Method != http.MethodConnect || req.Host != "redis.internal:6379" {
+			_, _ = io.WriteString(conn, "HTTP/1.1 400 Bad Request\r\n\r\n")
+			return
+		}
+		if req.Header.Get("Proxy-Authorization") != "Basic dXNlcjpzZWNyZXQ=" { // user:secret
+			_, _ = io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")
+			return
+		}
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		answerPing(br, conn)
+	})
+
+	if err := pingThrough(t, "http://user:secret@"+address); err != nil {
+		t.Fatalf("Ping() through HTTP proxy error = %v", err)
+	}
+	if err := pingThrough(t, "http://"+address); err == nil {
+		t.Error("expected a proxy authentication error")
+	}
+	if err := pingThrough(t, "ftp://"+address); err == nil {
+		t.Error("expected an unsupported scheme error")
+	}
+}
+
+func TestDialer_DialProxyTimeout(t *testing.T) {
+	// a proxy that accepts connections but never answers the handshake
+	address := startProxy(t, func(conn net.Conn) {
+		_, _ = io.Copy(io.Discard, conn)
+	})
+	u, _ := url.Parse("socks5://" + address)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := NewProxyDialer(u, nil).Dial(ctx, "tcp", "redis.internal:6379"); err == nil {
+		t.Fatal("Dial() expected a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Dial() took %v, the context deadline wasn't applied", elapsed)
+	}
+}
@@ -0,0 +1,244 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Message is a message published on a channel the PubSub is subscribed to.
+// Pattern is set when the message matched a PSubscribe pattern, Sharded when it was
+// published on a shard channel (SPUBLISH) subscribed with SSubscribe.
+type Message struct {
+	Channel string
+	Pattern string
+	Sharded bool
+	Payload string
+}
+
+// PubSub is a connection in subscribed mode, dedicated to receiving messages.
+// Messages are delivered on Channel until Close is called or the connection fails.
+type PubSub struct {
+	conn      IConnection
+	mu        sync.Mutex // serializes writes, the receive loop owns the reads
+	messages  chan *Message
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+func (client *Client) Subscribe(ctx context.Context, channels ...string) (*PubSub, error) {
+	ps, err := client.newPubSub(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) > 0 {
+		if err := ps.Subscribe(ctx, channels...); err != nil {
+			_ = ps.Close()
+			return nil, err
+		}
+	}
+	return ps, nil
+}
+
+func (client *Client) PSubscribe(ctx context.Context, patterns ...string) (*PubSub, error) {
+	ps, err := client.newPubSub(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.PSubscribe(ctx, patterns...); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	return ps, nil
+}
+
+// SSubscribe subscribes to shard channels (Redis 7+), see SPublish.
+func (client *Client) SSubscribe(ctx context.Context, channels ...string) (*PubSub, error) {
+	ps, err := client.newPubSub(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.SSubscribe(ctx, channels...); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	return ps, nil
+}
+
+// Publish posts message on channel and returns the number of subscribers that received it.
+func (client *Client) Publish(ctx context.Context, channel string, message string) (int, error) {
+	reply, err := client.Do(ctx, "PUBLISH", channel, message)
+	if err != nil {
+		return 0, err
+	}
+	receivers, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("publish: unexpected response from server %v", reply.Value())
+	}
+	return receivers, nil
+}
+
+// SPublish posts message on a shard channel (Redis 7+). In a cluster shard channels are assigned to slots
+// like keys, so the message only travels within the shard owning the channel.
+func (client *Client) SPublish(ctx context.Context, channel string, message string) (int, error) {
+	reply, err := client.Do(ctx, "SPUBLISH", channel, message)
+	if err != nil {
+		return 0, err
+	}
+	receivers, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("spublish: unexpected response from server %v", reply.Value())
+	}
+	return receivers, nil
+}
+
+func (client *Client) newPubSub(ctx context.Context) (*PubSub, error) {
+	// A subscribed connection can't run regular commands, so it never shares the client connection. It is
+	// read without a deadline: messages come whenever they are published, and a timeout in the middle of
+	// one would leave the rest of it on the connection.
+	conn, err := client.dialBlocking(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &PubSub{
+		conn:     conn,
+		messages: make(chan *Message, 100),
+		done:     make(chan struct{}),
+	}
+	go ps.receive()
+	return ps, nil
+}
+
+func (ps *PubSub) Subscribe(ctx context.Context, channels ...string) error {
+	if len(channels) == 0 {
+		return errors.New("subscribe: no channel")
+	}
+	return ps.send(ctx, "SUBSCRIBE", channels)
+}
+
+func (ps *PubSub) PSubscribe(ctx context.Context, patterns ...string) error {
+	if len(patterns) == 0 {
+		return errors.New("psubscribe: no pattern")
+	}
+	return ps.send(ctx, "PSUBSCRIBE", patterns)
+}
+
+func (ps *PubSub) SSubscribe(ctx context.Context, channels ...string) error {
+	if len(channels) == 0 {
+		return errors.New("ssubscribe: no channel")
+	}
+	return ps.send(ctx, "SSUBSCRIBE", channels)
+}
+
+// Unsubscribe stops listening on channels, or on every channel when none is given.
+func (ps *PubSub) Unsubscribe(ctx context.Context, channels ...string) error {
+	return ps.send(ctx, "UNSUBSCRIBE", channels)
+}
+
+// PUnsubscribe stops listening on patterns, or on every pattern when none is given.
+func (ps *PubSub) PUnsubscribe(ctx context.Context, patterns ...string) error {
+	return ps.send(ctx, "PUNSUBSCRIBE", patterns)
+}
+
+// SUnsubscribe stops listening on shard channels, or on every shard channel when none is given.
+func (ps *PubSub) SUnsubscribe(ctx context.Context, channels ...string) error {
+	return ps.send(ctx, "SUNSUBSCRIBE", channels)
+}
+
+// Channel returns the channel messages are delivered on, it is closed once the PubSub stops receiving.
+func (ps *PubSub) Channel() <-chan *Message {
+	return ps.messages
+}
+
+// Err returns the error that stopped the receive loop, if any.
+func (ps *PubSub) Err() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.err
+}
+
+func (ps *PubSub) Close() error {
+	var err error
+	ps.closeOnce.Do(func() {
+		close(ps.done)
+		err = ps.conn.Close()
+	})
+	return err
+}
+
+func (ps *PubSub) send(ctx context.Context, command string, names []string) error {
+	args := make([]interface{}, 0, len(names)+1)
+	args = append(args, command)
+	for _, name := range names {
+		args = append(args, name)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	// The receive loop shares the connection, a cancel of ctx must not interrupt it: only the write timeout applies.
+	if err := ps.conn.SendCommand(context.WithoutCancel(ctx), args...); err != nil {
+		// part of the command may be on the wire, the connection is out of sync
+		ps.err = err
+		_ = ps.Close()
+		return err
+	}
+	return nil
+}
+
+func (ps *PubSub) receive() {
+	defer close(ps.messages)
+	for {
+		value, err := ps.conn.ReceiveValue(context.Background())
+		if err != nil {
+			select {
+			case <-ps.done:
+				return // closed by the user
+			default:
+			}
+			if isReplyError(err) {
+				continue // a subscription command failed, the connection is still in sync
+			}
+			ps.mu.Lock()
+			ps.err = err
+			ps.mu.Unlock()
+			return
+		}
+
+		msg, ok := parseMessage(value)
+		if !ok {
+			continue // subscription confirmations and pongs
+		}
+		select {
+		case ps.messages <- msg:
+		case <-ps.done:
+			return
+		}
+	}
+}
+
+// parseMessage converts a pushed "message", "pmessage" or "smessage" array, other pushes are reported as not ok.
+func parseMessage(value interface{}) (*Message, bool) {
+	parts, err := NewReply(value).StringSlice()
+	if err != nil || len(parts) < 3 {
+		return nil, false
+	}
+	switch parts[0] {
+	case "message":
+		return &Message{Channel: parts[1], Payload: parts[2]}, true
+	case "pmessage":
+		if len(parts) != 4 {
+			return nil, false
+		}
+		return &Message{Pattern: parts[1], Channel: parts[2], Payload: parts[3]}, true
+	case "smessage":
+		return &Message{Channel: parts[1], Sharded: true, Payload: parts[2]}, true
+	default:
+		return nil, false
+	}
+}
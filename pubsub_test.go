@@ -0,0 +1,268 @@
+package resp
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPipeClient returns a client whose dialer hands out in-memory connections,
+// the server side of every dialed connection is sent on the returned channel.
+func newPipeClient(auth string) (*Client, <-chan *Connection) {
+	servers := make(chan *Connection, 1)
+	client := newMockClient(2, auth)
+	client.opts.Dialer = &MockDialer{DialFunc: func(ctx context.Context, network string, address string) (net.Conn, error) {
+		clientConn, serverConn := net.Pipe()
+		servers <- &Connection{
+			conn: serverConn,
+			rw:   bufio.NewReadWriter(bufio.NewReader(serverConn), bufio.NewWriter(serverConn)),
+		}
+		return clientConn, nil
+	}}
+	return client, servers
+}
+
+// expectCommand reads a command on the server side of a pipe connection and answers it with the raw reply.
+func expectCommand(t *testing.T, server *Connection, name string, reply string) {
+	value, err := server.ReceiveValue(context.Background())
+	if err != nil {
+		t.Errorf("server failed to read %s: %s", name, err)
+		return
+	}
+	if args, _ := NewReply(value).StringSlice(); len(args) == 0 || args[0] != name {
+		t.Errorf("server got %v, want %s", value, name)
+	}
+	_, _ = server.rw.WriteString(reply)
+	_ = server.rw.Flush()
+}
+
+func receiveMessage(t *testing.T, ps *PubSub) *Message {
+	select {
+	case msg := <-ps.Channel():
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return nil
+	}
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	client, servers := newPipeClient("")
+	go func() {
+		server := <-servers
+		expectCommand(t, server, "SUBSCRIBE",
+			"*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n"+
+				"*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n")
+		expectCommand(t, server, "PSUBSCRIBE",
+			"*3\r\n$10\r\npsubscribe\r\n$3\r\nn.*\r\n:2\r\n"+
+				"*4\r\n$8\r\npmessage\r\n$3\r\nn.*\r\n$3\r\nn.1\r\n$5\r\nworld\r\n")
+	}()
+
+	ps, err := client.Subscribe(context.Background(), "news")
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %s", err)
+	}
+	defer ps.Close()
+
+	msg := receiveMessage(t, ps)
+	if msg.Channel != "news" || msg.Payload != "hello" || msg.Pattern != "" {
+		t.Errorf("got message %+v", msg)
+	}
+
+	if err := ps.PSubscribe(context.Background(), "n.*"); err != nil {
+		t.Fatalf("PSubscribe returned error: %s", err)
+	}
+	msg = receiveMessage(t, ps)
+	if msg.Channel != "n.1" || msg.Payload != "world" || msg.Pattern != "n.*" {
+		t.Errorf("got message %+v", msg)
+	}
+}
+
+func TestClient_SSubscribe(t *testing.T) {
+	client, servers := newPipeClient("")
+	go func() {
+		server := <-servers
+		expectCommand(t, server, "SSUBSCRIBE",
+			"*3\r\n$10\r\nssubscribe\r\n$7\r\norders1\r\n:1\r\n"+
+				"*3\r\n$8\r\nsmessage\r\n$7\r\norders1\r\n$3\r\nnew\r\n")
+	}()
+
+	ps, err := client.SSubscribe(context.Background(), "orders1")
+	if err != nil {
+		t.Fatalf("SSubscribe returned error: %s", err)
+	}
+	defer ps.Close()
+
+	msg := receiveMessage(t, ps)
+	if msg.Channel != "orders1" || msg.Payload != "new" || !msg.Sharded {
+		t.Errorf("got message %+v", msg)
+	}
+}
+
+func TestPubSub_Close(t *testing.T) {
+	client, servers := newPipeClient("")
+	go func() {
+		<-servers
+	}()
+
+	ps, err := client.Subscribe(context.Background())
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %s", err)
+	}
+	if err := ps.Close(); err != nil {
+		t.Fatalf("Close returned error: %s", err)
+	}
+
+	select {
+	case _, ok := <-ps.Channel():
+		if ok {
+			t.Error("Channel delivered a message after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Channel was not closed")
+	}
+	if ps.Err() != nil {
+		t.Errorf("Err got = %v, want nil after Close", ps.Err())
+	}
+}
+
+func TestClient_SPublish(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return int64(1), nil
+	}
+	client := newMockClient(2, "password")
+	receivers, err := client.SPublish(context.Background(), "orders1", "new")
+	if err != nil {
+		t.Errorf("SPublish returned error: %s", err)
+	}
+	if receivers != 1 || !strings.Contains(sent, "SPUBLISH") {
+		t.Errorf("SPublish got = %d, sent %q", receivers, sent)
+	}
+}
+
+func TestClient_Publish(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return int64(2), nil
+	}
+	client := newMockClient(2, "password")
+	receivers, err := client.Publish(context.Background(), "news", "hello")
+	if err != nil {
+		t.Errorf("Publish returned error: %s", err)
+	}
+	if receivers != 2 {
+		t.Errorf("Publish got = %d, want 2", receivers)
+	}
+}
+
+func TestPubSub_NoChannel(t *testing.T) {
+	client, servers := newPipeClient("")
+	go func() {
+		<-servers
+	}()
+
+	ps, err := client.Subscribe(context.Background())
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %s", err)
+	}
+	defer ps.Close()
+	if err := ps.Subscribe(context.Background()); err == nil {
+		t.Error("Subscribe expected an error without channels")
+	}
+	if err := ps.PSubscribe(context.Background()); err == nil {
+		t.Error("PSubscribe expected an error without patterns")
+	}
+	if err := ps.SSubscribe(context.Background()); err == nil {
+		t.Error("SSubscribe expected an error without channels")
+	}
+}
+
+func TestPubSub_ErrorReply(t *testing.T) {
+	client, servers := newPipeClient("")
+	go func() {
+		server := <-servers
+		expectCommand(t, server, "SUBSCRIBE",
+			"-ERR Can't execute 'subscribe': only (P|S)SUBSCRIBE / (P|S)UNSUBSCRIBE / PING / QUIT / RESET are allowed\r\n"+
+				"*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$5\r\nhello\r\n")
+	}()
+
+	ps, err := client.Subscribe(context.Background(), "news")
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %s", err)
+	}
+	defer ps.Close()
+
+	msg := receiveMessage(t, ps)
+	if msg.Channel != "news" || msg.Payload != "hello" {
+		t.Errorf("got message %+v", msg)
+	}
+	if ps.Err() != nil {
+		t.Errorf("Err got = %v, want nil after an error reply", ps.Err())
+	}
+}
+
+func TestPubSub_SlowMessage(t *testing.T) {
+	client, servers := newPipeClient("")
+	client.opts.ReadTimeout = 20 * time.Millisecond
+	go func() {
+		server := <-servers
+		expectCommand(t, server, "SUBSCRIBE", "*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n*3\r\n$7\r\nmessage\r\n")
+		// the rest of the message comes after the read timeout of the client
+		time.Sleep(50 * time.Millisecond)
+		_, _ = server.rw.WriteString("$4\r\nnews\r\n$5\r\nhello\r\n")
+		_ = server.rw.Flush()
+	}()
+
+	ps, err := client.Subscribe(context.Background(), "news")
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %s", err)
+	}
+	defer ps.Close()
+
+	msg := receiveMessage(t, ps)
+	if msg.Channel != "news" || msg.Payload != "hello" {
+		t.Errorf("got message %+v", msg)
+	}
+}
+
+func TestPubSub_SubscribeCancel(t *testing.T) {
+	client, servers := newPipeClient("")
+	go func() {
+		server := <-servers
+		expectCommand(t, server, "SUBSCRIBE", "*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n")
+		// the second subscribe is only read after its context was cancelled
+		time.Sleep(50 * time.Millisecond)
+		expectCommand(t, server, "SUBSCRIBE",
+			"*3\r\n$9\r\nsubscribe\r\n$6\r\nsports\r\n:2\r\n"+
+				"*3\r\n$7\r\nmessage\r\n$6\r\nsports\r\n$5\r\nhello\r\n")
+	}()
+
+	ps, err := client.Subscribe(context.Background(), "news")
+	if err != nil {
+		t.Fatalf("Subscribe returned error: %s", err)
+	}
+	defer ps.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := ps.Subscribe(ctx, "sports"); err != nil {
+		t.Fatalf("Subscribe returned error: %s", err)
+	}
+	msg := receiveMessage(t, ps)
+	if msg.Channel != "sports" || msg.Payload != "hello" {
+		t.Errorf("got message %+v", msg)
+	}
+	if ps.Err() != nil {
+		t.Errorf("Err got = %v, want nil after a cancel", ps.Err())
+	}
+}
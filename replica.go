@@ -0,0 +1,229 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readOnlyCommands are the commands that never write and can be served by a replica. The SCAN family is left
+// out: a cursor is only valid on the server that returned it, scanCursor sends every page of a walk to one.
+var readOnlyCommands = map[string]bool{
+	"GET": true, "MGET": true, "STRLEN": true, "GETRANGE": true, "EXISTS": true, "TTL": true, "PTTL": true,
+	"EXPIRETIME": true, "PEXPIRETIME": true, "TYPE": true, "KEYS": true, "RANDOMKEY": true, "DBSIZE": true,
+	"HGET": true, "HMGET": true, "HGETALL": true, "HKEYS": true, "HVALS": true, "HLEN": true, "HEXISTS": true,
+	"HSTRLEN": true, "HRANDFIELD": true,
+	"LRANGE": true, "LLEN": true, "LINDEX": true, "LPOS": true,
+	"SMEMBERS": true, "SISMEMBER": true, "SMISMEMBER": true, "SCARD": true, "SRANDMEMBER": true, "SINTER": true,
+	"SINTERCARD": true, "SUNION": true, "SDIFF": true,
+	"ZRANGE": true, "ZRANDMEMBER": true, "ZRANGEBYSCORE": true, "ZRANGEBYLEX": true, "ZREVRANGE": true, "ZREVRANGEBYSCORE": true,
+	"ZSCORE": true, "ZMSCORE": true, "ZCARD": true, "ZCOUNT": true, "ZLEXCOUNT": true, "ZRANK": true, "ZREVRANK": true,
+	"XRANGE": true, "XREVRANGE": true, "XLEN": true,
+	"GEOPOS": true, "GEODIST": true, "GEOHASH": true, "GEOSEARCH": true,
+	"EVAL_RO": true, "EVALSHA_RO": true, "FCALL_RO": true,
+}
+
+const (
+	replicaProbeInterval = time.Second
+	// replicaSwitchRatio is how much faster another replica must be before reads move to it,
+	// so replicas with about the same latency don't make the routing flap
+	replicaSwitchRatio = 0.8
+)
+
+type replica struct {
+	client  *Client
+	latency time.Duration // smoothed PING round trip, zero until the first probe
+	healthy bool
+}
+
+// replicaSet holds the replicas read-only commands are routed to. A background probe PINGs every replica
+// and reads go to the fastest healthy one; until the first probe completes they are spread in round-robin order.
+type replicaSet struct {
+	mu        sync.RWMutex
+	replicas  []*replica
+	preferred *replica
+	next      uint32
+	probeOnce sync.Once
+	done      chan struct{}
+}
+
+// AddReplica connects to a replica of the primary; read-only commands (GET, HGETALL, LRANGE...) are then
+// routed to the replica with the lowest PING latency, and to the primary when no replica is reachable.
+// READONLY is sent on the replica connection, it is required by cluster replicas and ignored otherwise.
+// Keep in mind replication is asynchronous: a read from a replica may not see a write just made on the primary.
+func (client *Client) AddReplica(address string) error {
+	opts := *client.opts
+	opts.Address = address
+	opts.DB = int(client.db.Load())
+	opts.readOnly = true
+	// a failed read falls back to the primary instead of being retried on the replica
+	opts.MaxRetries = 0
+	replica := newClient(&opts)
+
+	conn, err := replica.pool.get(context.Background())
+	if isClusterDisabled(err) {
+		// A standalone replica, its connections don't need READONLY
+		opts.readOnly = false
+		conn, err = replica.pool.get(context.Background())
+	}
+	if err != nil {
+		_ = replica.pool.close()
+		return err
+	}
+	replica.pool.put(conn, nil)
+
+	client.replicas.add(replica)
+	return nil
+}
+
+// isClusterDisabled reports the error standalone servers reply to cluster-only commands such as READONLY.
+func isClusterDisabled(err error) bool {
+	var replyErr replyError
+	return errors.As(err, &replyErr) && strings.Contains(err.Error(), "cluster support disabled")
+}
+
+func (rs *replicaSet) add(client *Client) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.replicas = append(rs.replicas, &replica{client: client, healthy: true})
+
+	rs.probeOnce.Do(func() {
+		rs.done = make(chan struct{})
+		go rs.probeLoop(rs.done)
+	})
+}
+
+// pick returns the replica to run args on, or nil when the command must go to the primary.
+func (rs *replicaSet) pick(args []interface{}) *Client {
+	if len(args) == 0 {
+		return nil
+	}
+	name, ok := args[0].(string)
+	if !ok || !readOnlyCommands[strings.ToUpper(name)] {
+		return nil
+	}
+	return rs.route()
+}
+
+// route returns the replica reads go to, or nil when none is healthy.
+func (rs *replicaSet) route() *Client {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	if rs.preferred != nil {
+		return rs.preferred.client
+	}
+	healthy := make([]*replica, 0, len(rs.replicas))
+	for _, r := range rs.replicas {
+		if r.healthy {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	n := atomic.AddUint32(&rs.next, 1)
+	return healthy[int(n)%len(healthy)].client
+}
+
+// failed marks a replica that couldn't be reached as unhealthy until the next probe succeeds.
+func (rs *replicaSet) failed(client *Client) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for _, r := range rs.replicas {
+		if r.client == client {
+			r.healthy = false
+		}
+	}
+	rs.choose()
+}
+
+func (rs *replicaSet) probeLoop(done chan struct{}) {
+	ticker := time.NewTicker(replicaProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			rs.probe()
+		}
+	}
+}
+
+// probe measures the PING latency of every replica and updates the preferred one.
+func (rs *replicaSet) probe() {
+	rs.mu.RLock()
+	replicas := append([]*replica(nil), rs.replicas...)
+	rs.mu.RUnlock()
+
+	for _, r := range replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaProbeInterval)
+		start := time.Now()
+		_, err := r.client.Ping(ctx)
+		rtt := time.Since(start)
+		cancel()
+
+		rs.mu.Lock()
+		r.healthy = err == nil
+		if r.healthy {
+			if r.latency == 0 {
+				r.latency = rtt
+			} else {
+				r.latency = (r.latency + rtt) / 2
+			}
+		}
+		rs.mu.Unlock()
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.choose()
+}
+
+// choose picks the fastest healthy replica, keeping the current one unless the other is clearly faster.
+// rs.mu must be held.
+func (rs *replicaSet) choose() {
+	var fastest *replica
+	for _, r := range rs.replicas {
+		if r.healthy && r.latency > 0 && (fastest == nil || r.latency < fastest.latency) {
+			fastest = r
+		}
+	}
+
+	current := rs.preferred
+	switch {
+	case fastest == nil:
+		rs.preferred = nil
+	case current == nil || !current.healthy:
+		rs.preferred = fastest
+	case float64(fastest.latency) < float64(current.latency)*replicaSwitchRatio:
+		rs.preferred = fastest
+	}
+}
+
+func (rs *replicaSet) all() []*Client {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	clients := make([]*Client, len(rs.replicas))
+	for i, r := range rs.replicas {
+		clients[i] = r.client
+	}
+	return clients
+}
+
+func (rs *replicaSet) close() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.done != nil {
+		close(rs.done)
+		rs.done = nil
+	}
+	for _, r := range rs.replicas {
+		_ = r.client.Close()
+	}
+	rs.replicas = nil
+	rs.preferred = nil
+}
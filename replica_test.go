@@ -0,0 +1,106 @@
+package resp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestClient_AddReplica(t *testing.T) {
+	var primary []string
+	SendFunc = func(command string) error {
+		primary = append(primary, command)
+		return nil
+	}
+	ReceiveFunc = replySequence("OK", "from-primary")
+	client, servers := newPipeClient("")
+	served := make(chan *Connection, 1)
+	go func() {
+		// READONLY is part of the handshake, a standalone replica is then dialed again without it
+		server := <-servers
+		expectCommand(t, server, "READONLY", "-ERR This instance has cluster support disabled\r\n")
+		server = <-servers
+		expectCommand(t, server, "GET", "$12\r\nfrom-replica\r\n")
+		served <- server
+	}()
+
+	if err := client.AddReplica("replica:6379"); err != nil {
+		t.Fatalf("AddReplica returned error: %s", err)
+	}
+
+	value, err := client.Get(context.Background(), "key")
+	if err != nil || value != "from-replica" {
+		t.Errorf("Get got = %q, %v, want the replica value", value, err)
+	}
+
+	// writes always go to the primary
+	if err := client.Set(context.Background(), "key", "value"); err != nil || len(primary) != 1 {
+		t.Errorf("Set got = %v, sent %q to the primary", err, primary)
+	}
+
+	t.Run("falls back to the primary", func(t *testing.T) {
+		server := <-served
+		_ = server.Close()
+		value, err := client.Get(context.Background(), "key")
+		if err != nil || value != "from-primary" {
+			t.Errorf("Get got = %q, %v, want the primary value", value, err)
+		}
+	})
+}
+
+func TestReplicaSet_Choose(t *testing.T) {
+	a := &replica{client: &Client{address: "a"}, healthy: true, latency: 10 * time.Millisecond}
+	b := &replica{client: &Client{address: "b"}, healthy: true, latency: 9 * time.Millisecond}
+	rs := &replicaSet{replicas: []*replica{a, b}, preferred: a}
+	get := []interface{}{"GET", "key"}
+
+	rs.choose()
+	if rs.pick(get) != a.client {
+		t.Errorf("pick() switched to a replica only slightly faster")
+	}
+
+	b.latency = 5 * time.Millisecond
+	rs.choose()
+	if rs.pick(get) != b.client {
+		t.Errorf("pick() did not switch to the clearly faster replica")
+	}
+
+	rs.failed(b.client)
+	if rs.pick(get) != a.client {
+		t.Errorf("pick() did not move off the failed replica")
+	}
+
+	rs.failed(a.client)
+	if rs.pick(get) != nil {
+		t.Errorf("pick() returned a replica while none is healthy")
+	}
+	if rs.pick([]interface{}{"SET", "key", "value"}) != nil {
+		t.Errorf("pick() routed a write to a replica")
+	}
+	a.healthy = true
+	rs.choose()
+	for _, name := range []string{"SCAN", "HSCAN", "SSCAN", "ZSCAN"} {
+		if rs.pick([]interface{}{name, "0"}) != nil {
+			t.Errorf("pick() routed %s to a replica, its cursor is only valid on one server", name)
+		}
+	}
+}
+
+func TestReplicaSet_Probe(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return "PONG", nil
+	}
+	r := &replica{client: newMockClient(1, ""), healthy: false}
+	rs := &replicaSet{replicas: []*replica{r}}
+
+	rs.probe()
+	if !r.healthy || r.latency == 0 {
+		t.Errorf("probe() got healthy = %v, latency = %v", r.healthy, r.latency)
+	}
+	if rs.pick([]interface{}{"GET", "key"}) != r.client {
+		t.Errorf("pick() did not return the probed replica")
+	}
+}
@@ -0,0 +1,175 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrNil is returned when a nil reply is read as a value, e.g. by Get for a missing key.
+var ErrNil = errors.New("nil reply")
+
+// Reply is a single reply read from the server. It wraps the structured value returned by
+// Connection.ReceiveValue and converts it on demand, so callers don't parse raw replies themselves.
+type Reply struct {
+	value interface{}
+}
+
+func NewReply(value interface{}) *Reply {
+	return &Reply{value: value}
+}
+
+// Value returns the underlying value: string, int64, nil, error or []interface{}.
+func (r *Reply) Value() interface{} {
+	return r.value
+}
+
+// IsNil reports whether r is a nil reply (a missing key, an aborted transaction...). Converting it
+// with Text, Int, Array and the like returns ErrNil.
+func (r *Reply) IsNil() bool {
+	return r.value == nil
+}
+
+// Err returns the error reply held by r, it is only set for error replies nested in an array.
+func (r *Reply) Err() error {
+	if err, ok := r.value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+func (r *Reply) Text() (string, error) {
+	switch value := r.value.(type) {
+	case string:
+		return value, nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	default:
+		return "", r.convertErr("string")
+	}
+}
+
+func (r *Reply) Bytes() ([]byte, error) {
+	text, err := r.Text()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(text), nil
+}
+
+func (r *Reply) Int() (int, error) {
+	number, err := r.Int64()
+	if err != nil {
+		return 0, err
+	}
+	return int(number), nil
+}
+
+func (r *Reply) Int64() (int64, error) {
+	switch value := r.value.(type) {
+	case int64:
+		return value, nil
+	case string:
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("reply: cannot convert %q to int", value)
+		}
+		return number, nil
+	default:
+		return 0, r.convertErr("int")
+	}
+}
+
+func (r *Reply) Float64() (float64, error) {
+	switch value := r.value.(type) {
+	case int64:
+		return float64(value), nil
+	case string:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("reply: cannot convert %q to float", value)
+		}
+		return number, nil
+	default:
+		return 0, r.convertErr("float")
+	}
+}
+
+// Bool converts integer replies (non-zero is true) and "OK" status replies.
+func (r *Reply) Bool() (bool, error) {
+	switch value := r.value.(type) {
+	case int64:
+		return value != 0, nil
+	case string:
+		if value == "OK" {
+			return true, nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return false, fmt.Errorf("reply: cannot convert %q to bool", value)
+		}
+		return b, nil
+	default:
+		return false, r.convertErr("bool")
+	}
+}
+
+func (r *Reply) Array() ([]*Reply, error) {
+	values, ok := r.value.([]interface{})
+	if !ok {
+		return nil, r.convertErr("array")
+	}
+	replies := make([]*Reply, len(values))
+	for i, value := range values {
+		replies[i] = NewReply(value)
+	}
+	return replies, nil
+}
+
+// StringSlice converts an array reply, nil elements become empty strings; use Array and IsNil
+// on the elements to tell them apart, e.g. for MGET.
+func (r *Reply) StringSlice() ([]string, error) {
+	values, ok := r.value.([]interface{})
+	if !ok {
+		return nil, r.convertErr("[]string")
+	}
+	strs := make([]string, len(values))
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		str, err := NewReply(value).Text()
+		if err != nil {
+			return nil, err
+		}
+		strs[i] = str
+	}
+	return strs, nil
+}
+
+// Map converts a flat array of field/value pairs, as returned by HGETALL or CONFIG GET.
+func (r *Reply) Map() (map[string]string, error) {
+	strs, err := r.StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	if len(strs)%2 != 0 {
+		return nil, errors.New("reply: map reply has an odd number of elements")
+	}
+	m := make(map[string]string, len(strs)/2)
+	for i := 0; i < len(strs); i += 2 {
+		m[strs[i]] = strs[i+1]
+	}
+	return m, nil
+}
+
+func (r *Reply) convertErr(to string) error {
+	switch value := r.value.(type) {
+	case nil:
+		return ErrNil
+	case error:
+		return value
+	default:
+		return fmt.Errorf("reply: cannot convert %T to %s", value, to)
+	}
+}
@@ -0,0 +1,85 @@
+package resp
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReply_Conversions(t *testing.T) {
+	t.Run("integer reply", func(t *testing.T) {
+		reply := NewReply(int64(42))
+		if n, err := reply.Int(); err != nil || n != 42 {
+			t.Errorf("Int() got = %v, %v", n, err)
+		}
+		if f, err := reply.Float64(); err != nil || f != 42 {
+			t.Errorf("Float64() got = %v, %v", f, err)
+		}
+		if b, err := reply.Bool(); err != nil || !b {
+			t.Errorf("Bool() got = %v, %v", b, err)
+		}
+		if s, err := reply.Text(); err != nil || s != "42" {
+			t.Errorf("Text() got = %v, %v", s, err)
+		}
+	})
+
+	t.Run("bulk string reply", func(t *testing.T) {
+		reply := NewReply("3.5")
+		if f, err := reply.Float64(); err != nil || f != 3.5 {
+			t.Errorf("Float64() got = %v, %v", f, err)
+		}
+		if b, err := reply.Bytes(); err != nil || string(b) != "3.5" {
+			t.Errorf("Bytes() got = %v, %v", b, err)
+		}
+		if _, err := reply.Int(); err == nil {
+			t.Error("Int() expected error for non-integer string")
+		}
+	})
+
+	t.Run("nil reply", func(t *testing.T) {
+		reply := NewReply(nil)
+		if !reply.IsNil() {
+			t.Error("IsNil() got = false, want true")
+		}
+		if _, err := reply.Text(); err == nil {
+			t.Error("Text() expected error for nil reply")
+		}
+	})
+
+	t.Run("error element", func(t *testing.T) {
+		reply := NewReply(RedisError("WRONGTYPE Operation against a key"))
+		if reply.Err() == nil {
+			t.Fatal("Err() got = nil, want error")
+		}
+		if _, err := reply.Int(); !errors.Is(err, reply.Err()) {
+			t.Errorf("Int() got = %v, want %v", err, reply.Err())
+		}
+	})
+
+	t.Run("array reply", func(t *testing.T) {
+		reply := NewReply([]interface{}{"a", nil, int64(2), []interface{}{"b"}})
+		replies, err := reply.Array()
+		if err != nil || len(replies) != 4 {
+			t.Fatalf("Array() got = %v, %v", replies, err)
+		}
+		if !replies[1].IsNil() {
+			t.Errorf("Array()[1] got = %v, want nil", replies[1].Value())
+		}
+		nested, err := replies[3].StringSlice()
+		if err != nil || len(nested) != 1 || nested[0] != "b" {
+			t.Errorf("StringSlice() got = %v, %v", nested, err)
+		}
+	})
+
+	t.Run("map reply", func(t *testing.T) {
+		m, err := NewReply([]interface{}{"f1", "v1", "f2", int64(2)}).Map()
+		if err != nil {
+			t.Fatalf("Map() error = %v", err)
+		}
+		if m["f1"] != "v1" || m["f2"] != "2" {
+			t.Errorf("Map() got = %v", m)
+		}
+		if _, err := NewReply([]interface{}{"f1"}).Map(); err == nil {
+			t.Error("Map() expected error for odd number of elements")
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+)
+
+const (
+	// DefaultMinRetryBackoff is the backoff before the first retry when none is configured.
+	DefaultMinRetryBackoff = 8 * time.Millisecond
+	// DefaultMaxRetryBackoff caps the backoff between retries when no cap is configured.
+	DefaultMaxRetryBackoff = 512 * time.Millisecond
+)
+
+// errorKind classifies the error replies the retry layer acts on.
+type errorKind int
+
+const (
+	errorOther       errorKind = iota
+	errorLoading               // LOADING: the server is still loading its dataset in memory
+	errorReadOnly              // READONLY: a write reached a replica, typically a master demoted by a failover
+	errorClusterDown           // CLUSTERDOWN: the cluster can't serve the slot for now (failover in progress)
+	errorTryAgain              // TRYAGAIN: a multi-key command hit a slot being resharded
+	errorMasterDown            // MASTERDOWN: a replica lost its master and doesn't serve stale data
+)
+
+var errorKinds = map[string]errorKind{
+	"LOADING":     errorLoading,
+	"READONLY":    errorReadOnly,
+	"CLUSTERDOWN": errorClusterDown,
+	"TRYAGAIN":    errorTryAgain,
+	"MASTERDOWN":  errorMasterDown,
+}
+
+// replyErrorKind returns the kind of the error reply err holds from its prefix, errorOther for other errors.
+func replyErrorKind(err error) errorKind {
+	var redisErr RedisError
+	if !errors.As(err, &redisErr) {
+		return errorOther
+	}
+	return errorKinds[redisErr.Code()]
+}
+
+// isTransient reports whether a command that failed with err is worth retrying: the connection was
+// closed under it or couldn't be opened at all, or the server rejected it for a reason that goes away
+// on its own (see errorKind). The command didn't run in the latter cases.
+func isTransient(err error) bool {
+	if isBrokenConn(err) || replyErrorKind(err) != errorOther {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isReplicaUnavailable reports whether a replica replied err because it can't serve reads for now,
+// so the command should go to the primary instead.
+func isReplicaUnavailable(err error) bool {
+	kind := replyErrorKind(err)
+	return kind == errorLoading || kind == errorMasterDown
+}
+
+// retryBackoff returns how long to wait before retry attempt+1: a random duration ("full jitter") up to
+// minBackoff doubled attempt times, capped at maxBackoff, so clients failing together don't retry together.
+func retryBackoff(attempt int, minBackoff time.Duration, maxBackoff time.Duration) time.Duration {
+	backoff := maxBackoff
+	if attempt < 32 && minBackoff<<attempt < maxBackoff && minBackoff<<attempt > 0 {
+		backoff = minBackoff << attempt
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// sleepCtx waits for d, or returns the context error when ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	for attempt, ceiling := range []time.Duration{10, 20, 40, 50, 50} {
+		for i := 0; i < 100; i++ {
+			if got := retryBackoff(attempt, 10, 50); got < 0 || got > ceiling {
+				t.Fatalf("retryBackoff(%d) = %v, want at most %v", attempt, got, ceiling)
+			}
+		}
+	}
+	if got := retryBackoff(100, time.Millisecond, time.Second); got > time.Second {
+		t.Errorf("retryBackoff() = %v overflowed the cap", got)
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: io.EOF, want: true},
+		{err: &net.OpError{Op: "write", Err: syscall.EPIPE}, want: true},
+		{err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, want: true},
+		{err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{err: &net.OpError{Op: "read", Err: errors.New("i/o timeout")}, want: false},
+		{err: RedisError("ERR unknown command"), want: false},
+		{err: RedisError("LOADING Redis is loading the dataset in memory"), want: true},
+		{err: RedisError("TRYAGAIN Multiple keys request during rehashing of slot"), want: true},
+		{err: ErrPoolExhausted, want: false},
+		{err: context.Canceled, want: false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestReplyErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want errorKind
+	}{
+		{err: RedisError("LOADING Redis is loading the dataset in memory"), want: errorLoading},
+		{err: RedisError("READONLY You can't write against a read only replica."), want: errorReadOnly},
+		{err: RedisError("CLUSTERDOWN The cluster is down"), want: errorClusterDown},
+		{err: RedisError("TRYAGAIN Multiple keys request during rehashing of slot"), want: errorTryAgain},
+		{err: RedisError("MASTERDOWN Link with MASTER is down and replica-serve-stale-data is set to 'no'."), want: errorMasterDown},
+		{err: RedisError("ERR LOADING is not a command"), want: errorOther},
+		{err: &MovedError{Slot: 1, Addr: "127.0.0.1:7000"}, want: errorOther},
+		{err: io.EOF, want: errorOther},
+	}
+	for _, tt := range tests {
+		if got := replyErrorKind(tt.err); got != tt.want {
+			t.Errorf("replyErrorKind(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+	if !isReplicaUnavailable(RedisError("LOADING Redis is loading the dataset in memory")) || isReplicaUnavailable(RedisError("READONLY You can't write against a read only replica.")) {
+		t.Error("isReplicaUnavailable() should only hold for replicas that can't serve reads")
+	}
+}
@@ -0,0 +1,299 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ScanOptions filter the keys walked by Scan. The zero value walks every key with the server's default page size.
+type ScanOptions struct {
+	Match string // glob-style pattern, applied by the server after a page is read
+	Count int    // keys the server looks at per call, a hint
+	Type  string // only keys of this type (string, list, hash...), Redis 6.0+
+}
+
+func (opts ScanOptions) args() []interface{} {
+	var args []interface{}
+	if opts.Match != "" {
+		args = append(args, "MATCH", opts.Match)
+	}
+	if opts.Count > 0 {
+		args = append(args, "COUNT", opts.Count)
+	}
+	if opts.Type != "" {
+		args = append(args, "TYPE", opts.Type)
+	}
+	return args
+}
+
+// ScanIterator walks the keyspace with SCAN, one page per call, so it never blocks the server. A key can be
+// returned more than once, and keys added or removed during the walk may be missed.
+//
+//	it := client.Scan(ctx, resp.ScanOptions{Match: "session:*"})
+//	for it.Next() {
+//		fmt.Println(it.Key())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ScanIterator struct {
+	cursor scanCursor
+	key    string
+}
+
+// Scan returns an iterator over the keys matching opts, nothing is sent before the first call to Next.
+func (client *Client) Scan(ctx context.Context, opts ScanOptions) *ScanIterator {
+	return &ScanIterator{cursor: newScanCursor(ctx, client, []interface{}{"SCAN"}, opts.args())}
+}
+
+// Next moves to the next key, it returns false at the end of the walk or on error, see Err.
+func (it *ScanIterator) Next() bool {
+	if !it.cursor.fill() {
+		return false
+	}
+	it.key = it.cursor.page[0]
+	it.cursor.page = it.cursor.page[1:]
+	return true
+}
+
+// Key returns the current key.
+func (it *ScanIterator) Key() string {
+	return it.key
+}
+
+// Err returns the error that stopped the walk, if any.
+func (it *ScanIterator) Err() error {
+	return it.cursor.err
+}
+
+// scanCursor runs the cursor loop of the SCAN family on client, args is the command up to the cursor (SCAN,
+// HSCAN key...) and options what follows it.
+type scanCursor struct {
+	ctx     context.Context
+	client  *Client
+	target  *Client // the server every page goes to, a cursor is only valid there. Chosen with the first page
+	done    bool
+	args    []interface{}
+	options []interface{}
+	cursor  string
+	page    []string
+	err     error
+}
+
+func newScanCursor(ctx context.Context, client *Client, args []interface{}, options []interface{}) scanCursor {
+	return scanCursor{ctx: ctx, client: client, args: args, options: options, cursor: "0"}
+}
+
+// fill reads pages until page is not empty, it returns false at the end of the walk or on error.
+func (sc *scanCursor) fill() bool {
+	for len(sc.page) == 0 {
+		if sc.err != nil || sc.done {
+			return false
+		}
+		args := append(append(sc.args[:len(sc.args):len(sc.args)], sc.cursor), sc.options...)
+		reply, err := sc.do(args)
+		if err != nil {
+			sc.err = err
+			return false
+		}
+		if sc.cursor, sc.page, err = parseScanReply(reply); err != nil {
+			sc.err = fmt.Errorf("%s: %w", strings.ToLower(fmt.Sprint(sc.args[0])), err)
+			return false
+		}
+		sc.done = sc.cursor == "0"
+	}
+	return true
+}
+
+// do sends a page request to the target of the walk. The first page picks it: a replica when there are some,
+// like other reads, the primary otherwise or when the replica fails.
+func (sc *scanCursor) do(args []interface{}) (*Reply, error) {
+	if sc.target == nil {
+		if replica := sc.client.replicas.route(); replica != nil {
+			reply, err := replica.do(sc.ctx, args)
+			if !fallsBack(sc.ctx, err) {
+				sc.target = replica
+				return reply, err
+			}
+			sc.client.replicas.failed(replica)
+		}
+		sc.target = sc.client
+	}
+	return sc.target.do(sc.ctx, args)
+}
+
+// parseScanReply splits a SCAN family reply into the next cursor and the page.
+func parseScanReply(reply *Reply) (string, []string, error) {
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 2 {
+		return "", nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	cursor, err := parts[0].Text()
+	if err != nil {
+		return "", nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	page, err := parts[1].StringSlice()
+	if err != nil {
+		return "", nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	return cursor, page, nil
+}
+
+// HScanOptions filter the fields walked by HScan.
+type HScanOptions struct {
+	Match    string // glob-style pattern on the field names
+	Count    int    // fields the server looks at per call, a hint
+	NoValues bool   // only return the field names, Redis 7.4+
+}
+
+func (opts HScanOptions) args() []interface{} {
+	var args []interface{}
+	if opts.Match != "" {
+		args = append(args, "MATCH", opts.Match)
+	}
+	if opts.Count > 0 {
+		args = append(args, "COUNT", opts.Count)
+	}
+	if opts.NoValues {
+		args = append(args, "NOVALUES")
+	}
+	return args
+}
+
+// HScanIterator walks the fields of a hash with HSCAN, with the same guarantees as ScanIterator.
+type HScanIterator struct {
+	cursor   scanCursor
+	noValues bool
+	field    string
+	value    string
+}
+
+// HScan returns an iterator over the fields of the hash at key matching opts, nothing is sent before the
+// first call to Next. A missing key is walked as an empty hash.
+func (client *Client) HScan(ctx context.Context, key string, opts HScanOptions) *HScanIterator {
+	return &HScanIterator{
+		cursor:   newScanCursor(ctx, client, []interface{}{"HSCAN", key}, opts.args()),
+		noValues: opts.NoValues,
+	}
+}
+
+// Next moves to the next field, it returns false at the end of the walk or on error, see Err.
+func (it *HScanIterator) Next() bool {
+	if !it.cursor.fill() {
+		return false
+	}
+	page := it.cursor.page
+	if it.noValues {
+		it.field, it.cursor.page = page[0], page[1:]
+		return true
+	}
+	if len(page) < 2 {
+		it.cursor.err = fmt.Errorf("hscan: unexpected response from server, field %q without a value", page[0])
+		it.cursor.page = nil
+		return false
+	}
+	it.field, it.value, it.cursor.page = page[0], page[1], page[2:]
+	return true
+}
+
+// Field returns the current field.
+func (it *HScanIterator) Field() string {
+	return it.field
+}
+
+// Value returns the value of the current field, empty with HScanOptions.NoValues.
+func (it *HScanIterator) Value() string {
+	return it.value
+}
+
+// Err returns the error that stopped the walk, if any.
+func (it *HScanIterator) Err() error {
+	return it.cursor.err
+}
+
+// MemberScanOptions filter the members walked by SScan and ZScan.
+type MemberScanOptions struct {
+	Match string // glob-style pattern on the members
+	Count int    // members the server looks at per call, a hint
+}
+
+func (opts MemberScanOptions) args() []interface{} {
+	return ScanOptions{Match: opts.Match, Count: opts.Count}.args()
+}
+
+// SScanIterator walks the members of a set with SSCAN, with the same guarantees as ScanIterator.
+type SScanIterator struct {
+	cursor scanCursor
+	member string
+}
+
+// SScan returns an iterator over the members of the set at key matching opts, nothing is sent before the
+// first call to Next. A missing key is walked as an empty set.
+func (client *Client) SScan(ctx context.Context, key string, opts MemberScanOptions) *SScanIterator {
+	return &SScanIterator{cursor: newScanCursor(ctx, client, []interface{}{"SSCAN", key}, opts.args())}
+}
+
+// Next moves to the next member, it returns false at the end of the walk or on error, see Err.
+func (it *SScanIterator) Next() bool {
+	if !it.cursor.fill() {
+		return false
+	}
+	it.member = it.cursor.page[0]
+	it.cursor.page = it.cursor.page[1:]
+	return true
+}
+
+// Member returns the current member.
+func (it *SScanIterator) Member() string {
+	return it.member
+}
+
+// Err returns the error that stopped the walk, if any.
+func (it *SScanIterator) Err() error {
+	return it.cursor.err
+}
+
+// ZScanIterator walks the members of a sorted set with ZSCAN, with the same guarantees as ScanIterator.
+// Members come in no particular order.
+type ZScanIterator struct {
+	cursor scanCursor
+	member Member
+}
+
+// ZScan returns an iterator over the members of the sorted set at key matching opts, with their scores,
+// nothing is sent before the first call to Next. A missing key is walked as an empty sorted set.
+func (client *Client) ZScan(ctx context.Context, key string, opts MemberScanOptions) *ZScanIterator {
+	return &ZScanIterator{cursor: newScanCursor(ctx, client, []interface{}{"ZSCAN", key}, opts.args())}
+}
+
+// Next moves to the next member, it returns false at the end of the walk or on error, see Err.
+func (it *ZScanIterator) Next() bool {
+	if !it.cursor.fill() {
+		return false
+	}
+	page := it.cursor.page
+	if len(page) < 2 {
+		it.cursor.err = fmt.Errorf("zscan: unexpected response from server, member %q without a score", page[0])
+		it.cursor.page = nil
+		return false
+	}
+	member, err := newMember(NewReply(page[0]), NewReply(page[1]))
+	if err != nil {
+		it.cursor.err = fmt.Errorf("zscan: unexpected response from server, score %q", page[1])
+		it.cursor.page = nil
+		return false
+	}
+	it.member, it.cursor.page = member, page[2:]
+	return true
+}
+
+// Member returns the current member with its score.
+func (it *ZScanIterator) Member() Member {
+	return it.member
+}
+
+// Err returns the error that stopped the walk, if any.
+func (it *ZScanIterator) Err() error {
+	return it.cursor.err
+}
@@ -0,0 +1,220 @@
+package resp
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestScanOptions_Args(t *testing.T) {
+	tests := []struct {
+		opts ScanOptions
+		want []interface{}
+	}{
+		{opts: ScanOptions{}, want: nil},
+		{opts: ScanOptions{Match: "user:*"}, want: []interface{}{"MATCH", "user:*"}},
+		{opts: ScanOptions{Match: "user:*", Count: 100, Type: "hash"}, want: []interface{}{"MATCH", "user:*", "COUNT", 100, "TYPE", "hash"}},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.args(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.args() = %v, want %v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestClient_Scan(t *testing.T) {
+	var sent []string
+	SendFunc = func(command string) error {
+		sent = append(sent, command)
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(
+		[]interface{}{"17", []interface{}{"a", "b"}},
+		[]interface{}{"9", []interface{}{}}, // pages can be empty before the end
+		[]interface{}{"0", []interface{}{"c"}},
+	)
+	it := client.Scan(context.Background(), ScanOptions{Match: "*", Count: 10})
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if err := it.Err(); err != nil || !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Errorf("Scan got = %q, %v", keys, err)
+	}
+	want := []string{
+		"*6\r\n$4\r\nSCAN\r\n$1\r\n0\r\n$5\r\nMATCH\r\n$1\r\n*\r\n$5\r\nCOUNT\r\n$2\r\n10\r\n",
+		"*6\r\n$4\r\nSCAN\r\n$2\r\n17\r\n$5\r\nMATCH\r\n$1\r\n*\r\n$5\r\nCOUNT\r\n$2\r\n10\r\n",
+		"*6\r\n$4\r\nSCAN\r\n$1\r\n9\r\n$5\r\nMATCH\r\n$1\r\n*\r\n$5\r\nCOUNT\r\n$2\r\n10\r\n",
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("Scan sent %q, want %q", sent, want)
+	}
+	if it.Next() {
+		t.Error("Next() expected false after the end of the walk")
+	}
+
+	ReceiveFunc = replySequence([]interface{}{"5", []interface{}{"a"}}, RedisError("ERR busy"))
+	it = client.Scan(context.Background(), ScanOptions{})
+	if !it.Next() || it.Key() != "a" {
+		t.Fatalf("Next() got = %q, %v", it.Key(), it.Err())
+	}
+	if it.Next() || it.Err() == nil {
+		t.Error("Next() expected to stop on the error")
+	}
+
+	ReceiveFunc = replySequence("OK")
+	it = client.Scan(context.Background(), ScanOptions{})
+	if it.Next() || it.Err() == nil {
+		t.Error("Next() expected an error for a malformed reply")
+	}
+}
+
+func TestClient_HScan(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(
+		[]interface{}{"4", []interface{}{"name", "ada", "age", "36"}},
+		[]interface{}{"0", []interface{}{"lang", "go"}},
+	)
+	it := client.HScan(context.Background(), "user:1", HScanOptions{Match: "*"})
+	got := make(map[string]string)
+	for it.Next() {
+		got[it.Field()] = it.Value()
+	}
+	if err := it.Err(); err != nil || !reflect.DeepEqual(got, map[string]string{"name": "ada", "age": "36", "lang": "go"}) {
+		t.Errorf("HScan got = %v, %v", got, err)
+	}
+	if want := "*5\r\n$5\r\nHSCAN\r\n$6\r\nuser:1\r\n$1\r\n4\r\n$5\r\nMATCH\r\n$1\r\n*\r\n"; sent != want {
+		t.Errorf("HScan sent %q, want %q", sent, want)
+	}
+
+	ReceiveFunc = replySequence([]interface{}{"0", []interface{}{"name", "age"}})
+	it = client.HScan(context.Background(), "user:1", HScanOptions{NoValues: true})
+	var fields []string
+	for it.Next() {
+		fields = append(fields, it.Field())
+	}
+	if err := it.Err(); err != nil || !reflect.DeepEqual(fields, []string{"name", "age"}) {
+		t.Errorf("HScan got = %q, %v", fields, err)
+	}
+	if want := "*4\r\n$5\r\nHSCAN\r\n$6\r\nuser:1\r\n$1\r\n0\r\n$8\r\nNOVALUES\r\n"; sent != want {
+		t.Errorf("HScan sent %q, want %q", sent, want)
+	}
+
+	ReceiveFunc = replySequence([]interface{}{"0", []interface{}{"name"}})
+	it = client.HScan(context.Background(), "user:1", HScanOptions{})
+	if it.Next() || it.Err() == nil {
+		t.Error("Next() expected an error for a field without a value")
+	}
+}
+
+func TestClient_SScan(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(
+		[]interface{}{"6", []interface{}{"go", "rust"}},
+		[]interface{}{"0", []interface{}{"zig"}},
+	)
+	it := client.SScan(context.Background(), "langs", MemberScanOptions{Count: 50})
+	var members []string
+	for it.Next() {
+		members = append(members, it.Member())
+	}
+	if err := it.Err(); err != nil || !reflect.DeepEqual(members, []string{"go", "rust", "zig"}) {
+		t.Errorf("SScan got = %q, %v", members, err)
+	}
+	if want := "*5\r\n$5\r\nSSCAN\r\n$5\r\nlangs\r\n$1\r\n6\r\n$5\r\nCOUNT\r\n$2\r\n50\r\n"; sent != want {
+		t.Errorf("SScan sent %q, want %q", sent, want)
+	}
+}
+
+func TestClient_ZScan(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(
+		[]interface{}{"3", []interface{}{"ada", "1.5", "bob", "inf"}},
+		[]interface{}{"0", []interface{}{"cy", "-2"}},
+	)
+	it := client.ZScan(context.Background(), "board", MemberScanOptions{Match: "*"})
+	var members []Member
+	for it.Next() {
+		members = append(members, it.Member())
+	}
+	want := []Member{{Value: "ada", Score: 1.5}, {Value: "bob", Score: math.Inf(1)}, {Value: "cy", Score: -2}}
+	if err := it.Err(); err != nil || !reflect.DeepEqual(members, want) {
+		t.Errorf("ZScan got = %+v, %v, want %+v", members, err, want)
+	}
+	if want := "*5\r\n$5\r\nZSCAN\r\n$5\r\nboard\r\n$1\r\n3\r\n$5\r\nMATCH\r\n$1\r\n*\r\n"; sent != want {
+		t.Errorf("ZScan sent %q, want %q", sent, want)
+	}
+
+	for _, page := range [][]interface{}{{"ada"}, {"ada", "high"}} {
+		ReceiveFunc = replySequence([]interface{}{"0", page})
+		it = client.ZScan(context.Background(), "board", MemberScanOptions{})
+		if it.Next() || it.Err() == nil {
+			t.Errorf("Next() expected an error for the page %q", page)
+		}
+	}
+}
+
+func TestClient_ScanReplicas(t *testing.T) {
+	SendFunc = func(command string) error {
+		t.Errorf("the primary got %q, want every page on one replica", command)
+		return nil
+	}
+	client := newMockClient(1, "")
+	defer client.Close()
+	// the replicas hold the same keys, a page tells which one served it
+	for _, name := range []string{"r1", "r2"} {
+		replica, servers := newPipeClient("")
+		replica.pool = newPool(replica.newConn, &Options{MaxActive: 1, MaxIdle: 1, PoolTimeout: DefaultPoolTimeout})
+		go func(name string) {
+			for server := range servers {
+				for {
+					value, err := server.ReceiveValue(context.Background())
+					if err != nil {
+						break // closed by the client
+					}
+					args, _ := NewReply(value).StringSlice()
+					if args[1] == "0" {
+						_, _ = server.rw.WriteString("*2\r\n$1\r\n7\r\n*1\r\n$4\r\na@" + name + "\r\n")
+					} else {
+						_, _ = server.rw.WriteString("*2\r\n$1\r\n0\r\n*1\r\n$4\r\nb@" + name + "\r\n")
+					}
+					_ = server.rw.Flush()
+				}
+			}
+		}(name)
+		client.replicas.add(replica)
+	}
+
+	// before the first probe reads are spread over the replicas, the walks still stick to one
+	for i := 0; i < 2; i++ {
+		it := client.Scan(context.Background(), ScanOptions{})
+		var keys []string
+		for it.Next() {
+			keys = append(keys, it.Key())
+		}
+		if err := it.Err(); err != nil || len(keys) != 2 || keys[0][1:] != keys[1][1:] {
+			t.Errorf("Scan got = %q, %v, want both pages from the same replica", keys, err)
+		}
+	}
+}
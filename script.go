@@ -0,0 +1,129 @@
+package resp
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Script is a Lua script run by its SHA1 digest, so the source is only sent when the server
+// doesn't have it cached yet.
+type Script struct {
+	src  string
+	hash string
+}
+
+func NewScript(src string) *Script {
+	sum := sha1.Sum([]byte(src))
+	return &Script{src: src, hash: hex.EncodeToString(sum[:])}
+}
+
+// Hash returns the SHA1 digest of the script, as used by EVALSHA.
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+// Load caches the script on the server with SCRIPT LOAD.
+func (s *Script) Load(ctx context.Context, client IClient) error {
+	_, err := client.ScriptLoad(ctx, s.src)
+	return err
+}
+
+// Run runs the script with EVALSHA. When the server replies NOSCRIPT the script is sent with EVAL instead,
+// which also caches it for the next runs.
+func (s *Script) Run(ctx context.Context, client IClient, keys []string, args ...interface{}) (*Reply, error) {
+	reply, err := client.EvalSha(ctx, s.hash, keys, args...)
+	var redisErr RedisError
+	if errors.As(err, &redisErr) && redisErr.Code() == "NOSCRIPT" {
+		return client.Eval(ctx, s.src, keys, args...)
+	}
+	return reply, err
+}
+
+// Eval runs a Lua script, keys are available to the script as KEYS and args as ARGV.
+func (client *Client) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (*Reply, error) {
+	return client.Do(ctx, evalArgs("EVAL", script, keys, args)...)
+}
+
+// EvalSha runs a script cached on the server by its SHA1 digest, see Eval.
+func (client *Client) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) (*Reply, error) {
+	return client.Do(ctx, evalArgs("EVALSHA", sha1, keys, args)...)
+}
+
+// ScriptLoad caches script on the server and returns its SHA1 digest.
+func (client *Client) ScriptLoad(ctx context.Context, script string) (string, error) {
+	reply, err := client.Do(ctx, "SCRIPT", "LOAD", script)
+	if err != nil {
+		return "", err
+	}
+	hash, err := reply.Text()
+	if err != nil {
+		return "", fmt.Errorf("scriptLoad: unexpected response from server %v", reply.Value())
+	}
+	return hash, nil
+}
+
+// ScriptExists reports for each digest whether the script is cached on the server.
+func (client *Client) ScriptExists(ctx context.Context, hashes ...string) ([]bool, error) {
+	args := make([]interface{}, 0, len(hashes)+2)
+	args = append(args, "SCRIPT", "EXISTS")
+	for _, hash := range hashes {
+		args = append(args, hash)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := reply.Array()
+	if err != nil {
+		return nil, fmt.Errorf("scriptExists: unexpected response from server %v", reply.Value())
+	}
+	exists := make([]bool, len(results))
+	for i, result := range results {
+		if exists[i], err = result.Bool(); err != nil {
+			return nil, fmt.Errorf("scriptExists: unexpected response from server %v", reply.Value())
+		}
+	}
+	return exists, nil
+}
+
+// ScriptFlush empties the script cache, async lets the server free it in the background.
+func (client *Client) ScriptFlush(ctx context.Context, async bool) error {
+	mode := "SYNC"
+	if async {
+		mode = "ASYNC"
+	}
+	reply, err := client.Do(ctx, "SCRIPT", "FLUSH", mode)
+	if err != nil {
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("scriptFlush: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// ScriptKill stops the script currently running, as long as it didn't write yet.
+func (client *Client) ScriptKill(ctx context.Context) error {
+	reply, err := client.Do(ctx, "SCRIPT", "KILL")
+	if err != nil {
+		return err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return fmt.Errorf("scriptKill: unexpected response from server %v", reply.Value())
+	}
+	return nil
+}
+
+// evalArgs builds the arguments shared by EVAL-like commands: <script> <numkeys> <key>... <arg>...
+func evalArgs(command string, script string, keys []string, args []interface{}) []interface{} {
+	cmd := make([]interface{}, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, command, script, len(keys))
+	for _, key := range keys {
+		cmd = append(cmd, key)
+	}
+	return append(cmd, args...)
+}
@@ -0,0 +1,161 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewScript(t *testing.T) {
+	script := NewScript("return 1")
+	if script.Hash() != "e0e1f9fabfc9d4800c877a703b823ac0578ff8db" {
+		t.Errorf("Hash() got = %s", script.Hash())
+	}
+}
+
+func TestClient_Eval(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return int64(3), nil
+	}
+	client := newMockClient(2, "password")
+	reply, err := client.Eval(context.Background(), "return redis.call('INCRBY', KEYS[1], ARGV[1])", []string{"counter"}, 3)
+	if err != nil {
+		t.Fatalf("Eval returned error: %s", err)
+	}
+	if n, _ := reply.Int(); n != 3 {
+		t.Errorf("Eval got = %v", reply.Value())
+	}
+	if !strings.HasSuffix(sent, "$1\r\n1\r\n$7\r\ncounter\r\n$1\r\n3\r\n") {
+		t.Errorf("Eval sent %q", sent)
+	}
+}
+
+func TestScript_Run(t *testing.T) {
+	t.Run("cached script", func(t *testing.T) {
+		var sent []string
+		SendFunc = func(command string) error {
+			sent = append(sent, command)
+			return nil
+		}
+		ReceiveFunc = replySequence("ok")
+		client := newMockClient(2, "password")
+		reply, err := NewScript("return 'ok'").Run(context.Background(), client, nil)
+		if err != nil {
+			t.Fatalf("Run returned error: %s", err)
+		}
+		if text, _ := reply.Text(); text != "ok" {
+			t.Errorf("Run got = %v", reply.Value())
+		}
+		if len(sent) != 1 || !strings.Contains(sent[0], "EVALSHA") {
+			t.Errorf("Run sent %q, want a single EVALSHA", sent)
+		}
+	})
+
+	t.Run("falls back to EVAL on NOSCRIPT", func(t *testing.T) {
+		var sent []string
+		SendFunc = func(command string) error {
+			sent = append(sent, command)
+			return nil
+		}
+		ReceiveFunc = replySequence(RedisError("NOSCRIPT No matching script. Please use EVAL."), "ok")
+		client := newMockClient(2, "password")
+		reply, err := NewScript("return 'ok'").Run(context.Background(), client, nil)
+		if err != nil {
+			t.Fatalf("Run returned error: %s", err)
+		}
+		if text, _ := reply.Text(); text != "ok" {
+			t.Errorf("Run got = %v", reply.Value())
+		}
+		if len(sent) != 2 || !strings.Contains(sent[1], "$4\r\nEVAL\r\n") {
+			t.Errorf("Run sent %q, want EVALSHA then EVAL", sent)
+		}
+	})
+
+	t.Run("returns other errors", func(t *testing.T) {
+		var sent []string
+		SendFunc = func(command string) error {
+			sent = append(sent, command)
+			return nil
+		}
+		scriptErr := RedisError("ERR user_script:1: NOSCRIPT raised by the script")
+		ReceiveFunc = replySequence(scriptErr)
+		client := newMockClient(2, "password")
+		if _, err := NewScript("return redis.error_reply('NOSCRIPT')").Run(context.Background(), client, nil); !errors.Is(err, scriptErr) {
+			t.Errorf("Run error = %v, want %v", err, scriptErr)
+		}
+		if len(sent) != 1 {
+			t.Errorf("Run sent %q, want a single EVALSHA", sent)
+		}
+	})
+}
+
+func TestClient_ScriptLoad(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return "e0e1f9fabfc9d4800c877a703b823ac0578ff8db", nil
+	}
+	client := newMockClient(2, "password")
+	hash, err := client.ScriptLoad(context.Background(), "return 1")
+	if err != nil {
+		t.Fatalf("ScriptLoad returned error: %s", err)
+	}
+	if hash != NewScript("return 1").Hash() {
+		t.Errorf("ScriptLoad got = %s", hash)
+	}
+}
+
+func TestClient_ScriptExists(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return []interface{}{int64(1), int64(0)}, nil
+	}
+	client := newMockClient(2, "password")
+	exists, err := client.ScriptExists(context.Background(), "a", "b")
+	if err != nil {
+		t.Fatalf("ScriptExists returned error: %s", err)
+	}
+	if len(exists) != 2 || !exists[0] || exists[1] {
+		t.Errorf("ScriptExists got = %v", exists)
+	}
+}
+
+func TestClient_ScriptFlush(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return "OK", nil
+	}
+	client := newMockClient(2, "password")
+	if err := client.ScriptFlush(context.Background(), true); err != nil {
+		t.Fatalf("ScriptFlush returned error: %s", err)
+	}
+	if !strings.HasSuffix(sent, "$5\r\nASYNC\r\n") {
+		t.Errorf("ScriptFlush sent %q", sent)
+	}
+}
+
+func TestClient_ScriptKill(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return nil, RedisError("NOTBUSY No scripts in execution right now.")
+	}
+	client := newMockClient(2, "password")
+	if err := client.ScriptKill(context.Background()); err == nil || !strings.HasPrefix(err.Error(), "NOTBUSY") {
+		t.Errorf("ScriptKill got = %v, want NOTBUSY error", err)
+	}
+}
@@ -0,0 +1,110 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SInter returns the members present in all the sets of keys, a missing key is an empty set.
+func (client *Client) SInter(ctx context.Context, keys ...string) ([]string, error) {
+	return client.setAlgebra(ctx, "SINTER", keys)
+}
+
+// SUnion returns the members of any of the sets of keys.
+func (client *Client) SUnion(ctx context.Context, keys ...string) ([]string, error) {
+	return client.setAlgebra(ctx, "SUNION", keys)
+}
+
+// SDiff returns the members of the first set of keys that are in none of the others.
+func (client *Client) SDiff(ctx context.Context, keys ...string) ([]string, error) {
+	return client.setAlgebra(ctx, "SDIFF", keys)
+}
+
+func (client *Client) setAlgebra(ctx context.Context, command string, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no key", strings.ToLower(command))
+	}
+	reply, err := client.Do(ctx, keyArgs(command, keys)...)
+	if err != nil {
+		return nil, err
+	}
+	members, err := reply.StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return members, nil
+}
+
+// SInterStore stores the intersection of the sets of keys in destination, replacing it, and returns its size.
+func (client *Client) SInterStore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return client.setAlgebraStore(ctx, "SINTERSTORE", destination, keys)
+}
+
+// SUnionStore stores the union of the sets of keys in destination, replacing it, and returns its size.
+func (client *Client) SUnionStore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return client.setAlgebraStore(ctx, "SUNIONSTORE", destination, keys)
+}
+
+// SDiffStore stores the difference of the sets of keys in destination, replacing it, and returns its size.
+func (client *Client) SDiffStore(ctx context.Context, destination string, keys ...string) (int, error) {
+	return client.setAlgebraStore(ctx, "SDIFFSTORE", destination, keys)
+}
+
+func (client *Client) setAlgebraStore(ctx context.Context, command string, destination string, keys []string) (int, error) {
+	if len(keys) == 0 {
+		return 0, fmt.Errorf("%s: no key", strings.ToLower(command))
+	}
+	return client.doInt(ctx, keyArgs(command, append([]string{destination}, keys...))...)
+}
+
+// SInterCard returns the size of the intersection of the sets of keys without sending its members. The
+// count stops at limit when it is positive, which saves work when only a threshold matters (Redis 7.0+).
+func (client *Client) SInterCard(ctx context.Context, limit int, keys ...string) (int, error) {
+	if len(keys) == 0 {
+		return 0, errors.New("sintercard: no key")
+	}
+	args := make([]interface{}, 0, len(keys)+4)
+	args = append(args, "SINTERCARD", len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	if limit > 0 {
+		args = append(args, "LIMIT", limit)
+	}
+	return client.doInt(ctx, args...)
+}
+
+// SRandMember returns a random member of the set at key, or returns ErrNil when it doesn't exist.
+func (client *Client) SRandMember(ctx context.Context, key string) (string, error) {
+	return client.randomMember(ctx, "SRANDMEMBER", key)
+}
+
+// SRandMembers returns up to count distinct random members of the set at key when count is positive, and
+// exactly -count members, possibly repeated, when it is negative. A missing key returns an empty slice.
+func (client *Client) SRandMembers(ctx context.Context, key string, count int) ([]string, error) {
+	return client.randomMembers(ctx, "SRANDMEMBER", key, count)
+}
+
+// randomMember runs a sampling command without a count, HRANDFIELD, SRANDMEMBER or ZRANDMEMBER.
+func (client *Client) randomMember(ctx context.Context, command string, key string) (string, error) {
+	reply, err := client.Do(ctx, command, key)
+	if err != nil {
+		return "", err
+	}
+	return reply.Text()
+}
+
+// randomMembers is randomMember with a count.
+func (client *Client) randomMembers(ctx context.Context, command string, key string, count int) ([]string, error) {
+	reply, err := client.Do(ctx, command, key, count)
+	if err != nil {
+		return nil, err
+	}
+	members, err := reply.StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return members, nil
+}
@@ -0,0 +1,96 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestClient_SetAlgebra(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	members := []struct {
+		name string
+		call func(ctx context.Context, keys ...string) ([]string, error)
+	}{
+		{name: "SINTER", call: client.SInter},
+		{name: "SUNION", call: client.SUnion},
+		{name: "SDIFF", call: client.SDiff},
+	}
+	for _, tt := range members {
+		ReceiveFunc = replySequence([]interface{}{"a", "b"})
+		if got, err := tt.call(context.Background(), "s1", "s2"); err != nil || !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("%s got = %q, %v", tt.name, got, err)
+		}
+		if want := []string{tt.name, "s1", "s2"}; !reflect.DeepEqual(sent, want) {
+			t.Errorf("%s sent %q, want %q", tt.name, sent, want)
+		}
+		if _, err := tt.call(context.Background()); err == nil {
+			t.Errorf("%s expected an error without keys", tt.name)
+		}
+	}
+
+	stores := []struct {
+		name string
+		call func(ctx context.Context, destination string, keys ...string) (int, error)
+	}{
+		{name: "SINTERSTORE", call: client.SInterStore},
+		{name: "SUNIONSTORE", call: client.SUnionStore},
+		{name: "SDIFFSTORE", call: client.SDiffStore},
+	}
+	for _, tt := range stores {
+		ReceiveFunc = replySequence(int64(2))
+		if got, err := tt.call(context.Background(), "dst", "s1", "s2"); err != nil || got != 2 {
+			t.Errorf("%s got = %d, %v", tt.name, got, err)
+		}
+		if want := []string{tt.name, "dst", "s1", "s2"}; !reflect.DeepEqual(sent, want) {
+			t.Errorf("%s sent %q, want %q", tt.name, sent, want)
+		}
+	}
+}
+
+func TestClient_SInterCard(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(10), int64(3))
+	if got, err := client.SInterCard(context.Background(), 10, "s1", "s2"); err != nil || got != 10 {
+		t.Errorf("SInterCard got = %d, %v", got, err)
+	}
+	if want := []string{"SINTERCARD", "2", "s1", "s2", "LIMIT", "10"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("SInterCard sent %q, want %q", sent, want)
+	}
+	if got, err := client.SInterCard(context.Background(), 0, "s1"); err != nil || got != 3 {
+		t.Errorf("SInterCard got = %d, %v", got, err)
+	}
+	if want := []string{"SINTERCARD", "1", "s1"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("SInterCard sent %q, want %q", sent, want)
+	}
+}
+
+func TestClient_SRandMember(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("a", nil, []interface{}{}, []interface{}{"a", "a", "b"})
+	if got, err := client.SRandMember(context.Background(), "s"); err != nil || got != "a" {
+		t.Errorf("SRandMember() got = %q, %v", got, err)
+	}
+	if _, err := client.SRandMember(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("SRandMember() error = %v, want %v", err, ErrNil)
+	}
+	if got, err := client.SRandMembers(context.Background(), "missing", 3); err != nil || len(got) != 0 {
+		t.Errorf("SRandMembers() got = %q, %v", got, err)
+	}
+	if got, err := client.SRandMembers(context.Background(), "s", -3); err != nil || !reflect.DeepEqual(got, []string{"a", "a", "b"}) {
+		t.Errorf("SRandMembers() got = %q, %v", got, err)
+	}
+	if want := []string{"SRANDMEMBER", "s", "-3"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("SRandMembers() sent %q, want %q", sent, want)
+	}
+}
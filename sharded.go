@@ -0,0 +1,127 @@
+package resp
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ShardedClient spreads keys over several standalone redis servers with a ketama-style consistent hash ring:
+// adding or removing a server only moves the keys of the ring segments it owns. Each server has its own client.
+// Commands touching several keys only work when all of them live on the same shard: like in a cluster, only
+// the {hash tag} of a key is hashed when it has one, so {user:1}:name and {user:1}:email share a shard.
+type ShardedClient struct {
+	shards []*Client
+	ring   []ringPoint
+}
+
+type ringPoint struct {
+	hash  uint32
+	shard *Client
+}
+
+// NewShardedClient connects to every address, each shard being a client configured by opts like NewClient.
+// virtualNodes is the number of points each server gets on the ring, more points give a more even spread (160 is
+// the usual ketama value); values below 1 mean a single point.
+//
+//	resp.NewShardedClient([]string{"10.0.0.1:6379", "10.0.0.2:6379"}, 160, resp.WithAuth("secret"), resp.WithPoolSize(20))
+func NewShardedClient(addresses []string, virtualNodes int, opts ...Option) (*ShardedClient, error) {
+	if len(addresses) == 0 {
+		return nil, errors.New("sharded client: no address given")
+	}
+
+	shards := make([]*Client, 0, len(addresses))
+	for _, address := range addresses {
+		client, err := NewClient(address, opts...)
+		if err != nil {
+			for _, shard := range shards {
+				_ = shard.Close()
+			}
+			return nil, fmt.Errorf("sharded client: %s: %w", address, err)
+		}
+		shards = append(shards, client.(*Client))
+	}
+	return newShardedClient(shards, virtualNodes), nil
+}
+
+func newShardedClient(shards []*Client, virtualNodes int) *ShardedClient {
+	if virtualNodes < 1 {
+		virtualNodes = 1
+	}
+	sc := &ShardedClient{shards: shards}
+	for _, shard := range shards {
+		for i := 0; i < virtualNodes; i++ {
+			sc.ring = append(sc.ring, ringPoint{hash: ringHash(fmt.Sprintf("%s-%d", shard.address, i)), shard: shard})
+		}
+	}
+	sort.Slice(sc.ring, func(i, j int) bool {
+		return sc.ring[i].hash < sc.ring[j].hash
+	})
+	return sc
+}
+
+// Shard returns the client of the server owning key.
+func (sc *ShardedClient) Shard(key string) IClient {
+	return sc.shard(key)
+}
+
+// Do runs a command on the server owning key, which should be the key the command operates on.
+func (sc *ShardedClient) Do(ctx context.Context, key string, args ...interface{}) (*Reply, error) {
+	return sc.shard(key).Do(ctx, args...)
+}
+
+func (sc *ShardedClient) Set(ctx context.Context, key string, value string) error {
+	return sc.shard(key).Set(ctx, key, value)
+}
+
+func (sc *ShardedClient) SetWithTTL(ctx context.Context, key string, value string, ttl int) error {
+	return sc.shard(key).SetWithTTL(ctx, key, value, ttl)
+}
+
+func (sc *ShardedClient) Get(ctx context.Context, key string) (string, error) {
+	return sc.shard(key).Get(ctx, key)
+}
+
+func (sc *ShardedClient) Delete(ctx context.Context, key string) error {
+	return sc.shard(key).Delete(ctx, key)
+}
+
+func (sc *ShardedClient) Incr(ctx context.Context, key string) (int, error) {
+	return sc.shard(key).Incr(ctx, key)
+}
+
+func (sc *ShardedClient) Expire(ctx context.Context, key string, seconds int) (bool, error) {
+	return sc.shard(key).Expire(ctx, key, seconds)
+}
+
+// Close closes every shard, returning the first error.
+func (sc *ShardedClient) Close() error {
+	var firstErr error
+	for _, shard := range sc.shards {
+		if err := shard.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// shard walks the ring clockwise from the hash of the hash tag of key to the first server point.
+func (sc *ShardedClient) shard(key string) *Client {
+	hash := ringHash(hashTag(key))
+	i := sort.Search(len(sc.ring), func(i int) bool {
+		return sc.ring[i].hash >= hash
+	})
+	if i == len(sc.ring) {
+		i = 0
+	}
+	return sc.ring[i].shard
+}
+
+// ringHash is the ketama hash: the first 4 bytes of the MD5 digest, little endian.
+func ringHash(s string) uint32 {
+	sum := md5.Sum([]byte(s))
+	return binary.LittleEndian.Uint32(sum[:4])
+}
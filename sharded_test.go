@@ -0,0 +1,94 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func newMockShards(addresses ...string) []*Client {
+	shards := make([]*Client, len(addresses))
+	for i, address := range addresses {
+		shards[i] = newMockClient(1, "")
+		shards[i].address = address
+	}
+	return shards
+}
+
+func TestShardedClient_Distribution(t *testing.T) {
+	shards := newMockShards("10.0.0.1:6379", "10.0.0.2:6379", "10.0.0.3:6379")
+	sc := newShardedClient(shards, 160)
+
+	counts := make(map[*Client]int)
+	owners := make(map[string]*Client)
+	for i := 0; i < 3000; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		owner := sc.shard(key)
+		counts[owner]++
+		owners[key] = owner
+	}
+	for _, shard := range shards {
+		if counts[shard] < 700 || counts[shard] > 1300 {
+			t.Errorf("shard %s got %d of 3000 keys, want an even spread", shard.address, counts[shard])
+		}
+	}
+
+	// removing a shard only moves the keys it owned
+	reduced := newShardedClient(shards[:2], 160)
+	for key, owner := range owners {
+		if owner != shards[2] && reduced.shard(key) != owner {
+			t.Fatalf("key %s moved from %s to %s", key, owner.address, reduced.shard(key).address)
+		}
+	}
+}
+
+func TestShardedClient_Do(t *testing.T) {
+	SendFunc = func(command string) error {
+		return nil
+	}
+	ReceiveFunc = func() (interface{}, error) {
+		return "value", nil
+	}
+	sc := newShardedClient(newMockShards("a:6379", "b:6379"), 0)
+	if len(sc.ring) != 2 {
+		t.Fatalf("ring got %d points, want one per shard", len(sc.ring))
+	}
+	value, err := sc.Get(context.Background(), "key")
+	if err != nil || value != "value" {
+		t.Errorf("Get got = %q, %v", value, err)
+	}
+	if sc.Shard("key") != sc.shard("key") {
+		t.Errorf("Shard did not return the owning client")
+	}
+}
+
+func TestShardedClient_HashTag(t *testing.T) {
+	sc := newShardedClient(newMockShards("a:6379", "b:6379", "c:6379"), 160)
+
+	owners := make(map[*Client]bool)
+	for i := 0; i < 30; i++ {
+		owners[sc.shard(fmt.Sprintf("{user:1}:%d", i))] = true
+	}
+	if len(owners) != 1 || sc.shard("{user:1}:a") != sc.shard("user:1") {
+		t.Errorf("keys tagged {user:1} live on %d shards, want the shard of user:1", len(owners))
+	}
+	if sc.shard("{user:1}:a") != sc.shard("{user:1}:b") {
+		t.Error("{user:1}:a and {user:1}:b live on different shards")
+	}
+}
+
+func TestNewShardedClient(t *testing.T) {
+	sc, err := NewShardedClient([]string{"10.0.0.1:6379", "10.0.0.2:6379"}, 160, WithLazyConnect(), WithDB(2), WithPoolSize(7))
+	if err != nil {
+		t.Fatalf("NewShardedClient() error = %v", err)
+	}
+	defer sc.Close()
+	for _, shard := range sc.shards {
+		if shard.opts.DB != 2 || shard.opts.MaxActive != 7 {
+			t.Errorf("shard %s got options %+v, want every option applied", shard.address, shard.opts)
+		}
+	}
+	if _, err := NewShardedClient(nil, 160); err == nil {
+		t.Error("NewShardedClient() expected an error without addresses")
+	}
+}
@@ -0,0 +1,486 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Member is a member of a sorted set with its score.
+type Member struct {
+	Value string
+	Score float64
+}
+
+// ZPopOrder tells which end of a sorted set to pop from, see Client.ZMPop.
+type ZPopOrder string
+
+const (
+	ZPopMin ZPopOrder = "MIN"
+	ZPopMax ZPopOrder = "MAX"
+)
+
+// ZPopResult holds the members popped by ZMPop and the sorted set they were popped from.
+type ZPopResult struct {
+	Key     string
+	Members []Member
+}
+
+// ZMPop pops up to count members, 1 when count isn't positive, with the lowest or highest scores from the
+// first non-empty sorted set of keys. It returns ErrNil when they are all empty (Redis 7.0+).
+func (client *Client) ZMPop(ctx context.Context, order ZPopOrder, count int, keys ...string) (ZPopResult, error) {
+	if len(keys) == 0 {
+		return ZPopResult{}, errors.New("zmpop: no key to pop from")
+	}
+	reply, err := client.Do(ctx, append([]interface{}{"ZMPOP"}, mpopArgs(keys, string(order), count)...)...)
+	if err != nil {
+		return ZPopResult{}, err
+	}
+	return parseZPop("zmpop", reply)
+}
+
+// parseZPop converts a reply of ZMPOP or BZMPOP, nil when nothing was popped.
+func parseZPop(command string, reply *Reply) (ZPopResult, error) {
+	if reply.IsNil() {
+		return ZPopResult{}, ErrNil
+	}
+	key, elements, err := parseMPop(reply)
+	if err != nil {
+		return ZPopResult{}, fmt.Errorf("%s: %w", command, err)
+	}
+	result := ZPopResult{Key: key, Members: make([]Member, len(elements))}
+	for i, element := range elements {
+		if result.Members[i], err = parseMember(element); err != nil {
+			return ZPopResult{}, fmt.Errorf("%s: unexpected response from server %v", command, reply.Value())
+		}
+	}
+	return result, nil
+}
+
+// parseMember converts a [member, score] pair.
+func parseMember(reply *Reply) (Member, error) {
+	pair, err := reply.Array()
+	if err != nil || len(pair) != 2 {
+		return Member{}, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	return newMember(pair[0], pair[1])
+}
+
+// newMember converts the replies holding a member and its score.
+func newMember(value *Reply, score *Reply) (Member, error) {
+	member, err := value.Text()
+	if err != nil {
+		return Member{}, err
+	}
+	memberScore, err := score.Float64()
+	if err != nil {
+		return Member{}, err
+	}
+	return Member{Value: member, Score: memberScore}, nil
+}
+
+// parseMembers converts a flat array of member/score pairs, as returned by ZRANGE WITHSCORES or ZPOPMIN.
+func parseMembers(reply *Reply) ([]Member, error) {
+	replies, err := reply.Array()
+	if err != nil || len(replies)%2 != 0 {
+		return nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	members := make([]Member, len(replies)/2)
+	for i := range members {
+		if members[i], err = newMember(replies[2*i], replies[2*i+1]); err != nil {
+			return nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+		}
+	}
+	return members, nil
+}
+
+// ZAdd adds members to the sorted set at key, updating the score of those already in it, and returns how
+// many were added.
+func (client *Client) ZAdd(ctx context.Context, key string, members ...Member) (int, error) {
+	if len(members) == 0 {
+		return 0, errors.New("zadd: no member to add")
+	}
+	args := make([]interface{}, 0, 2*len(members)+2)
+	args = append(args, "ZADD", key)
+	for _, member := range members {
+		args = append(args, member.Score, member.Value)
+	}
+	return client.doInt(ctx, args...)
+}
+
+// ZAddOptions are the conditions of ZADD used by ZAddArgs and ZAddIncr, the zero value adds new members and
+// updates the scores of existing ones. NX and XX are exclusive, so are GT and LT, and NX can't be combined
+// with GT or LT.
+type ZAddOptions struct {
+	NX bool // only add new members, never update scores
+	XX bool // only update the scores of existing members, never add
+	GT bool // only update a score when the new one is greater, new members are still added
+	LT bool // only update a score when the new one is lower, new members are still added
+	CH bool // count the members whose score changed along with the added ones, see ZAddArgs
+}
+
+// args returns the ZADD command for key with the options, up to the score/member pairs.
+func (opts ZAddOptions) args(key string) ([]interface{}, error) {
+	if opts.NX && opts.XX {
+		return nil, errors.New("NX and XX are exclusive")
+	}
+	if opts.GT && opts.LT {
+		return nil, errors.New("GT and LT are exclusive")
+	}
+	if opts.NX && (opts.GT || opts.LT) {
+		return nil, errors.New("NX can't be combined with GT or LT")
+	}
+	args := []interface{}{"ZADD", key}
+	switch {
+	case opts.NX:
+		args = append(args, "NX")
+	case opts.XX:
+		args = append(args, "XX")
+	}
+	switch {
+	case opts.GT:
+		args = append(args, "GT")
+	case opts.LT:
+		args = append(args, "LT")
+	}
+	if opts.CH {
+		args = append(args, "CH")
+	}
+	return args, nil
+}
+
+// ZAddArgs adds or updates members of the sorted set at key under the conditions of opts and returns how
+// many were added, plus how many had their score changed with CH.
+func (client *Client) ZAddArgs(ctx context.Context, key string, opts ZAddOptions, members ...Member) (int, error) {
+	args, err := opts.args(key)
+	if err != nil {
+		return 0, fmt.Errorf("zaddArgs: %w", err)
+	}
+	if len(members) == 0 {
+		return 0, errors.New("zaddArgs: no member to add")
+	}
+	for _, member := range members {
+		args = append(args, member.Score, member.Value)
+	}
+	return client.doInt(ctx, args...)
+}
+
+// ZAddIncr adds member.Score to the score of member.Value like ZIncrBy, under the conditions of opts, and
+// returns the new score. It returns ErrNil when a condition prevented the update, e.g. the member already
+// exists with NX, which suits leaderboards that only keep a best score or counters that must exist.
+func (client *Client) ZAddIncr(ctx context.Context, key string, opts ZAddOptions, member Member) (float64, error) {
+	args, err := opts.args(key)
+	if err != nil {
+		return 0, fmt.Errorf("zaddIncr: %w", err)
+	}
+	reply, err := client.Do(ctx, append(args, "INCR", member.Score, member.Value)...)
+	if err != nil {
+		return 0, err
+	}
+	if reply.IsNil() {
+		return 0, ErrNil
+	}
+	score, err := reply.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("zaddIncr: unexpected response from server %v", reply.Value())
+	}
+	return score, nil
+}
+
+// ZScore returns the score of member in the sorted set at key, or ErrNil when it isn't in it.
+func (client *Client) ZScore(ctx context.Context, key string, member string) (float64, error) {
+	reply, err := client.Do(ctx, "ZSCORE", key, member)
+	if err != nil {
+		return 0, err
+	}
+	if reply.IsNil() {
+		return 0, ErrNil
+	}
+	score, err := reply.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("zscore: unexpected response from server %v", reply.Value())
+	}
+	return score, nil
+}
+
+// ZIncrBy adds increment to the score of member, 0 when it isn't in the sorted set at key, and returns the new score.
+func (client *Client) ZIncrBy(ctx context.Context, key string, increment float64, member string) (float64, error) {
+	reply, err := client.Do(ctx, "ZINCRBY", key, increment, member)
+	if err != nil {
+		return 0, err
+	}
+	score, err := reply.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("zincrby: unexpected response from server %v", reply.Value())
+	}
+	return score, nil
+}
+
+// ZRem removes members from the sorted set at key and returns how many were in it.
+func (client *Client) ZRem(ctx context.Context, key string, members ...string) (int, error) {
+	if len(members) == 0 {
+		return 0, nil
+	}
+	return client.doInt(ctx, keyArgs("ZREM", append([]string{key}, members...))...)
+}
+
+// ZCard returns the number of members of the sorted set at key, 0 when it doesn't exist.
+func (client *Client) ZCard(ctx context.Context, key string) (int, error) {
+	return client.doInt(ctx, "ZCARD", key)
+}
+
+// ZRank returns the rank of member in the sorted set at key, 0 for the lowest score, or ErrNil when it
+// isn't in it.
+func (client *Client) ZRank(ctx context.Context, key string, member string) (int, error) {
+	return client.zRank(ctx, "ZRANK", key, member)
+}
+
+// ZRevRank is like ZRank, 0 being the highest score.
+func (client *Client) ZRevRank(ctx context.Context, key string, member string) (int, error) {
+	return client.zRank(ctx, "ZREVRANK", key, member)
+}
+
+func (client *Client) zRank(ctx context.Context, command string, key string, member string) (int, error) {
+	reply, err := client.Do(ctx, command, key, member)
+	if err != nil {
+		return 0, err
+	}
+	if reply.IsNil() {
+		return 0, ErrNil
+	}
+	rank, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return rank, nil
+}
+
+// ZRankWithScore is ZRank also returning the score of member (Redis 7.2+).
+func (client *Client) ZRankWithScore(ctx context.Context, key string, member string) (int, float64, error) {
+	return client.zRankWithScore(ctx, "ZRANK", key, member)
+}
+
+// ZRevRankWithScore is ZRevRank also returning the score of member (Redis 7.2+).
+func (client *Client) ZRevRankWithScore(ctx context.Context, key string, member string) (int, float64, error) {
+	return client.zRankWithScore(ctx, "ZREVRANK", key, member)
+}
+
+func (client *Client) zRankWithScore(ctx context.Context, command string, key string, member string) (int, float64, error) {
+	reply, err := client.Do(ctx, command, key, member, "WITHSCORE")
+	if err != nil {
+		return 0, 0, err
+	}
+	if reply.IsNil() {
+		return 0, 0, ErrNil
+	}
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 2 {
+		return 0, 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	rank, err := parts[0].Int()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	score, err := parts[1].Float64()
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: unexpected response from server %v", strings.ToLower(command), reply.Value())
+	}
+	return rank, score, nil
+}
+
+// ZRange returns the members of the sorted set at key ranked from start to stop included, lowest score
+// first, negative ranks count from the highest (-1 is the last member).
+func (client *Client) ZRange(ctx context.Context, key string, start int, stop int) ([]string, error) {
+	reply, err := client.Do(ctx, "ZRANGE", key, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	members, err := reply.StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("zrange: unexpected response from server %v", reply.Value())
+	}
+	return members, nil
+}
+
+// ZRangeWithScores is ZRange also returning the scores.
+func (client *Client) ZRangeWithScores(ctx context.Context, key string, start int, stop int) ([]Member, error) {
+	reply, err := client.Do(ctx, "ZRANGE", key, start, stop, "WITHSCORES")
+	if err != nil {
+		return nil, err
+	}
+	members, err := parseMembers(reply)
+	if err != nil {
+		return nil, fmt.Errorf("zrange: %w", err)
+	}
+	return members, nil
+}
+
+// ZRangeBy is what the bounds of ZRangeOptions are.
+type ZRangeBy string
+
+const (
+	ZByRank  ZRangeBy = ""        // ranks, 0 for the lowest score, negative ranks count from the highest
+	ZByScore ZRangeBy = "BYSCORE" // scores, see ScoreBound
+	ZByLex   ZRangeBy = "BYLEX"   // members, for sorted sets whose members all have the same score, see LexBound
+)
+
+// The unbounded ends of score and lexicographic ranges.
+const (
+	ScoreMin = "-inf"
+	ScoreMax = "+inf"
+	LexMin   = "-"
+	LexMax   = "+"
+)
+
+// ScoreBound returns a score bound of ZRangeOptions, excluding score itself when exclusive is set.
+func ScoreBound(score float64, exclusive bool) string {
+	bound := strconv.FormatFloat(score, 'f', -1, 64)
+	if exclusive {
+		return "(" + bound
+	}
+	return bound
+}
+
+// LexBound returns a lexicographic bound of ZRangeOptions, excluding member itself when exclusive is set.
+func LexBound(member string, exclusive bool) string {
+	if exclusive {
+		return "(" + member
+	}
+	return "[" + member
+}
+
+// ZRangeOptions select the members of ZRangeArgs and ZRangeStore: from Start to Stop, both in the unit
+// of By. With Rev the order is reversed and Start is the high end, e.g. ScoreMax to ScoreMin.
+type ZRangeOptions struct {
+	By    ZRangeBy
+	Start string
+	Stop  string
+	Rev   bool
+	// Offset and Count page through the range, only with ZByScore and ZByLex; Count <= 0 returns
+	// everything past Offset
+	Offset int
+	Count  int
+}
+
+// args returns the bounds and options of ZRANGE and ZRANGESTORE.
+func (opts ZRangeOptions) args() ([]interface{}, error) {
+	limit := opts.Offset != 0 || opts.Count > 0
+	if limit && opts.By == ZByRank {
+		return nil, errors.New("Offset and Count need ZByScore or ZByLex")
+	}
+	args := []interface{}{opts.Start, opts.Stop}
+	if opts.By != ZByRank {
+		args = append(args, string(opts.By))
+	}
+	if opts.Rev {
+		args = append(args, "REV")
+	}
+	if limit {
+		count := opts.Count
+		if count <= 0 {
+			count = -1
+		}
+		args = append(args, "LIMIT", opts.Offset, count)
+	}
+	return args, nil
+}
+
+// ZRangeArgs returns the members of the sorted set at key in the range of opts, e.g. a page of the
+// members scored between two timestamps, or the members starting with a prefix.
+func (client *Client) ZRangeArgs(ctx context.Context, key string, opts ZRangeOptions) ([]string, error) {
+	args, err := opts.args()
+	if err != nil {
+		return nil, fmt.Errorf("zrangeArgs: %w", err)
+	}
+	reply, err := client.Do(ctx, append([]interface{}{"ZRANGE", key}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	members, err := reply.StringSlice()
+	if err != nil {
+		return nil, fmt.Errorf("zrangeArgs: unexpected response from server %v", reply.Value())
+	}
+	return members, nil
+}
+
+// ZRangeArgsWithScores is ZRangeArgs also returning the scores, not with ZByLex.
+func (client *Client) ZRangeArgsWithScores(ctx context.Context, key string, opts ZRangeOptions) ([]Member, error) {
+	args, err := opts.args()
+	if err != nil {
+		return nil, fmt.Errorf("zrangeArgs: %w", err)
+	}
+	reply, err := client.Do(ctx, append(append([]interface{}{"ZRANGE", key}, args...), "WITHSCORES")...)
+	if err != nil {
+		return nil, err
+	}
+	members, err := parseMembers(reply)
+	if err != nil {
+		return nil, fmt.Errorf("zrangeArgs: %w", err)
+	}
+	return members, nil
+}
+
+// ZRangeStore stores the members of source in the range of opts in destination, replacing it, and returns
+// how many were stored (Redis 6.2+).
+func (client *Client) ZRangeStore(ctx context.Context, destination string, source string, opts ZRangeOptions) (int, error) {
+	args, err := opts.args()
+	if err != nil {
+		return 0, fmt.Errorf("zrangeStore: %w", err)
+	}
+	return client.doInt(ctx, append([]interface{}{"ZRANGESTORE", destination, source}, args...)...)
+}
+
+// ZPopMin removes and returns up to count members with the lowest scores, lowest first, 1 when count isn't
+// positive. A missing key gives an empty slice.
+func (client *Client) ZPopMin(ctx context.Context, key string, count int) ([]Member, error) {
+	return client.zPop(ctx, "ZPOPMIN", key, count)
+}
+
+// ZPopMax is like ZPopMin with the highest scores, highest first.
+func (client *Client) ZPopMax(ctx context.Context, key string, count int) ([]Member, error) {
+	return client.zPop(ctx, "ZPOPMAX", key, count)
+}
+
+func (client *Client) zPop(ctx context.Context, command string, key string, count int) ([]Member, error) {
+	args := []interface{}{command, key}
+	if count > 0 {
+		args = append(args, count)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	members, err := parseMembers(reply)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", strings.ToLower(command), err)
+	}
+	return members, nil
+}
+
+// ZRandMember returns a random member of the sorted set at key, or returns ErrNil when it doesn't exist
+// (Redis 6.2+).
+func (client *Client) ZRandMember(ctx context.Context, key string) (string, error) {
+	return client.randomMember(ctx, "ZRANDMEMBER", key)
+}
+
+// ZRandMembers returns up to count distinct random members of the sorted set at key when count is
+// positive, and exactly -count members, possibly repeated, when it is negative. A missing key returns an
+// empty slice.
+func (client *Client) ZRandMembers(ctx context.Context, key string, count int) ([]string, error) {
+	return client.randomMembers(ctx, "ZRANDMEMBER", key, count)
+}
+
+// ZRandMembersWithScores is like ZRandMembers, returning the scores of the members as well.
+func (client *Client) ZRandMembersWithScores(ctx context.Context, key string, count int) ([]Member, error) {
+	reply, err := client.Do(ctx, "ZRANDMEMBER", key, count, "WITHSCORES")
+	if err != nil {
+		return nil, err
+	}
+	members, err := parseMembers(reply)
+	if err != nil {
+		return nil, fmt.Errorf("zrandmember: %w", err)
+	}
+	return members, nil
+}
@@ -0,0 +1,276 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestClient_ZMPop(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(
+		[]interface{}{"scores", []interface{}{[]interface{}{"ada", "1.5"}, []interface{}{"bob", "-inf"}}},
+		nil,
+		[]interface{}{"scores", []interface{}{[]interface{}{"ada", "high"}}},
+	)
+	got, err := client.ZMPop(context.Background(), ZPopMin, 2, "scores")
+	want := ZPopResult{Key: "scores", Members: []Member{{Value: "ada", Score: 1.5}, {Value: "bob", Score: math.Inf(-1)}}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ZMPop() got = %+v, %v, want %+v", got, err, want)
+	}
+	if want := []string{"ZMPOP", "1", "scores", "MIN", "COUNT", "2"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("ZMPop() sent %q, want %q", sent, want)
+	}
+	if _, err := client.ZMPop(context.Background(), ZPopMax, 0, "a", "b"); !errors.Is(err, ErrNil) {
+		t.Errorf("ZMPop() error = %v, want %v", err, ErrNil)
+	}
+	if want := []string{"ZMPOP", "2", "a", "b", "MAX"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("ZMPop() sent %q, want %q", sent, want)
+	}
+	if _, err := client.ZMPop(context.Background(), ZPopMax, 0, "scores"); err == nil {
+		t.Error("ZMPop() expected an error for a non-numeric score")
+	}
+	if _, err := client.ZMPop(context.Background(), ZPopMax, 0); err == nil {
+		t.Error("ZMPop() expected an error without keys")
+	}
+}
+
+func TestClient_SortedSets(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	tests := []struct {
+		name  string
+		reply interface{}
+		call  func(ctx context.Context) (interface{}, error)
+		want  interface{}
+		args  []string
+	}{
+		{
+			name: "ZAdd", reply: int64(2), want: 2, args: []string{"ZADD", "board", "10", "ada", "2.5", "bob"},
+			call: func(ctx context.Context) (interface{}, error) {
+				return client.ZAdd(ctx, "board", Member{Value: "ada", Score: 10}, Member{Value: "bob", Score: 2.5})
+			},
+		},
+		{
+			name: "ZScore", reply: "10.5", want: 10.5, args: []string{"ZSCORE", "board", "ada"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZScore(ctx, "board", "ada") },
+		},
+		{
+			name: "ZIncrBy", reply: "12", want: 12.0, args: []string{"ZINCRBY", "board", "1.5", "ada"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZIncrBy(ctx, "board", 1.5, "ada") },
+		},
+		{
+			name: "ZRem", reply: int64(1), want: 1, args: []string{"ZREM", "board", "ada", "zed"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZRem(ctx, "board", "ada", "zed") },
+		},
+		{
+			name: "ZCard", reply: int64(5), want: 5, args: []string{"ZCARD", "board"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZCard(ctx, "board") },
+		},
+		{
+			name: "ZRevRank", reply: int64(0), want: 0, args: []string{"ZREVRANK", "board", "ada"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZRevRank(ctx, "board", "ada") },
+		},
+		{
+			name: "ZRankWithScore", reply: []interface{}{int64(3), "10"}, want: Member{Value: "3", Score: 10},
+			args: []string{"ZRANK", "board", "ada", "WITHSCORE"},
+			call: func(ctx context.Context) (interface{}, error) {
+				rank, score, err := client.ZRankWithScore(ctx, "board", "ada")
+				return Member{Value: strconv.Itoa(rank), Score: score}, err
+			},
+		},
+		{
+			name: "ZRange", reply: []interface{}{"bob", "ada"}, want: []string{"bob", "ada"}, args: []string{"ZRANGE", "board", "0", "-1"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZRange(ctx, "board", 0, -1) },
+		},
+		{
+			name: "ZRangeWithScores", reply: []interface{}{"bob", "2.5", "ada", "10"},
+			want: []Member{{Value: "bob", Score: 2.5}, {Value: "ada", Score: 10}}, args: []string{"ZRANGE", "board", "0", "1", "WITHSCORES"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZRangeWithScores(ctx, "board", 0, 1) },
+		},
+		{
+			name: "ZPopMax", reply: []interface{}{"ada", "10"}, want: []Member{{Value: "ada", Score: 10}}, args: []string{"ZPOPMAX", "board"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZPopMax(ctx, "board", 0) },
+		},
+		{
+			name: "ZPopMin", reply: []interface{}{}, want: []Member{}, args: []string{"ZPOPMIN", "missing", "3"},
+			call: func(ctx context.Context) (interface{}, error) { return client.ZPopMin(ctx, "missing", 3) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ReceiveFunc = replySequence(tt.reply)
+			got, err := tt.call(context.Background())
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got = %v, %v, want %v", got, err, tt.want)
+			}
+			if !reflect.DeepEqual(sent, tt.args) {
+				t.Errorf("sent %q, want %q", sent, tt.args)
+			}
+		})
+	}
+}
+
+func TestClient_SortedSetsNil(t *testing.T) {
+	SendFunc = func(command string) error { return nil }
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(nil, nil, nil, []interface{}{"ada", "10", "bob"})
+	if _, err := client.ZScore(context.Background(), "board", "zed"); !errors.Is(err, ErrNil) {
+		t.Errorf("ZScore error = %v, want %v", err, ErrNil)
+	}
+	if _, err := client.ZRank(context.Background(), "board", "zed"); !errors.Is(err, ErrNil) {
+		t.Errorf("ZRank error = %v, want %v", err, ErrNil)
+	}
+	if _, _, err := client.ZRevRankWithScore(context.Background(), "board", "zed"); !errors.Is(err, ErrNil) {
+		t.Errorf("ZRevRankWithScore error = %v, want %v", err, ErrNil)
+	}
+	if _, err := client.ZRangeWithScores(context.Background(), "board", 0, -1); err == nil {
+		t.Error("ZRangeWithScores expected an error for a member without a score")
+	}
+	if _, err := client.ZAdd(context.Background(), "board"); err == nil {
+		t.Error("ZAdd expected an error without members")
+	}
+}
+
+func TestZAddOptions_Args(t *testing.T) {
+	tests := []struct {
+		opts    ZAddOptions
+		want    []interface{}
+		wantErr bool
+	}{
+		{opts: ZAddOptions{}, want: []interface{}{"ZADD", "k"}},
+		{opts: ZAddOptions{NX: true}, want: []interface{}{"ZADD", "k", "NX"}},
+		{opts: ZAddOptions{XX: true, GT: true, CH: true}, want: []interface{}{"ZADD", "k", "XX", "GT", "CH"}},
+		{opts: ZAddOptions{LT: true}, want: []interface{}{"ZADD", "k", "LT"}},
+		{opts: ZAddOptions{NX: true, XX: true}, wantErr: true},
+		{opts: ZAddOptions{GT: true, LT: true}, wantErr: true},
+		{opts: ZAddOptions{NX: true, GT: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.args("k")
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.args() = %v, %v, want %v", tt.opts, got, err, tt.want)
+		}
+	}
+}
+
+func TestClient_ZAddArgs(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(2), "15", nil)
+	if got, err := client.ZAddArgs(context.Background(), "board", ZAddOptions{GT: true, CH: true}, Member{Value: "ada", Score: 15}, Member{Value: "bob", Score: 3}); err != nil || got != 2 {
+		t.Errorf("ZAddArgs() got = %d, %v", got, err)
+	}
+	if want := []string{"ZADD", "board", "GT", "CH", "15", "ada", "3", "bob"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("ZAddArgs() sent %q, want %q", sent, want)
+	}
+	if got, err := client.ZAddIncr(context.Background(), "board", ZAddOptions{XX: true}, Member{Value: "ada", Score: 5}); err != nil || got != 15 {
+		t.Errorf("ZAddIncr() got = %v, %v", got, err)
+	}
+	if want := []string{"ZADD", "board", "XX", "INCR", "5", "ada"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("ZAddIncr() sent %q, want %q", sent, want)
+	}
+	if _, err := client.ZAddIncr(context.Background(), "board", ZAddOptions{NX: true}, Member{Value: "ada", Score: 1}); !errors.Is(err, ErrNil) {
+		t.Errorf("ZAddIncr() error = %v, want %v", err, ErrNil)
+	}
+
+	sent = nil
+	if _, err := client.ZAddArgs(context.Background(), "board", ZAddOptions{NX: true, LT: true}, Member{Value: "ada"}); err == nil || sent != nil {
+		t.Errorf("ZAddArgs() error = %v, sent %q, want an error before sending", err, sent)
+	}
+}
+
+func TestZRangeOptions_Args(t *testing.T) {
+	tests := []struct {
+		opts    ZRangeOptions
+		want    []interface{}
+		wantErr bool
+	}{
+		{opts: ZRangeOptions{Start: "0", Stop: "-1"}, want: []interface{}{"0", "-1"}},
+		{opts: ZRangeOptions{Start: "0", Stop: "9", Rev: true}, want: []interface{}{"0", "9", "REV"}},
+		{
+			opts: ZRangeOptions{By: ZByScore, Start: ScoreBound(1, true), Stop: ScoreMax, Offset: 20, Count: 10},
+			want: []interface{}{"(1", "+inf", "BYSCORE", "LIMIT", 20, 10},
+		},
+		{
+			opts: ZRangeOptions{By: ZByLex, Start: LexMax, Stop: LexBound("a", false), Rev: true, Offset: 5},
+			want: []interface{}{"+", "[a", "BYLEX", "REV", "LIMIT", 5, -1},
+		},
+		{opts: ZRangeOptions{Start: "0", Stop: "-1", Count: 10}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.args()
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.args() = %v, %v, want %v", tt.opts, got, err, tt.want)
+		}
+	}
+	if got := ScoreBound(2.5, false); got != "2.5" {
+		t.Errorf("ScoreBound() = %q", got)
+	}
+	if got := LexBound("b", true); got != "(b" {
+		t.Errorf("LexBound() = %q", got)
+	}
+}
+
+func TestClient_ZRangeArgs(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+	byScore := ZRangeOptions{By: ZByScore, Start: ScoreMin, Stop: ScoreBound(100, true), Count: 2}
+
+	ReceiveFunc = replySequence([]interface{}{"ada", "bob"}, []interface{}{"ada", "1", "bob", "2"}, int64(2))
+	if got, err := client.ZRangeArgs(context.Background(), "board", byScore); err != nil || !reflect.DeepEqual(got, []string{"ada", "bob"}) {
+		t.Errorf("ZRangeArgs() got = %q, %v", got, err)
+	}
+	if want := []string{"ZRANGE", "board", "-inf", "(100", "BYSCORE", "LIMIT", "0", "2"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("ZRangeArgs() sent %q, want %q", sent, want)
+	}
+	got, err := client.ZRangeArgsWithScores(context.Background(), "board", byScore)
+	if want := []Member{{Value: "ada", Score: 1}, {Value: "bob", Score: 2}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ZRangeArgsWithScores() got = %v, %v", got, err)
+	}
+	if want := []string{"ZRANGE", "board", "-inf", "(100", "BYSCORE", "LIMIT", "0", "2", "WITHSCORES"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("ZRangeArgsWithScores() sent %q, want %q", sent, want)
+	}
+	if got, err := client.ZRangeStore(context.Background(), "top", "board", ZRangeOptions{Start: "0", Stop: "1", Rev: true}); err != nil || got != 2 {
+		t.Errorf("ZRangeStore() got = %d, %v", got, err)
+	}
+	if want := []string{"ZRANGESTORE", "top", "board", "0", "1", "REV"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("ZRangeStore() sent %q, want %q", sent, want)
+	}
+}
+
+func TestClient_ZRandMember(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(nil, []interface{}{"ada", "bob"}, []interface{}{"ada", "1.5", "bob", "2"}, []interface{}{"ada"})
+	if _, err := client.ZRandMember(context.Background(), "missing"); !errors.Is(err, ErrNil) {
+		t.Errorf("ZRandMember() error = %v, want %v", err, ErrNil)
+	}
+	if got, err := client.ZRandMembers(context.Background(), "board", 2); err != nil || !reflect.DeepEqual(got, []string{"ada", "bob"}) {
+		t.Errorf("ZRandMembers() got = %q, %v", got, err)
+	}
+	got, err := client.ZRandMembersWithScores(context.Background(), "board", 2)
+	if want := []Member{{Value: "ada", Score: 1.5}, {Value: "bob", Score: 2}}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ZRandMembersWithScores() got = %+v, %v, want %+v", got, err, want)
+	}
+	if want := []string{"ZRANDMEMBER", "board", "2", "WITHSCORES"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("ZRandMembersWithScores() sent %q, want %q", sent, want)
+	}
+	if _, err := client.ZRandMembersWithScores(context.Background(), "board", 1); err == nil {
+		t.Error("ZRandMembersWithScores() expected an error for a member without a score")
+	}
+}
@@ -0,0 +1,590 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AutoID      = "*" // lets XAdd generate the ID of the entry from the server time
+	StreamStart = "-" // the smallest ID, to start XRange from the first entry
+	StreamEnd   = "+" // the greatest ID, to end XRange at the last entry
+	StreamNew   = "$" // the last ID of a stream when XRead is called, to read only entries added after
+	// StreamUndelivered reads the entries never delivered to a consumer of the group with XReadGroup, any
+	// other ID reads the entries delivered to this consumer and not acknowledged yet.
+	StreamUndelivered = ">"
+)
+
+// StreamEntry is an entry of a stream, Fields is nil for an entry deleted since it was delivered.
+type StreamEntry struct {
+	ID     string
+	Fields map[string]string
+}
+
+// XAdd appends an entry with fields to the stream at key, created when missing, and returns its ID. With
+// AutoID as id, the server generates a unique ID greater than the last one.
+func (client *Client) XAdd(ctx context.Context, key string, id string, fields map[string]string) (string, error) {
+	return client.XAddArgs(ctx, key, XAddOptions{ID: id}, fields)
+}
+
+// XAddOptions tune XAddArgs.
+type XAddOptions struct {
+	ID         string       // the ID of the entry, AutoID when empty
+	NoMkStream bool         // don't create the stream when missing, see XAddArgs
+	Trim       XTrimOptions // trims the stream after adding the entry, no trimming when zero
+}
+
+// XAddArgs is XAdd with opts, which can cap the size of the stream as entries are added. It returns ErrNil
+// when the stream doesn't exist with NoMkStream.
+func (client *Client) XAddArgs(ctx context.Context, key string, opts XAddOptions, fields map[string]string) (string, error) {
+	if len(fields) == 0 {
+		return "", errors.New("xadd: no field to add")
+	}
+	args := []interface{}{"XADD", key}
+	if opts.NoMkStream {
+		args = append(args, "NOMKSTREAM")
+	}
+	if opts.Trim != (XTrimOptions{}) {
+		trim, err := opts.Trim.args()
+		if err != nil {
+			return "", fmt.Errorf("xadd: %w", err)
+		}
+		args = append(args, trim...)
+	}
+	id := opts.ID
+	if id == "" {
+		id = AutoID
+	}
+	args = append(args, id)
+	for field, value := range fields {
+		args = append(args, field, value)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	if reply.IsNil() {
+		return "", ErrNil
+	}
+	entryID, err := reply.Text()
+	if err != nil {
+		return "", fmt.Errorf("xadd: unexpected response from server %v", reply.Value())
+	}
+	return entryID, nil
+}
+
+// XTrimOptions is how XTrim, or XAddArgs, trims a stream: by length with MaxLen or by age with MinID, one
+// of the two.
+type XTrimOptions struct {
+	MaxLen int    // keep the MaxLen newest entries
+	MinID  string // drop the entries with an ID lower than MinID (Redis 6.2+)
+	// Approx only drops whole nodes of the stream, which is much cheaper, keeping a few more entries than
+	// asked for.
+	Approx bool
+	Limit  int // with Approx, drop Limit entries at most per call, bounding its cost (Redis 6.2+)
+}
+
+func (opts XTrimOptions) args() ([]interface{}, error) {
+	if (opts.MaxLen > 0) == (opts.MinID != "") {
+		return nil, errors.New("one of MaxLen and MinID is required")
+	}
+	if opts.Limit > 0 && !opts.Approx {
+		return nil, errors.New("LIMIT requires Approx")
+	}
+	var args []interface{}
+	if opts.MaxLen > 0 {
+		args = append(args, "MAXLEN")
+	} else {
+		args = append(args, "MINID")
+	}
+	if opts.Approx {
+		args = append(args, "~")
+	}
+	if opts.MaxLen > 0 {
+		args = append(args, opts.MaxLen)
+	} else {
+		args = append(args, opts.MinID)
+	}
+	if opts.Limit > 0 {
+		args = append(args, "LIMIT", opts.Limit)
+	}
+	return args, nil
+}
+
+// XTrim trims the stream at key as opts tell and returns how many entries were dropped.
+func (client *Client) XTrim(ctx context.Context, key string, opts XTrimOptions) (int, error) {
+	trim, err := opts.args()
+	if err != nil {
+		return 0, fmt.Errorf("xtrim: %w", err)
+	}
+	return client.doInt(ctx, append([]interface{}{"XTRIM", key}, trim...)...)
+}
+
+// XLen returns the number of entries of the stream at key, 0 when it doesn't exist.
+func (client *Client) XLen(ctx context.Context, key string) (int, error) {
+	return client.doInt(ctx, "XLEN", key)
+}
+
+// XDel removes the entries with ids from the stream at key and returns how many existed.
+func (client *Client) XDel(ctx context.Context, key string, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, errors.New("xdel: no id")
+	}
+	return client.doInt(ctx, keyArgs("XDEL", append([]string{key}, ids...))...)
+}
+
+// XRange returns the entries of the stream at key with IDs from start to end included, see StreamStart and
+// StreamEnd, a "(" before an ID excludes it (Redis 6.2+). A missing key is an empty stream.
+func (client *Client) XRange(ctx context.Context, key string, start string, end string) ([]StreamEntry, error) {
+	return client.xrange(ctx, "XRANGE", key, start, end, 0)
+}
+
+// XRangeCount is like XRange for the count first entries at most.
+func (client *Client) XRangeCount(ctx context.Context, key string, start string, end string, count int) ([]StreamEntry, error) {
+	return client.xrange(ctx, "XRANGE", key, start, end, count)
+}
+
+// XRevRange is like XRange in reverse order, from end down to start.
+func (client *Client) XRevRange(ctx context.Context, key string, end string, start string) ([]StreamEntry, error) {
+	return client.xrange(ctx, "XREVRANGE", key, end, start, 0)
+}
+
+// XRevRangeCount is like XRevRange for the count last entries at most.
+func (client *Client) XRevRangeCount(ctx context.Context, key string, end string, start string, count int) ([]StreamEntry, error) {
+	return client.xrange(ctx, "XREVRANGE", key, end, start, count)
+}
+
+func (client *Client) xrange(ctx context.Context, command string, key string, from string, to string, count int) ([]StreamEntry, error) {
+	args := []interface{}{command, key, from, to}
+	if count > 0 {
+		args = append(args, "COUNT", count)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseStreamEntries(reply)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", strings.ToLower(command), err)
+	}
+	return entries, nil
+}
+
+// XReadOptions tune XRead.
+type XReadOptions struct {
+	Count int // entries per stream at most, all of them when zero
+	// Block waits up to Block for an entry when there is none, no longer than the deadline of ctx. A negative
+	// Block waits until the deadline, forever without one, and zero doesn't wait.
+	Block time.Duration
+}
+
+// XRead returns the entries with an ID greater than the one given for each stream of streams, a map of key
+// to ID, see StreamNew. Streams without entries are left out, and it returns ErrNil when they all are.
+//
+// A blocking read runs on a connection of its own, closed afterwards, like BLPop.
+func (client *Client) XRead(ctx context.Context, opts XReadOptions, streams map[string]string) (map[string][]StreamEntry, error) {
+	if len(streams) == 0 {
+		return nil, errors.New("xread: no stream")
+	}
+	args := func(wait time.Duration) []interface{} {
+		args := []interface{}{"XREAD"}
+		if opts.Count > 0 {
+			args = append(args, "COUNT", opts.Count)
+		}
+		if opts.Block != 0 {
+			args = append(args, "BLOCK", blockMillis(opts.Block, wait))
+		}
+		return append(args, streamArgs(streams)...)
+	}
+	var reply *Reply
+	var err error
+	if opts.Block != 0 {
+		reply, err = client.doBlocking(ctx, args)
+	} else {
+		reply, err = client.Do(ctx, args(0)...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseXRead("xread", reply)
+}
+
+// XGroupCreate creates the consumer group group of the stream at key, starting after the entry id: StreamNew
+// for new entries only, "0" for every entry. mkStream creates an empty stream when key doesn't exist, the
+// server returns an error otherwise. It returns false when the group already exists.
+func (client *Client) XGroupCreate(ctx context.Context, key string, group string, id string, mkStream bool) (bool, error) {
+	args := []interface{}{"XGROUP", "CREATE", key, group, id}
+	if mkStream {
+		args = append(args, "MKSTREAM")
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		var redisErr RedisError
+		if errors.As(err, &redisErr) && redisErr.Code() == "BUSYGROUP" {
+			return false, nil
+		}
+		return false, err
+	}
+	if response, err := reply.Text(); err != nil || response != "OK" {
+		return false, fmt.Errorf("xgroup: unexpected response from server %v", reply.Value())
+	}
+	return true, nil
+}
+
+// XGroupDestroy removes the consumer group group of the stream at key, with its pending entries, and
+// reports whether it existed.
+func (client *Client) XGroupDestroy(ctx context.Context, key string, group string) (bool, error) {
+	n, err := client.doInt(ctx, "XGROUP", "DESTROY", key, group)
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// XReadGroupOptions tune XReadGroup.
+type XReadGroupOptions struct {
+	Count int           // entries per stream at most, all of them when zero
+	Block time.Duration // see XReadOptions
+	NoAck bool          // don't track the entries read as pending, they need no XAck
+}
+
+// XReadGroup reads entries as consumer of the consumer group group, like XRead: streams maps keys to
+// StreamUndelivered for new entries, or to an ID to read the entries already delivered to consumer after it.
+// Entries stay pending until acknowledged with XAck. See Consumer for a managed read loop.
+func (client *Client) XReadGroup(ctx context.Context, group string, consumer string, opts XReadGroupOptions, streams map[string]string) (map[string][]StreamEntry, error) {
+	if len(streams) == 0 {
+		return nil, errors.New("xreadgroup: no stream")
+	}
+	var reply *Reply
+	var err error
+	if opts.Block != 0 {
+		reply, err = client.doBlocking(ctx, func(wait time.Duration) []interface{} {
+			return xreadGroupArgs(group, consumer, opts, wait, streams)
+		})
+	} else {
+		reply, err = client.Do(ctx, xreadGroupArgs(group, consumer, opts, 0, streams)...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseXRead("xreadgroup", reply)
+}
+
+func xreadGroupArgs(group string, consumer string, opts XReadGroupOptions, wait time.Duration, streams map[string]string) []interface{} {
+	args := []interface{}{"XREADGROUP", "GROUP", group, consumer}
+	if opts.Count > 0 {
+		args = append(args, "COUNT", opts.Count)
+	}
+	if opts.Block != 0 {
+		args = append(args, "BLOCK", blockMillis(opts.Block, wait))
+	}
+	if opts.NoAck {
+		args = append(args, "NOACK")
+	}
+	return append(args, streamArgs(streams)...)
+}
+
+// XAck acknowledges the entries with ids of the stream at key for the consumer group group, removing them
+// from its pending entries, and returns how many were pending.
+func (client *Client) XAck(ctx context.Context, key string, group string, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, errors.New("xack: no id")
+	}
+	return client.doInt(ctx, keyArgs("XACK", append([]string{key, group}, ids...))...)
+}
+
+// XClaim changes the owner of the pending entries with ids of the stream at key to consumer, for those idle
+// for at least minIdle, and returns them. Entries deleted from the stream are left out (Redis 7.0+) or
+// returned without fields.
+func (client *Client) XClaim(ctx context.Context, key string, group string, consumer string, minIdle time.Duration, ids ...string) ([]StreamEntry, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("xclaim: no id")
+	}
+	args := make([]interface{}, 0, len(ids)+5)
+	args = append(args, "XCLAIM", key, group, consumer, minIdle.Milliseconds())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseStreamEntries(reply)
+	if err != nil {
+		return nil, fmt.Errorf("xclaim: %w", err)
+	}
+	return entries, nil
+}
+
+// XAutoClaimResult is a page of XAutoClaim.
+type XAutoClaimResult struct {
+	Next    string        // where to start the next page, "0-0" after the last one
+	Entries []StreamEntry // the claimed entries
+	Deleted []string      // the IDs of pending entries deleted from the stream, removed from the group (Redis 7.0+)
+}
+
+// XAutoClaim is XClaim for the pending entries of the group idle for at least minIdle, count at most
+// (100 when zero), from the ID start on (Redis 6.2+). Starting from "0-0", it goes through every pending
+// entry a page at a time.
+func (client *Client) XAutoClaim(ctx context.Context, key string, group string, consumer string, minIdle time.Duration, start string, count int) (XAutoClaimResult, error) {
+	args := []interface{}{"XAUTOCLAIM", key, group, consumer, minIdle.Milliseconds(), start}
+	if count > 0 {
+		args = append(args, "COUNT", count)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return XAutoClaimResult{}, err
+	}
+	parts, err := reply.Array()
+	if err != nil || len(parts) < 2 {
+		return XAutoClaimResult{}, fmt.Errorf("xautoclaim: unexpected response from server %v", reply.Value())
+	}
+	var result XAutoClaimResult
+	if result.Next, err = parts[0].Text(); err != nil {
+		return XAutoClaimResult{}, fmt.Errorf("xautoclaim: unexpected response from server %v", reply.Value())
+	}
+	if result.Entries, err = parseStreamEntries(parts[1]); err != nil {
+		return XAutoClaimResult{}, fmt.Errorf("xautoclaim: %w", err)
+	}
+	if len(parts) > 2 {
+		if result.Deleted, err = parts[2].StringSlice(); err != nil {
+			return XAutoClaimResult{}, fmt.Errorf("xautoclaim: unexpected response from server %v", reply.Value())
+		}
+	}
+	return result, nil
+}
+
+// XPendingSummary sums up the pending entries of a consumer group.
+type XPendingSummary struct {
+	Count     int            // pending entries
+	Lowest    string         // ID of the oldest pending entry
+	Highest   string         // ID of the newest pending entry
+	Consumers map[string]int // pending entries per consumer, the ones with none are left out
+}
+
+// XPending returns the summary of the pending entries of the consumer group group of the stream at key.
+func (client *Client) XPending(ctx context.Context, key string, group string) (XPendingSummary, error) {
+	reply, err := client.Do(ctx, "XPENDING", key, group)
+	if err != nil {
+		return XPendingSummary{}, err
+	}
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 4 {
+		return XPendingSummary{}, fmt.Errorf("xpending: unexpected response from server %v", reply.Value())
+	}
+	var summary XPendingSummary
+	if summary.Count, err = parts[0].Int(); err != nil {
+		return XPendingSummary{}, fmt.Errorf("xpending: unexpected response from server %v", reply.Value())
+	}
+	if summary.Count == 0 {
+		return summary, nil // the other parts are nil
+	}
+	summary.Lowest, err = parts[1].Text()
+	if err == nil {
+		summary.Highest, err = parts[2].Text()
+	}
+	var consumers []*Reply
+	if err == nil {
+		consumers, err = parts[3].Array()
+	}
+	if err != nil {
+		return XPendingSummary{}, fmt.Errorf("xpending: unexpected response from server %v", reply.Value())
+	}
+	summary.Consumers = make(map[string]int, len(consumers))
+	for _, consumer := range consumers {
+		// each consumer is its name and its count, as a string
+		pair, err := consumer.StringSlice()
+		if err != nil || len(pair) != 2 {
+			return XPendingSummary{}, fmt.Errorf("xpending: unexpected response from server %v", reply.Value())
+		}
+		if summary.Consumers[pair[0]], err = strconv.Atoi(pair[1]); err != nil {
+			return XPendingSummary{}, fmt.Errorf("xpending: unexpected response from server %v", reply.Value())
+		}
+	}
+	return summary, nil
+}
+
+// XPendingOptions select the entries of XPendingExt.
+type XPendingOptions struct {
+	Start    string        // the lowest ID, StreamStart when empty
+	End      string        // the highest ID, StreamEnd when empty
+	Count    int           // entries at most, 100 when zero
+	Consumer string        // only the entries of this consumer, of every one when empty
+	MinIdle  time.Duration // only the entries idle for at least MinIdle (Redis 6.2+)
+}
+
+func (opts XPendingOptions) args() []interface{} {
+	var args []interface{}
+	if opts.MinIdle > 0 {
+		args = append(args, "IDLE", opts.MinIdle.Milliseconds())
+	}
+	start, end, count := opts.Start, opts.End, opts.Count
+	if start == "" {
+		start = StreamStart
+	}
+	if end == "" {
+		end = StreamEnd
+	}
+	if count <= 0 {
+		count = 100
+	}
+	args = append(args, start, end, count)
+	if opts.Consumer != "" {
+		args = append(args, opts.Consumer)
+	}
+	return args
+}
+
+// XPendingEntry is a pending entry of a consumer group.
+type XPendingEntry struct {
+	ID         string
+	Consumer   string        // the consumer it was last delivered to
+	Idle       time.Duration // since it was last delivered
+	Deliveries int           // how many times it was delivered, a high count hints at an entry that fails
+}
+
+// XPendingExt returns the pending entries of the consumer group group of the stream at key selected by
+// opts, in ID order, to find stuck entries and lagging consumers.
+func (client *Client) XPendingExt(ctx context.Context, key string, group string, opts XPendingOptions) ([]XPendingEntry, error) {
+	reply, err := client.Do(ctx, append([]interface{}{"XPENDING", key, group}, opts.args()...)...)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := reply.Array()
+	if err != nil {
+		return nil, fmt.Errorf("xpending: unexpected response from server %v", reply.Value())
+	}
+	entries := make([]XPendingEntry, len(replies))
+	for i, r := range replies {
+		if entries[i], err = parsePendingEntry(r); err != nil {
+			return nil, fmt.Errorf("xpending: unexpected response from server %v", reply.Value())
+		}
+	}
+	return entries, nil
+}
+
+// parsePendingEntry converts an entry of the extended XPENDING reply: ID, consumer, idle ms, deliveries.
+func parsePendingEntry(reply *Reply) (XPendingEntry, error) {
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 4 {
+		return XPendingEntry{}, errors.New("malformed entry")
+	}
+	var entry XPendingEntry
+	if entry.ID, err = parts[0].Text(); err != nil {
+		return XPendingEntry{}, err
+	}
+	if entry.Consumer, err = parts[1].Text(); err != nil {
+		return XPendingEntry{}, err
+	}
+	idle, err := parts[2].Int64()
+	if err != nil {
+		return XPendingEntry{}, err
+	}
+	entry.Idle = time.Duration(idle) * time.Millisecond
+	if entry.Deliveries, err = parts[3].Int(); err != nil {
+		return XPendingEntry{}, err
+	}
+	return entry, nil
+}
+
+// streamArgs returns the STREAMS arguments of XREAD and XREADGROUP, the keys in order then their IDs.
+func streamArgs(streams map[string]string) []interface{} {
+	keys := streamKeys(streams)
+	args := make([]interface{}, 0, 2*len(keys)+1)
+	args = append(args, "STREAMS")
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	for _, key := range keys {
+		args = append(args, streams[key])
+	}
+	return args
+}
+
+// streamKeys returns the sorted keys of streams.
+func streamKeys(streams map[string]string) []string {
+	keys := make([]string, 0, len(streams))
+	for key := range streams {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// blockMillis returns the BLOCK argument in milliseconds for block, see XReadOptions, wait being the time
+// left until the deadline, 0 without one.
+func blockMillis(block time.Duration, wait time.Duration) int {
+	if block < 0 || (wait > 0 && wait < block) {
+		block = wait
+	}
+	if block <= 0 {
+		return 0
+	}
+	// rounded down, the server must time out before the deadline, 0 would block forever
+	return int(max(block/time.Millisecond, 1))
+}
+
+// parseXRead converts a reply of XREAD or XREADGROUP, an array of key and entries pairs.
+func parseXRead(command string, reply *Reply) (map[string][]StreamEntry, error) {
+	if reply.IsNil() {
+		return nil, ErrNil
+	}
+	streams, err := reply.Array()
+	if err != nil {
+		return nil, fmt.Errorf("%s: unexpected response from server %v", command, reply.Value())
+	}
+	result := make(map[string][]StreamEntry, len(streams))
+	for _, stream := range streams {
+		parts, err := stream.Array()
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("%s: unexpected response from server %v", command, reply.Value())
+		}
+		key, err := parts[0].Text()
+		if err != nil {
+			return nil, fmt.Errorf("%s: unexpected response from server %v", command, reply.Value())
+		}
+		if result[key], err = parseStreamEntries(parts[1]); err != nil {
+			return nil, fmt.Errorf("%s: %w", command, err)
+		}
+	}
+	return result, nil
+}
+
+// parseStreamEntries converts an array of entries, each an ID and a flat array of fields and values.
+func parseStreamEntries(reply *Reply) ([]StreamEntry, error) {
+	replies, err := reply.Array()
+	if err != nil {
+		return nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+	}
+	entries := make([]StreamEntry, len(replies))
+	for i, r := range replies {
+		if entries[i], err = parseStreamEntry(r); err != nil {
+			return nil, fmt.Errorf("unexpected response from server %v", reply.Value())
+		}
+	}
+	return entries, nil
+}
+
+func parseStreamEntry(reply *Reply) (StreamEntry, error) {
+	parts, err := reply.Array()
+	if err != nil || len(parts) != 2 {
+		return StreamEntry{}, errors.New("malformed entry")
+	}
+	id, err := parts[0].Text()
+	if err != nil {
+		return StreamEntry{}, err
+	}
+	if parts[1].IsNil() {
+		return StreamEntry{ID: id}, nil
+	}
+	fields, err := parts[1].Map()
+	if err != nil {
+		return StreamEntry{}, err
+	}
+	return StreamEntry{ID: id, Fields: fields}, nil
+}
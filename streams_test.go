@@ -0,0 +1,291 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_Streams(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("1700000000000-0", int64(2), int64(1))
+	if id, err := client.XAdd(context.Background(), "events", AutoID, map[string]string{"type": "login"}); err != nil || id != "1700000000000-0" {
+		t.Errorf("XAdd() got = %q, %v", id, err)
+	}
+	if want := []string{"XADD", "events", "*", "type", "login"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XAdd() sent %q, want %q", sent, want)
+	}
+	if n, err := client.XLen(context.Background(), "events"); err != nil || n != 2 {
+		t.Errorf("XLen() got = %d, %v", n, err)
+	}
+	if n, err := client.XDel(context.Background(), "events", "1-0", "2-0"); err != nil || n != 1 {
+		t.Errorf("XDel() got = %d, %v", n, err)
+	}
+	if want := []string{"XDEL", "events", "1-0", "2-0"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XDel() sent %q, want %q", sent, want)
+	}
+	if _, err := client.XAdd(context.Background(), "events", AutoID, nil); err == nil {
+		t.Error("XAdd() expected an error without fields")
+	}
+
+	ReceiveFunc = replySequence(
+		[]interface{}{
+			[]interface{}{"1-0", []interface{}{"type", "login", "user", "ada"}},
+			[]interface{}{"2-0", nil},
+		},
+		[]interface{}{[]interface{}{"2-0", []interface{}{"type", "logout"}}},
+		[]interface{}{[]interface{}{"1-0"}},
+	)
+	entries, err := client.XRange(context.Background(), "events", StreamStart, StreamEnd)
+	want := []StreamEntry{{ID: "1-0", Fields: map[string]string{"type": "login", "user": "ada"}}, {ID: "2-0"}}
+	if err != nil || !reflect.DeepEqual(entries, want) {
+		t.Errorf("XRange() got = %+v, %v, want %+v", entries, err, want)
+	}
+	if want := []string{"XRANGE", "events", "-", "+"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XRange() sent %q, want %q", sent, want)
+	}
+	entries, err = client.XRevRangeCount(context.Background(), "events", StreamEnd, StreamStart, 1)
+	if want := []StreamEntry{{ID: "2-0", Fields: map[string]string{"type": "logout"}}}; err != nil || !reflect.DeepEqual(entries, want) {
+		t.Errorf("XRevRangeCount() got = %+v, %v, want %+v", entries, err, want)
+	}
+	if want := []string{"XREVRANGE", "events", "+", "-", "COUNT", "1"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XRevRangeCount() sent %q, want %q", sent, want)
+	}
+	if _, err := client.XRange(context.Background(), "events", StreamStart, StreamEnd); err == nil {
+		t.Error("XRange() expected an error for a malformed entry")
+	}
+}
+
+func TestClient_XRead(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence([]interface{}{
+		[]interface{}{"orders", []interface{}{[]interface{}{"5-0", []interface{}{"sku", "a1"}}}},
+	}, nil)
+	got, err := client.XRead(context.Background(), XReadOptions{Count: 10}, map[string]string{"orders": "4-0", "audit": "0"})
+	want := map[string][]StreamEntry{"orders": {{ID: "5-0", Fields: map[string]string{"sku": "a1"}}}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("XRead() got = %+v, %v, want %+v", got, err, want)
+	}
+	if want := []string{"XREAD", "COUNT", "10", "STREAMS", "audit", "orders", "0", "4-0"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XRead() sent %q, want %q", sent, want)
+	}
+	if _, err := client.XRead(context.Background(), XReadOptions{}, map[string]string{"orders": StreamNew}); !errors.Is(err, ErrNil) {
+		t.Errorf("XRead() error = %v, want %v", err, ErrNil)
+	}
+	if _, err := client.XRead(context.Background(), XReadOptions{}, nil); err == nil {
+		t.Error("XRead() expected an error without streams")
+	}
+}
+
+func TestClient_XReadBlock(t *testing.T) {
+	client, servers := newPipeClient("")
+	received := serveBlocking(t, servers, "*-1\r\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := client.XRead(ctx, XReadOptions{Block: 5 * time.Second}, map[string]string{"orders": StreamNew}); !errors.Is(err, ErrNil) {
+		t.Errorf("XRead() error = %v, want %v", err, ErrNil)
+	}
+	args := <-received
+	if len(args) != 6 || args[0] != "XREAD" || args[1] != "BLOCK" || args[2] == "5000" || args[3] != "STREAMS" {
+		t.Errorf("XRead() sent %q, want BLOCK capped by the deadline", args)
+	}
+	if stats := client.PoolStats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("PoolStats() got %+v, want the pool unused", stats)
+	}
+}
+
+func TestBlockMillis(t *testing.T) {
+	tests := []struct {
+		block time.Duration
+		wait  time.Duration
+		want  int
+	}{
+		{block: time.Second, wait: 0, want: 1000},
+		{block: time.Second, wait: 300 * time.Millisecond, want: 300},
+		{block: time.Second, wait: 2 * time.Second, want: 1000},
+		{block: -1, wait: 1500 * time.Microsecond, want: 1},
+		{block: -1, wait: 2999 * time.Microsecond, want: 2},
+		{block: 200 * time.Microsecond, wait: 0, want: 1},
+		{block: -1, wait: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := blockMillis(tt.block, tt.wait); got != tt.want {
+			t.Errorf("blockMillis(%v, %v) = %d, want %d", tt.block, tt.wait, got, tt.want)
+		}
+	}
+}
+
+func TestClient_XGroup(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence("OK", RedisError("BUSYGROUP Consumer Group name already exists"), RedisError("ERR The XGROUP subcommand requires the key to exist."), int64(1), int64(2))
+	if created, err := client.XGroupCreate(context.Background(), "jobs", "workers", StreamNew, true); err != nil || !created {
+		t.Errorf("XGroupCreate() got = %v, %v", created, err)
+	}
+	if want := []string{"XGROUP", "CREATE", "jobs", "workers", "$", "MKSTREAM"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XGroupCreate() sent %q, want %q", sent, want)
+	}
+	if created, err := client.XGroupCreate(context.Background(), "jobs", "workers", "0", false); err != nil || created {
+		t.Errorf("XGroupCreate() got = %v, %v, want false for an existing group", created, err)
+	}
+	if _, err := client.XGroupCreate(context.Background(), "missing", "workers", "0", false); err == nil {
+		t.Error("XGroupCreate() expected the server error")
+	}
+	if destroyed, err := client.XGroupDestroy(context.Background(), "jobs", "workers"); err != nil || !destroyed {
+		t.Errorf("XGroupDestroy() got = %v, %v", destroyed, err)
+	}
+	if n, err := client.XAck(context.Background(), "jobs", "workers", "1-0", "2-0"); err != nil || n != 2 {
+		t.Errorf("XAck() got = %d, %v", n, err)
+	}
+	if want := []string{"XACK", "jobs", "workers", "1-0", "2-0"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XAck() sent %q, want %q", sent, want)
+	}
+
+	ReceiveFunc = replySequence([]interface{}{
+		[]interface{}{"jobs", []interface{}{[]interface{}{"3-0", []interface{}{"task", "resize"}}}},
+	})
+	got, err := client.XReadGroup(context.Background(), "workers", "w1", XReadGroupOptions{Count: 1, NoAck: true}, map[string]string{"jobs": StreamUndelivered})
+	want := map[string][]StreamEntry{"jobs": {{ID: "3-0", Fields: map[string]string{"task": "resize"}}}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("XReadGroup() got = %+v, %v, want %+v", got, err, want)
+	}
+	if want := []string{"XREADGROUP", "GROUP", "workers", "w1", "COUNT", "1", "NOACK", "STREAMS", "jobs", ">"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XReadGroup() sent %q, want %q", sent, want)
+	}
+}
+
+func TestClient_XClaim(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(
+		[]interface{}{[]interface{}{"1-0", []interface{}{"task", "resize"}}},
+		[]interface{}{"5-0", []interface{}{[]interface{}{"2-0", []interface{}{"task", "crop"}}}, []interface{}{"3-0"}},
+		[]interface{}{"0-0", []interface{}{[]interface{}{"6-0", nil}}}, // Redis 6.2 has no deleted IDs
+	)
+	entries, err := client.XClaim(context.Background(), "jobs", "workers", "w2", time.Minute, "1-0")
+	if want := []StreamEntry{{ID: "1-0", Fields: map[string]string{"task": "resize"}}}; err != nil || !reflect.DeepEqual(entries, want) {
+		t.Errorf("XClaim() got = %+v, %v, want %+v", entries, err, want)
+	}
+	if want := []string{"XCLAIM", "jobs", "workers", "w2", "60000", "1-0"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XClaim() sent %q, want %q", sent, want)
+	}
+
+	got, err := client.XAutoClaim(context.Background(), "jobs", "workers", "w2", time.Minute, "0-0", 10)
+	want := XAutoClaimResult{Next: "5-0", Entries: []StreamEntry{{ID: "2-0", Fields: map[string]string{"task": "crop"}}}, Deleted: []string{"3-0"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("XAutoClaim() got = %+v, %v, want %+v", got, err, want)
+	}
+	if want := []string{"XAUTOCLAIM", "jobs", "workers", "w2", "60000", "0-0", "COUNT", "10"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XAutoClaim() sent %q, want %q", sent, want)
+	}
+	got, err = client.XAutoClaim(context.Background(), "jobs", "workers", "w2", time.Minute, "5-0", 0)
+	if want := (XAutoClaimResult{Next: "0-0", Entries: []StreamEntry{{ID: "6-0"}}}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("XAutoClaim() got = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestClient_XPending(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(
+		[]interface{}{int64(3), "1-0", "4-0", []interface{}{[]interface{}{"w1", "2"}, []interface{}{"w2", "1"}}},
+		[]interface{}{int64(0), nil, nil, nil},
+		[]interface{}{
+			[]interface{}{"1-0", "w1", int64(90000), int64(7)},
+			[]interface{}{"4-0", "w1", int64(65000), int64(1)},
+		},
+		[]interface{}{[]interface{}{"1-0", "w1", "idle", int64(7)}},
+	)
+	summary, err := client.XPending(context.Background(), "jobs", "workers")
+	want := XPendingSummary{Count: 3, Lowest: "1-0", Highest: "4-0", Consumers: map[string]int{"w1": 2, "w2": 1}}
+	if err != nil || !reflect.DeepEqual(summary, want) {
+		t.Errorf("XPending() got = %+v, %v, want %+v", summary, err, want)
+	}
+	if summary, err := client.XPending(context.Background(), "jobs", "idle"); err != nil || !reflect.DeepEqual(summary, XPendingSummary{}) {
+		t.Errorf("XPending() got = %+v, %v, want an empty summary", summary, err)
+	}
+
+	entries, err := client.XPendingExt(context.Background(), "jobs", "workers", XPendingOptions{Consumer: "w1", MinIdle: time.Minute})
+	wantEntries := []XPendingEntry{
+		{ID: "1-0", Consumer: "w1", Idle: 90 * time.Second, Deliveries: 7},
+		{ID: "4-0", Consumer: "w1", Idle: 65 * time.Second, Deliveries: 1},
+	}
+	if err != nil || !reflect.DeepEqual(entries, wantEntries) {
+		t.Errorf("XPendingExt() got = %+v, %v, want %+v", entries, err, wantEntries)
+	}
+	if want := []string{"XPENDING", "jobs", "workers", "IDLE", "60000", "-", "+", "100", "w1"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XPendingExt() sent %q, want %q", sent, want)
+	}
+	if _, err := client.XPendingExt(context.Background(), "jobs", "workers", XPendingOptions{Start: "1-0", End: "9-0", Count: 5}); err == nil {
+		t.Error("XPendingExt() expected an error for a malformed entry")
+	}
+	if want := []string{"XPENDING", "jobs", "workers", "1-0", "9-0", "5"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XPendingExt() sent %q, want %q", sent, want)
+	}
+}
+
+func TestXTrimOptions_Args(t *testing.T) {
+	tests := []struct {
+		opts    XTrimOptions
+		want    []interface{}
+		wantErr bool
+	}{
+		{opts: XTrimOptions{MaxLen: 1000}, want: []interface{}{"MAXLEN", 1000}},
+		{opts: XTrimOptions{MaxLen: 1000, Approx: true, Limit: 100}, want: []interface{}{"MAXLEN", "~", 1000, "LIMIT", 100}},
+		{opts: XTrimOptions{MinID: "1700000000000-0", Approx: true}, want: []interface{}{"MINID", "~", "1700000000000-0"}},
+		{opts: XTrimOptions{}, wantErr: true},
+		{opts: XTrimOptions{MaxLen: 10, MinID: "1-0"}, wantErr: true},
+		{opts: XTrimOptions{MaxLen: 10, Limit: 5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.opts.args()
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.args() = %v, %v, want %v", tt.opts, got, err, tt.want)
+		}
+	}
+}
+
+func TestClient_XTrim(t *testing.T) {
+	var sent []string
+	SendFunc = recordCommand(&sent)
+	client := newMockClient(1, "")
+
+	ReceiveFunc = replySequence(int64(12), "5-0", nil)
+	if n, err := client.XTrim(context.Background(), "events", XTrimOptions{MaxLen: 100, Approx: true}); err != nil || n != 12 {
+		t.Errorf("XTrim() got = %d, %v", n, err)
+	}
+	if want := []string{"XTRIM", "events", "MAXLEN", "~", "100"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XTrim() sent %q, want %q", sent, want)
+	}
+
+	opts := XAddOptions{NoMkStream: true, Trim: XTrimOptions{MinID: "1-0"}}
+	if id, err := client.XAddArgs(context.Background(), "events", opts, map[string]string{"type": "login"}); err != nil || id != "5-0" {
+		t.Errorf("XAddArgs() got = %q, %v", id, err)
+	}
+	if want := []string{"XADD", "events", "NOMKSTREAM", "MINID", "1-0", "*", "type", "login"}; !reflect.DeepEqual(sent, want) {
+		t.Errorf("XAddArgs() sent %q, want %q", sent, want)
+	}
+	if _, err := client.XAddArgs(context.Background(), "missing", XAddOptions{NoMkStream: true}, map[string]string{"type": "login"}); !errors.Is(err, ErrNil) {
+		t.Errorf("XAddArgs() error = %v, want %v", err, ErrNil)
+	}
+
+	sent = nil
+	if _, err := client.XTrim(context.Background(), "events", XTrimOptions{}); err == nil || sent != nil {
+		t.Errorf("XTrim() error = %v, sent %q, want an error before sending", err, sent)
+	}
+}
@@ -0,0 +1,235 @@
+package resp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// structField is a struct field tagged `redis:"name"` or `redis:"name,omitempty"`.
+type structField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// structFields returns the tagged fields of the struct type t, untagged fields and `redis:"-"` are skipped.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("redis"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		fields = append(fields, structField{name: name, index: i, omitEmpty: options == "omitempty"})
+	}
+	return fields
+}
+
+// structPointer returns the struct pointed to by v.
+func structPointer(v interface{}) (reflect.Value, error) {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("resp: want a non-nil pointer to a struct, got %T", v)
+	}
+	return ptr.Elem(), nil
+}
+
+// ScanStruct sets the fields of the struct pointed to by dst from values, by their `redis:"name"` tag.
+// Strings, []byte, integers, floats, bools (1, 0, true, false...) and time.Time (RFC 3339 or Unix seconds)
+// are converted, fields missing from values are left unchanged.
+//
+//	type User struct {
+//		Name    string    `redis:"name"`
+//		Age     int       `redis:"age"`
+//		Created time.Time `redis:"created"`
+//	}
+func ScanStruct(dst interface{}, values map[string]string) error {
+	value, err := structPointer(dst)
+	if err != nil {
+		return err
+	}
+	for _, field := range structFields(value.Type()) {
+		s, ok := values[field.name]
+		if !ok {
+			continue
+		}
+		if err := setField(value.Field(field.index), s); err != nil {
+			return fmt.Errorf("resp: field %s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+func setField(v reflect.Value, s string) error {
+	if v.Type() == timeType {
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.SetBytes([]byte(s))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// ScanStruct sets the fields of the struct pointed to by dst from an HGETALL reply, see ScanStruct.
+func (r *Reply) ScanStruct(dst interface{}) error {
+	values, err := r.Map()
+	if err != nil {
+		return err
+	}
+	return ScanStruct(dst, values)
+}
+
+// HMGetStruct reads the tagged fields of the struct pointed to by dst from the hash at key with HMGET, see
+// ScanStruct. It returns ErrNil when none of the fields exist.
+func (client *Client) HMGetStruct(ctx context.Context, key string, dst interface{}) error {
+	value, err := structPointer(dst)
+	if err != nil {
+		return err
+	}
+	fields := structFields(value.Type())
+	if len(fields) == 0 {
+		return nil
+	}
+	args := make([]interface{}, 0, len(fields)+2)
+	args = append(args, "HMGET", key)
+	for _, field := range fields {
+		args = append(args, field.name)
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return err
+	}
+	replies, err := reply.Array()
+	if err != nil || len(replies) != len(fields) {
+		return fmt.Errorf("hmget: unexpected response from server %v", reply.Value())
+	}
+	values := make(map[string]string, len(fields))
+	for i, r := range replies {
+		if r.IsNil() {
+			continue
+		}
+		if values[fields[i].name], err = r.Text(); err != nil {
+			return fmt.Errorf("hmget: unexpected response from server %v", reply.Value())
+		}
+	}
+	if len(values) == 0 {
+		return ErrNil
+	}
+	return ScanStruct(dst, values)
+}
+
+// HSetStruct writes the tagged fields of v, a struct or a pointer to one, to the hash at key with HSET and
+// returns how many fields were added. Values are formatted the way ScanStruct reads them back, time.Time as
+// RFC 3339, and fields tagged `redis:"name,omitempty"` are skipped when they hold their zero value.
+func (client *Client) HSetStruct(ctx context.Context, key string, v interface{}) (int, error) {
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("resp: want a struct or a pointer to one, got %T", v)
+	}
+	args := []interface{}{"HSET", key}
+	for _, field := range structFields(value.Type()) {
+		fieldValue := value.Field(field.index)
+		if field.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+		s, err := formatField(fieldValue)
+		if err != nil {
+			return 0, fmt.Errorf("resp: field %s: %w", field.name, err)
+		}
+		args = append(args, field.name, s)
+	}
+	if len(args) == 2 {
+		return 0, nil // HSET needs at least one field
+	}
+	reply, err := client.Do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	added, err := reply.Int()
+	if err != nil {
+		return 0, fmt.Errorf("hset: unexpected response from server %v", reply.Value())
+	}
+	return added, nil
+}
+
+func formatField(v reflect.Value) (string, error) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return "", fmt.Errorf("unsupported type %s", v.Type())
+		}
+		return string(v.Bytes()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		if v.Bool() {
+			return "1", nil
+		}
+		return "0", nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
@@ -0,0 +1,147 @@
+package resp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testProfile struct {
+	Name     string    `redis:"name"`
+	Age      int       `redis:"age"`
+	Visits   uint32    `redis:"visits"`
+	Score    float64   `redis:"score"`
+	Admin    bool      `redis:"admin"`
+	Avatar   []byte    `redis:"avatar"`
+	Created  time.Time `redis:"created"`
+	Internal string    `redis:"-"`
+	Untagged string
+}
+
+func TestScanStruct(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	var got testProfile
+	err := ScanStruct(&got, map[string]string{
+		"name": "ada", "age": "36", "visits": "12", "score": "9.5", "admin": "1", "avatar": "png",
+		"created": created.Format(time.RFC3339Nano), "Internal": "x", "Untagged": "x", "unknown": "x",
+	})
+	want := testProfile{Name: "ada", Age: 36, Visits: 12, Score: 9.5, Admin: true, Avatar: []byte("png"), Created: created}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanStruct() got = %+v, %v, want %+v", got, err, want)
+	}
+
+	// missing fields are left unchanged, times can be Unix seconds
+	got = testProfile{Name: "kept"}
+	if err := ScanStruct(&got, map[string]string{"created": "1700000000", "admin": "false"}); err != nil {
+		t.Fatalf("ScanStruct() error = %v", err)
+	}
+	if got.Name != "kept" || !got.Created.Equal(time.Unix(1700000000, 0)) || got.Admin {
+		t.Errorf("ScanStruct() got = %+v", got)
+	}
+
+	for _, values := range []map[string]string{{"age": "old"}, {"visits": "-1"}, {"admin": "maybe"}, {"created": "yesterday"}} {
+		if err := ScanStruct(&testProfile{}, values); err == nil {
+			t.Errorf("ScanStruct(%v) expected a conversion error", values)
+		}
+	}
+	var unsupported struct {
+		Tags []string `redis:"tags"`
+	}
+	if err := ScanStruct(&unsupported, map[string]string{"tags": "a"}); err == nil {
+		t.Error("ScanStruct() expected an error for an unsupported type")
+	}
+	if err := ScanStruct(testProfile{}, nil); err == nil {
+		t.Error("ScanStruct() expected an error for a non-pointer")
+	}
+}
+
+func TestReply_ScanStruct(t *testing.T) {
+	var got testProfile
+	reply := NewReply([]interface{}{"name", "ada", "age", "36"})
+	if err := reply.ScanStruct(&got); err != nil || got.Name != "ada" || got.Age != 36 {
+		t.Errorf("ScanStruct() got = %+v, %v", got, err)
+	}
+	if err := NewReply([]interface{}{"name"}).ScanStruct(&got); err == nil {
+		t.Error("ScanStruct() expected an error for an odd number of elements")
+	}
+}
+
+func TestClient_HMGetStruct(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+
+	var dst struct {
+		Name string `redis:"name"`
+		Age  int    `redis:"age"`
+	}
+	ReceiveFunc = replySequence([]interface{}{"ada", nil}, []interface{}{nil, nil})
+	if err := client.HMGetStruct(context.Background(), "user:1", &dst); err != nil || dst.Name != "ada" || dst.Age != 0 {
+		t.Errorf("HMGetStruct() got = %+v, %v", dst, err)
+	}
+	if want := "*4\r\n$5\r\nHMGET\r\n$6\r\nuser:1\r\n$4\r\nname\r\n$3\r\nage\r\n"; sent != want {
+		t.Errorf("HMGetStruct() sent %q, want %q", sent, want)
+	}
+	if err := client.HMGetStruct(context.Background(), "missing", &dst); !errors.Is(err, ErrNil) {
+		t.Errorf("HMGetStruct() error = %v, want %v", err, ErrNil)
+	}
+
+	sent = ""
+	if err := client.HMGetStruct(context.Background(), "user:1", dst); err == nil || sent != "" {
+		t.Errorf("HMGetStruct() error = %v, sent %q, want an error before sending", err, sent)
+	}
+}
+
+func TestClient_HSetStruct(t *testing.T) {
+	var sent string
+	SendFunc = func(command string) error {
+		sent = command
+		return nil
+	}
+	client := newMockClient(1, "")
+	ReceiveFunc = func() (interface{}, error) { return int64(2), nil }
+
+	profile := testProfile{Name: "ada", Age: 36, Score: 9.5, Admin: true, Created: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), Internal: "x"}
+	if added, err := client.HSetStruct(context.Background(), "user:1", &profile); err != nil || added != 2 {
+		t.Fatalf("HSetStruct() got = %d, %v", added, err)
+	}
+	value, _ := newMockConnection(sent, new(bytes.Buffer), time.Time{}).ReceiveValue(context.Background())
+	fields, _ := NewReply(value).StringSlice()
+	want := []string{"HSET", "user:1", "name", "ada", "age", "36", "visits", "0", "score", "9.5", "admin", "1", "avatar", "", "created", "2024-05-01T12:30:00Z"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("HSetStruct() sent %q, want %q", fields, want)
+	}
+	// what was written scans back into the same struct
+	written, _ := NewReply(value.([]interface{})[2:]).Map()
+	var got testProfile
+	if err := ScanStruct(&got, written); err != nil || !reflect.DeepEqual(got, testProfile{Name: "ada", Age: 36, Score: 9.5, Admin: true, Avatar: []byte{}, Created: profile.Created}) {
+		t.Errorf("ScanStruct() got = %+v, %v", got, err)
+	}
+
+	var sparse struct {
+		Name  string `redis:"name,omitempty"`
+		Email string `redis:"email,omitempty"`
+	}
+	sparse.Name = "ada"
+	if _, err := client.HSetStruct(context.Background(), "user:1", sparse); err != nil {
+		t.Fatalf("HSetStruct() error = %v", err)
+	}
+	if want := "*4\r\n$4\r\nHSET\r\n$6\r\nuser:1\r\n$4\r\nname\r\n$3\r\nada\r\n"; sent != want {
+		t.Errorf("HSetStruct() sent %q, want %q", sent, want)
+	}
+
+	sent = ""
+	sparse.Name = ""
+	if added, err := client.HSetStruct(context.Background(), "user:1", sparse); err != nil || added != 0 || sent != "" {
+		t.Errorf("HSetStruct() got = %d, %v, sent %q, want nothing sent", added, err, sent)
+	}
+	if _, err := client.HSetStruct(context.Background(), "user:1", "not a struct"); err == nil {
+		t.Error("HSetStruct() expected an error for a non-struct")
+	}
+}
@@ -0,0 +1,128 @@
+package resp
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTxFailed is returned when EXEC aborts a transaction because a watched key was modified.
+var ErrTxFailed = errors.New("transaction failed: watched keys were modified")
+
+// errWatchPanicked releases the connection of a Watch whose fn panicked, so the pool closes it.
+var errWatchPanicked = errors.New("watch: fn panicked")
+
+// maxWatchRetries bounds how many times Watch reruns a transaction aborted by a concurrent write.
+const maxWatchRetries = 10
+
+// Tx runs commands on the connection holding the WATCH, see Client.Watch.
+type Tx struct {
+	conn     IConnection
+	executed bool
+	connErr  error // the last error the connection failed with, decides whether it can be reused
+}
+
+// Watch implements optimistic locking (check-and-set): it WATCHes keys, then calls fn, which reads the
+// current values with tx.Do and queues the writes with tx.Exec. If a watched key changes before EXEC
+// the transaction is aborted and fn runs again, up to maxWatchRetries times, after which ErrTxFailed is returned.
+// The connection is dedicated to fn for its whole run.
+func (client *Client) Watch(ctx context.Context, fn func(tx *Tx) error, keys ...string) error {
+	if len(keys) == 0 {
+		return errors.New("watch: at least one key is required")
+	}
+
+	for attempt := 0; attempt < maxWatchRetries; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := client.watch(ctx, fn, keys)
+		if !errors.Is(err, ErrTxFailed) {
+			return err
+		}
+	}
+	return ErrTxFailed
+}
+
+func (client *Client) watch(ctx context.Context, fn func(tx *Tx) error, keys []string) error {
+	conn, err := client.getConn(ctx)
+	if err != nil {
+		return err
+	}
+	tx := &Tx{conn: conn}
+	defer func() {
+		if r := recover(); r != nil {
+			// the keys are still watched and a reply may be unread, the connection can't be reused
+			client.releaseConn(conn, errWatchPanicked)
+			panic(r)
+		}
+		// errors returned by fn don't say anything about the connection, only the ones it failed with do
+		client.releaseConn(conn, tx.connErr)
+	}()
+
+	args := make([]interface{}, 0, len(keys)+1)
+	args = append(args, "WATCH")
+	for _, key := range keys {
+		args = append(args, key)
+	}
+	if _, err := tx.Do(ctx, args...); err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if !tx.executed {
+		// EXEC clears the watched keys on its own, otherwise they'd stay watched on the connection
+		if unwatchErr := tx.unwatch(ctx); err == nil {
+			err = unwatchErr
+		}
+	}
+	return err
+}
+
+// Do runs a command right away, outside the transaction, typically to read the watched keys.
+func (tx *Tx) Do(ctx context.Context, args ...interface{}) (*Reply, error) {
+	if err := tx.conn.SendCommand(ctx, args...); err != nil {
+		tx.connErr = err
+		return nil, err
+	}
+	value, err := tx.conn.ReceiveValue(ctx)
+	if err != nil {
+		tx.connErr = err
+		return nil, err
+	}
+	return NewReply(value), nil
+}
+
+// Exec wraps the commands queued by fn in MULTI/EXEC and returns their replies.
+// It returns ErrTxFailed if a watched key was modified, in which case none of the commands ran.
+func (tx *Tx) Exec(ctx context.Context, fn func(pipe *Pipeline)) ([]*Reply, error) {
+	pipe := &Pipeline{}
+	fn(pipe)
+
+	cmds := make([][]interface{}, 0, len(pipe.cmds)+2)
+	cmds = append(cmds, []interface{}{"MULTI"})
+	cmds = append(cmds, pipe.cmds...)
+	cmds = append(cmds, []interface{}{"EXEC"})
+
+	tx.executed = true
+	replies, err := execPipeline(ctx, tx.conn, cmds)
+	if err != nil {
+		tx.connErr = err
+		return nil, err
+	}
+
+	// A command rejected while queueing makes EXEC fail with EXECABORT, report the original error instead
+	for _, reply := range replies[:len(replies)-1] {
+		if err := reply.Err(); err != nil {
+			return nil, err
+		}
+	}
+	exec := replies[len(replies)-1]
+	if exec.IsNil() {
+		return nil, ErrTxFailed
+	}
+	return exec.Array()
+}
+
+func (tx *Tx) unwatch(ctx context.Context) error {
+	_, err := tx.Do(ctx, "UNWATCH")
+	return err
+}
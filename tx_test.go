@@ -0,0 +1,137 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClient_Watch(t *testing.T) {
+	t.Run("check and set", func(t *testing.T) {
+		var sent []string
+		SendFunc = func(command string) error {
+			sent = append(sent, command)
+			return nil
+		}
+		// WATCH, GET, MULTI, SET, EXEC
+		ReceiveFunc = replySequence("OK", "5", "OK", "QUEUED", []interface{}{"OK"})
+		client := newMockClient(2, "password")
+
+		err := client.Watch(context.Background(), func(tx *Tx) error {
+			reply, err := tx.Do(context.Background(), "GET", "counter")
+			if err != nil {
+				return err
+			}
+			n, _ := reply.Int()
+			replies, err := tx.Exec(context.Background(), func(pipe *Pipeline) {
+				pipe.Do("SET", "counter", n+1)
+			})
+			if err != nil {
+				return err
+			}
+			if len(replies) != 1 {
+				t.Errorf("Exec got %d replies, want 1", len(replies))
+			}
+			return nil
+		}, "counter")
+		if err != nil {
+			t.Fatalf("Watch returned error: %s", err)
+		}
+		if len(sent) != 5 || !strings.Contains(sent[0], "WATCH") || !strings.Contains(sent[3], "$1\r\n6\r\n") {
+			t.Errorf("Watch sent unexpected commands: %q", sent)
+		}
+	})
+
+	t.Run("retries when the transaction is aborted", func(t *testing.T) {
+		SendFunc = func(command string) error {
+			return nil
+		}
+		// first attempt: WATCH, MULTI, INCR, EXEC (nil => aborted), second attempt succeeds
+		ReceiveFunc = replySequence("OK", "OK", "QUEUED", nil, "OK", "OK", "QUEUED", []interface{}{int64(1)})
+		client := newMockClient(2, "password")
+
+		attempts := 0
+		err := client.Watch(context.Background(), func(tx *Tx) error {
+			attempts++
+			_, err := tx.Exec(context.Background(), func(pipe *Pipeline) {
+				pipe.Do("INCR", "counter")
+			})
+			return err
+		}, "counter")
+		if err != nil {
+			t.Fatalf("Watch returned error: %s", err)
+		}
+		if attempts != 2 {
+			t.Errorf("Watch ran fn %d times, want 2", attempts)
+		}
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		SendFunc = func(command string) error {
+			return nil
+		}
+		ReceiveFunc = func() (interface{}, error) {
+			return "OK", nil
+		}
+		client := newMockClient(2, "password")
+
+		attempts := 0
+		err := client.Watch(context.Background(), func(tx *Tx) error {
+			attempts++
+			return ErrTxFailed
+		}, "counter")
+		if !errors.Is(err, ErrTxFailed) {
+			t.Errorf("Watch got = %v, want ErrTxFailed", err)
+		}
+		if attempts != maxWatchRetries {
+			t.Errorf("Watch ran fn %d times, want %d", attempts, maxWatchRetries)
+		}
+	})
+
+	t.Run("unwatches when fn fails", func(t *testing.T) {
+		var sent []string
+		SendFunc = func(command string) error {
+			sent = append(sent, command)
+			return nil
+		}
+		ReceiveFunc = func() (interface{}, error) {
+			return "OK", nil
+		}
+		client := newMockClient(2, "password")
+
+		fnErr := errors.New("fn failed")
+		err := client.Watch(context.Background(), func(tx *Tx) error {
+			return fnErr
+		}, "counter")
+		if !errors.Is(err, fnErr) {
+			t.Errorf("Watch got = %v, want %v", err, fnErr)
+		}
+		if len(sent) != 2 || !strings.Contains(sent[1], "UNWATCH") {
+			t.Errorf("Watch sent %q, want WATCH then UNWATCH", sent)
+		}
+	})
+
+	t.Run("closes the connection when fn panics", func(t *testing.T) {
+		client, servers := newPipeClient("")
+		client.pool = newPool(client.newConn, &Options{MaxActive: 1, MaxIdle: 1, PoolTimeout: DefaultPoolTimeout})
+		defer client.Close()
+		go func() {
+			expectCommand(t, <-servers, "WATCH", "+OK\r\n")
+		}()
+
+		func() {
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Errorf("Watch recovered %v, want the panic of fn", r)
+				}
+			}()
+			_ = client.Watch(context.Background(), func(tx *Tx) error {
+				panic("boom")
+			}, "counter")
+		}()
+		if stats := client.PoolStats(); stats.IdleConns != 0 || stats.ActiveConns != 0 {
+			t.Errorf("PoolStats() got %+v, want the watching connection closed", stats)
+		}
+	})
+}